@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnswerProfileController serves the template question catalogue and each user's saved answers to it
+// A guardian reaches a dependent's answers with ?user_id=
+type AnswerProfileController struct {
+	answerProfileUseCase usecases.AnswerProfileUseCase
+}
+
+func NewAnswerProfileController(uc usecases.AnswerProfileUseCase) *AnswerProfileController {
+	return &AnswerProfileController{answerProfileUseCase: uc}
+}
+
+// ListQuestions returns the template questions a QA pair can name with question_id
+func (pc *AnswerProfileController) ListQuestions(c *gin.Context) {
+	c.JSON(http.StatusOK, domain.QuestionTemplates())
+}
+
+// GetAnswers returns the authenticated user's saved answers
+func (pc *AnswerProfileController) GetAnswers(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	profile, err := pc.answerProfileUseCase.GetAnswerProfile(c.Request.Context(), actorID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SaveAnswers adds or replaces saved answers; answers not in the request are kept
+func (pc *AnswerProfileController) SaveAnswers(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req struct {
+		Answers []domain.ProfileAnswer `json:"answers"`
+	}
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	profile, err := pc.answerProfileUseCase.SaveProfileAnswers(c.Request.Context(), actorID, ownerID, req.Answers)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Answers saved successfully",
+		"profile": profile,
+	})
+}
+
+// DeleteAnswer forgets one saved answer
+func (pc *AnswerProfileController) DeleteAnswer(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := pc.answerProfileUseCase.DeleteProfileAnswer(c.Request.Context(), actorID, ownerID, c.Param("question_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Answer deleted successfully"})
+}
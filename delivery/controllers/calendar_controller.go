@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarController serves flights as iCalendar: single-flight downloads and a per-user subscription feed
+type CalendarController struct {
+	calendarUseCase usecases.CalendarUseCase
+	publicURL       string
+	cardURL         string
+}
+
+// NewCalendarController builds feed links from publicURL, or from each request when it is empty;
+// cardURL is the answer-card link placed in every event, with {id} replaced by the flight ID
+func NewCalendarController(uc usecases.CalendarUseCase, publicURL, cardURL string) *CalendarController {
+	return &CalendarController{
+		calendarUseCase: uc,
+		publicURL:       strings.TrimRight(publicURL, "/"),
+		cardURL:         cardURL,
+	}
+}
+
+// ExportFlight downloads one of the authenticated user's flights, or a dependent's with ?user_id=, as an
+// .ics file
+func (cc *CalendarController) ExportFlight(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	flight, err := cc.calendarUseCase.ExportFlight(c.Request.Context(), actorID, ownerID, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="flight-`+flight.ID+`.ics"`)
+	cc.writeCalendar(c, flight.Title, []domain.Flight{*flight})
+}
+
+// RotateCalendarToken issues a new feed URL; the previous one stops working immediately
+func (cc *CalendarController) RotateCalendarToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	token, err := cc.calendarUseCase.RotateCalendarToken(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The token is shown only here; the server keeps just its hash
+	feedURL := baseURL(c, cc.publicURL) + "/calendar/" + token + ".ics"
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Calendar feed issued; any previous feed URL no longer works",
+		"token":      token,
+		"feed_url":   feedURL,
+		"webcal_url": "webcal://" + feedURL[strings.Index(feedURL, "://")+3:],
+	})
+}
+
+// RevokeCalendarToken disables the authenticated user's feed URL
+func (cc *CalendarController) RevokeCalendarToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	if err := cc.calendarUseCase.RevokeCalendarToken(c.Request.Context(), userID.(string)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+}
+
+// Feed serves the upcoming flights of the token's owner; calendar clients cannot send an Authorization
+// header, so the secret token in the path is the only credential
+func (cc *CalendarController) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	flights, err := cc.calendarUseCase.UpcomingFlights(c.Request.Context(), token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("Referrer-Policy", "no-referrer")
+	cc.writeCalendar(c, "PassMe flights", flights)
+}
+
+func (cc *CalendarController) writeCalendar(c *gin.Context, name string, flights []domain.Flight) {
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(renderCalendar(name, flights, cc.cardURL, time.Now())))
+}
+
+// baseURL is the configured public URL, or the scheme and host this request arrived on
+func baseURL(c *gin.Context, publicURL string) string {
+	if publicURL != "" {
+		return publicURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// etag renders a resource version as a strong entity tag
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// notModified sets the ETag of the version about to be sent. When If-None-Match already names it, it answers
+// 304 and reports true so the handler stops without sending the body
+func notModified(c *gin.Context, version int64) bool {
+	tag := etag(version)
+	c.Header("ETag", tag)
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		// If-None-Match compares weakly, so W/"3" matches "3"
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch reads the version a write must match from If-Match. Zero means the header is absent or * and the
+// write is unconditional; anything but a single strong tag from etag can never match, so it fails outright
+func ifMatch(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, domain.ErrPreconditionFailed
+	}
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, domain.ErrPreconditionFailed
+	}
+	return version, nil
+}
+
+// precondition reports a version conflict on a write made under If-Match as the failed precondition
+func precondition(err error, version int64) error {
+	if version != 0 && errors.Is(err, domain.ErrVersionConflict) {
+		return domain.ErrPreconditionFailed
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func init() {
+	// Report validation failures by their JSON names rather than Go field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindingError converts a request binding failure into a validation error with per-field details
+func bindingError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		fields := make(map[string]string, len(fieldErrs))
+		for _, fe := range fieldErrs {
+			fields[fe.Field()] = fe.Tag()
+		}
+		return domain.NewValidationError("invalid request body", fields)
+	}
+	return &domain.Error{Kind: domain.KindInvalid, Code: domain.ErrValidation.Code, Message: "malformed request body", Err: err}
+}
+
+var tracer = otel.Tracer("github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers")
+
+// bindJSON decodes the request body in its own span so slow or malformed payloads show up in traces
+func bindJSON(c *gin.Context, obj any) error {
+	_, span := tracer.Start(c.Request.Context(), "BindJSON")
+	defer span.End()
+
+	if err := c.ShouldBindJSON(obj); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid request body")
+		return bindingError(err)
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
@@ -10,155 +13,465 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxBoardingPassUpload bounds boarding-pass uploads; phone screenshots and scans are well under it
+const maxBoardingPassUpload = 10 << 20
+
+// maxItineraryUpload bounds itinerary uploads at the message size mail providers accept
+const maxItineraryUpload = 25 << 20
+
 type FlightController struct {
 	flightUseCase usecases.FlightUseCase
+	importUseCase usecases.ImportUseCase
 }
 
-func NewFlightController(uc usecases.FlightUseCase) *FlightController {
+func NewFlightController(uc usecases.FlightUseCase, importUC usecases.ImportUseCase) *FlightController {
 	return &FlightController{
 		flightUseCase: uc,
+		importUseCase: importUC,
 	}
 }
 
-// CreateFlight handles creating a new flight
+// flightRequest is the body of CreateFlight and UpdateFlight. QA pairs may name a template question with
+// question_id and leave the answer blank to take it from the answer profile
+type flightRequest struct {
+	domain.Flight
+	// SaveToProfile writes the flight's template answers back to the answer profile
+	SaveToProfile bool `json:"save_to_profile"`
+}
+
+// CreateFlight handles creating a new flight. A guardian creates one for a dependent with ?user_id=
 func (fc *FlightController) CreateFlight(c *gin.Context) {
-	var flight domain.Flight
-	if err := c.ShouldBindJSON(&flight); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req flightRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	flight := req.Flight
+	if err := validateFlight(&flight); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Validate required fields
-	if flight.Title == "" || flight.FromCountry == "" || flight.ToCountry == "" || flight.Language == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required flight fields"})
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	flight.UserID = ownerID
 
-	// Validate that we have the correct number of questions/answers
-	if len(flight.QA) != 5 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly 5 question-answer pairs are required"})
+	// Default to current time if neither a date nor a departure time is provided
+	if flight.Date.IsZero() && flight.DepartureTime == nil {
+		flight.Date = time.Now()
+	}
+
+	if err := fc.flightUseCase.AddFlight(c.Request.Context(), actorID, &flight, req.SaveToProfile); err != nil {
+		c.Error(err)
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Flight created successfully",
+		"flight":  flightResponse(flight),
+	})
+}
+
+// UpdateFlight replaces a flight the authenticated user may manage; it keeps its owner. A guardian names a
+// dependent's flight with ?user_id=. With If-Match the flight must still be at that version or the
+// update fails with 412
+func (fc *FlightController) UpdateFlight(c *gin.Context) {
+	var req flightRequest
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	flight := req.Flight
+	if err := validateFlight(&flight); err != nil {
+		c.Error(err)
+		return
+	}
+	if flight.Date.IsZero() && flight.DepartureTime == nil {
+		c.Error(domain.NewValidationError("Missing required flight fields", map[string]string{"date": "required"}))
 		return
 	}
-	flight.UserID = userID.(string)
 
-	// Default to current time if no date provided
-	if flight.Date.IsZero() {
-		flight.Date = time.Now()
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	flight.ID = c.Param("id")
+	flight.UserID = ownerID
+	// If-Match takes precedence over a version in the body
+	if version != 0 {
+		flight.Version = version
 	}
 
-	if err := fc.flightUseCase.AddFlight(&flight); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := fc.flightUseCase.UpdateFlight(c.Request.Context(), actorID, &flight, req.SaveToProfile); err != nil {
+		c.Error(precondition(err, version))
 		return
 	}
 
+	c.Header("ETag", etag(flight.Version))
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Flight created successfully",
-		"flight":  flight,
+		"message": "Flight updated successfully",
+		"flight":  flightResponse(flight),
 	})
 }
 
-// GetFlightByID retrieves a flight by its ID and sends the response
-func (fc *FlightController) GetFlightByID(c *gin.Context) {
-	id := c.Param("id")
+// cloneRequest mirrors usecases.FlightOverrides field for field so it converts directly
+type cloneRequest struct {
+	Title              *string     `json:"title"`
+	FromCountry        *string     `json:"from_country"`
+	ToCountry          *string     `json:"to_country"`
+	Language           *string     `json:"language"`
+	Date               *time.Time  `json:"date"`
+	QA                 []domain.QA `json:"qa"`
+	FlightNumber       *string     `json:"flight_number"`
+	OriginAirport      *string     `json:"origin_airport"`
+	DestinationAirport *string     `json:"destination_airport"`
+	DepartureTime      *time.Time  `json:"departure_time"`
+	DepartureTimezone  *string     `json:"departure_timezone"`
+	ArrivalTime        *time.Time  `json:"arrival_time"`
+	ArrivalTimezone    *string     `json:"arrival_timezone"`
+}
 
-	// Get user ID from the authenticated user
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+// CloneFlight copies a flight the authenticated user may manage as a new flight for the same traveller.
+// The optional body overrides fields of the copy; "review" lists the QA pairs whose answers depend on the
+// travel dates
+func (fc *FlightController) CloneFlight(c *gin.Context) {
+	var req cloneRequest
+	if c.Request.ContentLength != 0 {
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if req.QA != nil && len(req.QA) != 5 {
+		c.Error(domain.NewValidationError("Exactly 5 question-answer pairs are required", map[string]string{"qa": "len=5"}))
 		return
 	}
 
-	flight, err := fc.flightUseCase.FetchFlightByID(id)
+	actorID, ownerID, err := subject(c)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flight not found"})
+		c.Error(err)
 		return
 	}
 
-	// Check if the flight belongs to the authenticated user
-	if flight.UserID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this flight"})
+	clone, err := fc.flightUseCase.CloneFlight(c.Request.Context(), actorID, ownerID, c.Param("id"), usecases.FlightOverrides(req))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
+	review := make([]gin.H, 0, len(clone.Review))
+	for _, i := range clone.Review {
+		qa := clone.Flight.QA[i]
+		review = append(review, gin.H{"index": i, "question_id": qa.QuestionID, "question": qa.Question})
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"id":           flight.ID,
-		"title":        flight.Title,
-		"from_country": flight.FromCountry,
-		"to_country":   flight.ToCountry,
-		"date":         flight.Date,
-		"user_id":      flight.UserID,
-		"language":     flight.Language, // <- Added language field in the response
-		"qa":           flight.QA,
+		"message": "Flight cloned successfully",
+		"flight":  flightResponse(clone.Flight),
+		"review":  review,
 	})
 }
 
-// GetUserFlights retrieves all flights for the authenticated user
+// validateFlight checks the fields a flight needs before it reaches the use case
+func validateFlight(flight *domain.Flight) error {
+	missing := map[string]string{}
+	if flight.Title == "" {
+		missing["title"] = "required"
+	}
+	// An airport code stands in for its country, which the use case derives from the dataset
+	if flight.FromCountry == "" && flight.OriginAirport == "" {
+		missing["from_country"] = "required"
+	}
+	if flight.ToCountry == "" && flight.DestinationAirport == "" {
+		missing["to_country"] = "required"
+	}
+	if flight.Language == "" {
+		missing["language"] = "required"
+	}
+	if len(missing) > 0 {
+		return domain.NewValidationError("Missing required flight fields", missing)
+	}
+
+	// Validate that we have the correct number of questions/answers
+	if len(flight.QA) != 5 {
+		return domain.NewValidationError("Exactly 5 question-answer pairs are required", map[string]string{"qa": "len=5"})
+	}
+	return nil
+}
+
+// GetFlightByID retrieves a flight by its ID and sends the response, tagged with the flight's version. A
+// client that already holds that version gets 304 through If-None-Match
+func (fc *FlightController) GetFlightByID(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	flight, err := fc.flightUseCase.FetchFlightByID(c.Request.Context(), actorID, ownerID, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if notModified(c, flight.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, flightResponse(*flight))
+}
+
+// GetUserFlights retrieves all flights for the authenticated user, or for a dependent with ?user_id=
 func (fc *FlightController) GetUserFlights(c *gin.Context) {
-	// Get user ID from the authenticated user
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	flights, err := fc.flightUseCase.FetchFlightsByUserID(userID.(string))
+	flights, err := fc.flightUseCase.FetchFlightsByUserID(c.Request.Context(), actorID, ownerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Send the language field as part of each flight
 	var flightResponses []gin.H
 	for _, flight := range flights {
-		flightResponses = append(flightResponses, gin.H{
-			"id":           flight.ID,
-			"title":        flight.Title,
-			"from_country": flight.FromCountry,
-			"to_country":   flight.ToCountry,
-			"date":         flight.Date,
-			"user_id":      flight.UserID,
-			"language":     flight.Language, 
-			"qa":           flight.QA,
-		})
+		flightResponses = append(flightResponses, flightResponse(flight))
 	}
 
 	c.JSON(http.StatusOK, flightResponses)
 }
 
-// DeleteFlight handles the deletion of a flight by its ID
+// GetExpiringFlights lists the flights the user has been warned will be purged soon, so the app can show
+// the warning; a guardian sees a dependent's with ?user_id=
+func (fc *FlightController) GetExpiringFlights(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	flights, err := fc.flightUseCase.FetchExpiringFlights(c.Request.Context(), actorID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	responses := make([]gin.H, 0, len(flights))
+	for _, flight := range flights {
+		responses = append(responses, flightResponse(flight))
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// DeleteFlight handles the deletion of a flight by its ID, only at the If-Match version when one is given
 func (fc *FlightController) DeleteFlight(c *gin.Context) {
-	id := c.Param("id")
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	// Get user ID from the authenticated user
+	if err := fc.flightUseCase.DeleteFlight(c.Request.Context(), actorID, ownerID, c.Param("id"), version); err != nil {
+		c.Error(precondition(err, version))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Flight deleted successfully"})
+}
+
+// ImportBoardingPass reads a boarding pass and returns draft flights for the traveller to review and save.
+// It accepts JSON {"data": "<BCBP text>"}, a multipart form with a "data" field or an "image" file,
+// or a raw PNG, JPEG or GIF body
+func (fc *FlightController) ImportBoardingPass(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.Error(domain.ErrUnauthenticated)
 		return
 	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBoardingPassUpload)
+
+	var data string
+	var image []byte
+	switch contentType := c.ContentType(); {
+	case contentType == "multipart/form-data":
+		data = c.PostForm("data")
+		if file, err := c.FormFile("image"); err == nil {
+			f, err := file.Open()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			defer f.Close()
+			if image, err = io.ReadAll(f); err != nil {
+				c.Error(err)
+				return
+			}
+		} else if !errors.Is(err, http.ErrMissingFile) {
+			c.Error(uploadError("image", err))
+			return
+		}
+	case strings.HasPrefix(contentType, "image/"):
+		var err error
+		if image, err = io.ReadAll(c.Request.Body); err != nil {
+			c.Error(uploadError("image", err))
+			return
+		}
+	default:
+		var req struct {
+			Data string `json:"data" binding:"required"`
+		}
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+		data = req.Data
+	}
 
-	// Check if the flight belongs to the authenticated user
-	flight, err := fc.flightUseCase.FetchFlightByID(id)
+	result, err := fc.importUseCase.ImportBoardingPass(c.Request.Context(), userID.(string), data, image)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Flight not found"})
+		c.Error(err)
 		return
 	}
 
-	if flight.UserID != userID.(string) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this flight"})
+	flights := make([]gin.H, 0, len(result.Flights))
+	for _, flight := range result.Flights {
+		flights = append(flights, flightResponse(flight))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"boarding_pass": result.BoardingPass,
+		"flights":       flights,
+	})
+}
+
+// ImportItinerary reads an airline confirmation email or calendar file and returns draft flights for the
+// traveller to review and save. It accepts a multipart form with a "file" field, or the raw .eml or .ics body
+func (fc *FlightController) ImportItinerary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
 		return
 	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxItineraryUpload)
 
-	// Delete the flight
-	if err := fc.flightUseCase.DeleteFlight(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var file []byte
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.Error(uploadError("file", err))
+			return
+		}
+		f, err := header.Open()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer f.Close()
+		if file, err = io.ReadAll(f); err != nil {
+			c.Error(uploadError("file", err))
+			return
+		}
+	} else {
+		var err error
+		if file, err = io.ReadAll(c.Request.Body); err != nil {
+			c.Error(uploadError("file", err))
+			return
+		}
+	}
+
+	result, err := fc.importUseCase.ImportItinerary(c.Request.Context(), userID.(string), file)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Flight deleted successfully"})
+	flights := make([]gin.H, 0, len(result.Flights))
+	for _, flight := range result.Flights {
+		flights = append(flights, flightResponse(flight))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"segments": result.Segments,
+		"flights":  flights,
+	})
+}
+
+// uploadError reports an oversized or missing upload as a validation failure rather than a server error
+func uploadError(field string, err error) error {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return domain.NewValidationError("Upload is too large", map[string]string{field: "max_bytes"})
+	case errors.Is(err, http.ErrMissingFile):
+		return domain.NewValidationError("Upload is missing", map[string]string{field: "required"})
+	}
+	return domain.NewValidationError("Upload could not be read", map[string]string{field: "unreadable"})
+}
+
+// flightResponse renders a flight, including when it will be purged and, once its owner has been warned,
+// the warning to show; departure and arrival are also given as wall-clock times in their own timezones
+func flightResponse(flight domain.Flight) gin.H {
+	return gin.H{
+		"id":                  flight.ID,
+		"title":               flight.Title,
+		"from_country":        flight.FromCountry,
+		"to_country":          flight.ToCountry,
+		"date":                flight.Date,
+		"user_id":             flight.UserID,
+		"language":            flight.Language,
+		"qa":                  flight.QA,
+		"expires_on":          flight.ExpiresAt,
+		"expiry_warned":       flight.ExpiryWarnedAt != nil,
+		"expiry_warning":      expiryWarning("flight", flight.ExpiresAt, flight.ExpiryWarnedAt),
+		"flight_number":       flight.FlightNumber,
+		"airline_code":        flight.AirlineCode,
+		"origin_airport":      flight.OriginAirport,
+		"destination_airport": flight.DestinationAirport,
+		"departure_time":      flight.DepartureTime,
+		"departure_timezone":  flight.DepartureTimezone,
+		"departure_local":     localTime(flight.DepartureTime, flight.DepartureTimezone),
+		"arrival_time":        flight.ArrivalTime,
+		"arrival_timezone":    flight.ArrivalTimezone,
+		"arrival_local":       localTime(flight.ArrivalTime, flight.ArrivalTimezone),
+		"version":             flight.Version,
+		"updated_at":          flight.UpdatedAt,
+	}
+}
+
+// localTime formats t as RFC 3339 in the named zone, or returns nil when either is missing
+func localTime(t *time.Time, timezone string) any {
+	if t == nil || timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// expiryWarning is the notice shown once the retention sweep has warned about a flight or trip, or nil before then
+func expiryWarning(kind string, expiresAt, warnedAt *time.Time) any {
+	if warnedAt == nil || expiresAt == nil {
+		return nil
+	}
+	return gin.H{
+		"expires_on": expiresAt,
+		"warned_at":  warnedAt,
+		"message": "This " + kind + " and its answers will be deleted on " + expiresAt.UTC().Format("2 January 2006") +
+			" under your retention setting. Update the " + kind + " or change the setting to keep it longer.",
+	}
 }
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessCheck reports whether a dependency can currently serve traffic
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthController struct {
+	checks   []ReadinessCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealthController(timeout time.Duration, checks ...ReadinessCheck) *HealthController {
+	return &HealthController{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// SetDraining makes readiness fail so the orchestrator stops routing traffic during shutdown
+func (hc *HealthController) SetDraining() {
+	hc.draining.Store(true)
+}
+
+// Liveness reports that the process is up and able to handle requests
+func (hc *HealthController) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness runs every dependency check and reports 503 if any of them fails. The endpoint is public, so
+// the reason a check failed is only logged
+func (hc *HealthController) Readiness(c *gin.Context) {
+	if hc.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), hc.timeout)
+	defer cancel()
+
+	status := http.StatusOK
+	results := gin.H{}
+	for _, check := range hc.checks {
+		if err := check.Check(ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			results[check.Name] = "unavailable"
+			slog.Default().WarnContext(ctx, "readiness check failed", "layer", "http", "check", check.Name, "error", err)
+			continue
+		}
+		results[check.Name] = "ok"
+	}
+
+	state := "ok"
+	if status != http.StatusOK {
+		state = "unavailable"
+	}
+	c.JSON(status, gin.H{"status": state, "checks": results})
+}
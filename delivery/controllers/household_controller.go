@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"net/http"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HouseholdController manages households, through which guardians act for their dependents
+type HouseholdController struct {
+	householdUseCase usecases.HouseholdUseCase
+}
+
+func NewHouseholdController(uc usecases.HouseholdUseCase) *HouseholdController {
+	return &HouseholdController{householdUseCase: uc}
+}
+
+// CreateHousehold creates a household with the authenticated user as its guardian
+func (hc *HouseholdController) CreateHousehold(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	household, err := hc.householdUseCase.CreateHousehold(c.Request.Context(), userID.(string), req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Household created successfully",
+		"household": household,
+	})
+}
+
+// ListHouseholds returns the households the authenticated user belongs to or is invited to
+func (hc *HouseholdController) ListHouseholds(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	households, err := hc.householdUseCase.ListHouseholds(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if households == nil {
+		households = []domain.Household{}
+	}
+
+	c.JSON(http.StatusOK, households)
+}
+
+func (hc *HouseholdController) GetHousehold(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	household, err := hc.householdUseCase.GetHousehold(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+// AddDependent adds someone without an account of their own. Their user_id is then passed as ?user_id= to
+// the flight and answer endpoints
+func (hc *HouseholdController) AddDependent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	member, err := hc.householdUseCase.AddDependent(c.Request.Context(), userID.(string), c.Param("id"), req.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Dependent added successfully",
+		"member":  member,
+	})
+}
+
+// InviteMember invites an existing user by username with a role
+func (hc *HouseholdController) InviteMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	var req struct {
+		Username string               `json:"username"`
+		Role     domain.HouseholdRole `json:"role"`
+	}
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	member, err := hc.householdUseCase.InviteMember(c.Request.Context(), userID.(string), c.Param("id"), req.Username, req.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member invited successfully",
+		"member":  member,
+	})
+}
+
+// AcceptInvitation makes the authenticated user an active member of a household that invited them
+func (hc *HouseholdController) AcceptInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	if err := hc.householdUseCase.AcceptInvitation(c.Request.Context(), userID.(string), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted successfully"})
+}
+
+// RemoveMember removes a member or declines an invitation; users remove themselves to leave
+func (hc *HouseholdController) RemoveMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(domain.ErrUnauthenticated)
+		return
+	}
+
+	if err := hc.householdUseCase.RemoveMember(c.Request.Context(), userID.(string), c.Param("id"), c.Param("user_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// subject returns who is asking and whose data the request is about: the authenticated user's own, or that
+// of the user named by ?user_id=. The use case decides whether the actor may act for that user
+func subject(c *gin.Context) (actorID, ownerID string, err error) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return "", "", domain.ErrUnauthenticated
+	}
+	actorID = userID.(string)
+	if ownerID = c.Query("user_id"); ownerID == "" {
+		ownerID = actorID
+	}
+	return actorID, ownerID, nil
+}
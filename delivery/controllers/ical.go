@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// iCalendar (RFC 5545) rendering of flights. Answers never appear in events: calendars are synced to
+// third-party services, so events only link back to the answer card.
+
+const (
+	icsProductID   = "-//PassMe//Flights//EN"
+	icsDateTime    = "20060102T150405"
+	icsDate        = "20060102"
+	icsLineOctets  = 75
+	icsCalendarTTL = "PT1H"
+)
+
+// calendar collects the lines of one VCALENDAR object
+type calendar struct {
+	b strings.Builder
+}
+
+// renderCalendar writes a complete VCALENDAR with a VTIMEZONE for every zone its events use
+func renderCalendar(name string, flights []domain.Flight, cardURL string, now time.Time) string {
+	var cal calendar
+	cal.line("BEGIN", "VCALENDAR")
+	cal.line("VERSION", "2.0")
+	cal.line("PRODID", icsProductID)
+	cal.line("CALSCALE", "GREGORIAN")
+	cal.line("METHOD", "PUBLISH")
+	cal.line("X-WR-CALNAME", escapeText(name))
+	cal.line("REFRESH-INTERVAL;VALUE=DURATION", icsCalendarTTL)
+	cal.line("X-PUBLISHED-TTL", icsCalendarTTL)
+
+	for _, zone := range calendarZones(flights) {
+		cal.timezone(zone.loc, zone.from, zone.to)
+	}
+	for _, flight := range flights {
+		cal.event(flight, cardURL, now)
+	}
+	cal.line("END", "VCALENDAR")
+	return cal.b.String()
+}
+
+// event writes one VEVENT; flights with a departure time are zoned, date-only flights are all-day events
+func (cal *calendar) event(flight domain.Flight, cardURL string, now time.Time) {
+	cal.line("BEGIN", "VEVENT")
+	cal.line("UID", flight.ID+"@passme")
+	cal.line("DTSTAMP", now.UTC().Format(icsDateTime)+"Z")
+	if flight.DepartureTime != nil {
+		cal.dateTime("DTSTART", *flight.DepartureTime, flight.DepartureTimezone)
+		if flight.ArrivalTime != nil && !flight.ArrivalTime.Before(*flight.DepartureTime) {
+			cal.dateTime("DTEND", *flight.ArrivalTime, flight.ArrivalTimezone)
+		}
+	} else {
+		day := flight.Date.UTC()
+		cal.line("DTSTART;VALUE=DATE", day.Format(icsDate))
+		cal.line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format(icsDate))
+		cal.line("TRANSP", "TRANSPARENT")
+	}
+	cal.line("SUMMARY", escapeText(flight.Title))
+	if location := joinNonEmpty(", ", flight.OriginAirport, flight.FromCountry); location != "" {
+		cal.line("LOCATION", escapeText(location))
+	}
+
+	link := strings.ReplaceAll(cardURL, "{id}", flight.ID)
+	route := fmt.Sprintf("%s to %s", joinNonEmpty(" ", flight.FromCountry, parenthesise(flight.OriginAirport)),
+		joinNonEmpty(" ", flight.ToCountry, parenthesise(flight.DestinationAirport)))
+	description := joinNonEmpty("\n", joinNonEmpty(" ", "Flight", flight.FlightNumber, "from", route), "Answer card: "+link)
+	cal.line("DESCRIPTION", escapeText(description))
+	cal.line("URL;VALUE=URI", link)
+	cal.line("END", "VEVENT")
+}
+
+// dateTime writes a local time with its TZID, or a UTC time when the flight has no zone
+func (cal *calendar) dateTime(name string, t time.Time, timezone string) {
+	if loc, err := time.LoadLocation(timezone); err == nil && timezone != "" && timezone != "UTC" {
+		cal.line(name+";TZID="+timezone, t.In(loc).Format(icsDateTime))
+		return
+	}
+	cal.line(name, t.UTC().Format(icsDateTime)+"Z")
+}
+
+// calendarZone is an IANA zone and the span of event times that need its definition
+type calendarZone struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// calendarZones lists the zones used by zoned events, sorted by name for stable output
+func calendarZones(flights []domain.Flight) []calendarZone {
+	zones := map[string]*calendarZone{}
+	add := func(t *time.Time, timezone string) {
+		if t == nil || timezone == "" || timezone == "UTC" {
+			return
+		}
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return
+		}
+		zone, ok := zones[timezone]
+		if !ok {
+			zones[timezone] = &calendarZone{loc: loc, from: *t, to: *t}
+			return
+		}
+		if t.Before(zone.from) {
+			zone.from = *t
+		}
+		if t.After(zone.to) {
+			zone.to = *t
+		}
+	}
+	for _, flight := range flights {
+		if flight.DepartureTime == nil {
+			continue
+		}
+		add(flight.DepartureTime, flight.DepartureTimezone)
+		add(flight.ArrivalTime, flight.ArrivalTimezone)
+	}
+
+	result := make([]calendarZone, 0, len(zones))
+	for _, zone := range zones {
+		result = append(result, *zone)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].loc.String() < result[j].loc.String() })
+	return result
+}
+
+// timezone writes a VTIMEZONE holding every offset period that overlaps [from, to], computed from Go's
+// zone database so clients do not have to share our idea of the zone's rules
+func (cal *calendar) timezone(loc *time.Location, from, to time.Time) {
+	cal.line("BEGIN", "VTIMEZONE")
+	cal.line("TZID", loc.String())
+	t := from.In(loc)
+	for {
+		start, end := t.ZoneBounds()
+		name, offset := t.Zone()
+		previous := offset
+		onset := "19700101T000000"
+		if !start.IsZero() {
+			_, previous = start.Add(-time.Second).Zone()
+			onset = start.In(time.FixedZone("", previous)).Format(icsDateTime)
+		}
+
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		cal.line("BEGIN", kind)
+		cal.line("DTSTART", onset)
+		cal.line("TZOFFSETFROM", formatOffset(previous))
+		cal.line("TZOFFSETTO", formatOffset(offset))
+		if name != "" && !strings.HasPrefix(name, "+") && !strings.HasPrefix(name, "-") {
+			cal.line("TZNAME", escapeText(name))
+		}
+		cal.line("END", kind)
+
+		if end.IsZero() || end.After(to) {
+			break
+		}
+		t = end.In(loc)
+	}
+	cal.line("END", "VTIMEZONE")
+}
+
+// formatOffset renders a UTC offset in seconds as +HHMM, or +HHMMSS when it has seconds
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	if seconds%60 != 0 {
+		return fmt.Sprintf("%c%02d%02d%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds/60%60)
+}
+
+// line writes a content line terminated by CRLF, folding it so no physical line exceeds 75 octets
+// and no UTF-8 sequence is split
+func (cal *calendar) line(name, value string) {
+	content := name + ":" + value
+	limit := icsLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		cal.b.WriteString(content[:cut])
+		cal.b.WriteString("\r\n ")
+		content = content[cut:]
+		limit = icsLineOctets - 1 // the leading space of a continuation line counts
+	}
+	cal.b.WriteString(content)
+	cal.b.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes a TEXT value as RFC 5545 section 3.3.11 requires
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	kept := parts[:0:0]
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+func parenthesise(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "(" + value + ")"
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shareCard is the page a share link's visitor sees in a browser: the flight and its answers, laid out to
+// be read off a phone at a counter
+var shareCard = template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0 auto; max-width: 40em; padding: 1em; color: #111; }
+h1 { font-size: 1.4em; margin-bottom: 0.2em; }
+.route { color: #555; margin-top: 0; }
+dt { font-weight: 600; margin-top: 1em; }
+dd { margin: 0.2em 0 0; font-size: 1.2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="route">{{if .FlightNumber}}{{.FlightNumber}} · {{end}}{{if .OriginAirport}}{{.OriginAirport}}{{else}}{{.FromCountry}}{{end}} → {{if .DestinationAirport}}{{.DestinationAirport}}{{else}}{{.ToCountry}}{{end}} · {{.Day}}</p>
+<dl>
+{{range .QA}}<dt>{{.Question}}</dt>
+<dd>{{.Answer}}</dd>
+{{end}}</dl>
+</body>
+</html>
+`))
+
+// renderShareCard writes the flight as shareCard; html/template escapes every field
+func renderShareCard(c *gin.Context, flight domain.Flight) {
+	day := flight.Date.Format("2 Jan 2006")
+	if local, ok := localTime(flight.DepartureTime, flight.DepartureTimezone).(string); ok {
+		day = local[:len("2006-01-02T15:04")]
+	}
+	data := struct {
+		domain.Flight
+		Day string
+	}{flight, day}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := shareCard.Execute(c.Writer, data); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShareController issues read-only flight links and serves them to visitors without an account
+type ShareController struct {
+	shareUseCase usecases.ShareUseCase
+	publicURL    string
+}
+
+// NewShareController builds share links from publicURL, or from each request when it is empty
+func NewShareController(uc usecases.ShareUseCase, publicURL string) *ShareController {
+	return &ShareController{
+		shareUseCase: uc,
+		publicURL:    strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// CreateShare issues a link to one of the authenticated user's flights, or a dependent's with ?user_id=
+func (sc *ShareController) CreateShare(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req struct {
+		Label     string     `json:"label"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	link, err := sc.shareUseCase.CreateShare(c.Request.Context(), actorID, ownerID, c.Param("id"), req.Label, req.ExpiresAt)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Share link created successfully",
+		"share":   sc.shareResponse(c, *link),
+	})
+}
+
+// ListShares returns the links issued for one of the authenticated user's flights with their access history
+func (sc *ShareController) ListShares(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	links, err := sc.shareUseCase.ListShares(c.Request.Context(), actorID, ownerID, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	shares := make([]gin.H, 0, len(links))
+	for _, link := range links {
+		shares = append(shares, sc.shareResponse(c, link))
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// RevokeShare stops one of a flight's links from working
+func (sc *ShareController) RevokeShare(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := sc.shareUseCase.RevokeShare(c.Request.Context(), actorID, ownerID, c.Param("id"), c.Param("share_id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked successfully"})
+}
+
+// Shared shows the flight behind a link, as an HTML card to browsers and as JSON otherwise. The token in
+// the path is the only credential
+func (sc *ShareController) Shared(c *gin.Context) {
+	flight, err := sc.shareUseCase.OpenShare(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Robots-Tag", "noindex")
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		renderShareCard(c, *flight)
+		return
+	}
+	c.JSON(http.StatusOK, sharedFlightResponse(*flight))
+}
+
+// shareResponse describes a link to its owner; dead links have no URL
+func (sc *ShareController) shareResponse(c *gin.Context, link usecases.SharedLink) gin.H {
+	share := link.Share
+	status := "active"
+	switch {
+	case share.RevokedAt != nil:
+		status = "revoked"
+	case !share.Active(time.Now()):
+		status = "expired"
+	}
+	response := gin.H{
+		"id":               share.ID,
+		"flight_id":        share.FlightID,
+		"label":            share.Label,
+		"status":           status,
+		"created_at":       share.CreatedAt,
+		"expires_at":       share.ExpiresAt,
+		"revoked_at":       share.RevokedAt,
+		"access_count":     share.AccessCount,
+		"last_accessed_at": share.LastAccessedAt,
+	}
+	if link.Token != "" {
+		response["token"] = link.Token
+		response["url"] = baseURL(c, sc.publicURL) + "/shared/" + link.Token
+	}
+	return response
+}
+
+// sharedFlightResponse is what a link's visitor sees: the journey and the answers, without the owner's
+// account or retention details
+func sharedFlightResponse(flight domain.Flight) gin.H {
+	return gin.H{
+		"title":               flight.Title,
+		"from_country":        flight.FromCountry,
+		"to_country":          flight.ToCountry,
+		"date":                flight.Date,
+		"language":            flight.Language,
+		"qa":                  flight.QA,
+		"flight_number":       flight.FlightNumber,
+		"airline_code":        flight.AirlineCode,
+		"origin_airport":      flight.OriginAirport,
+		"destination_airport": flight.DestinationAirport,
+		"departure_local":     localTime(flight.DepartureTime, flight.DepartureTimezone),
+		"arrival_local":       localTime(flight.ArrivalTime, flight.ArrivalTimezone),
+	}
+}
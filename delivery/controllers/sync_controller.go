@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncController serves the endpoint the mobile app uses to catch up after working offline
+type SyncController struct {
+	syncUseCase usecases.SyncUseCase
+}
+
+func NewSyncController(uc usecases.SyncUseCase) *SyncController {
+	return &SyncController{syncUseCase: uc}
+}
+
+// syncMutation is one offline change in a sync request; flight is the full flight for create and update
+type syncMutation struct {
+	Op            usecases.SyncOp `json:"op"`
+	ClientRef     string          `json:"client_ref"`
+	FlightID      string          `json:"flight_id"`
+	Version       int64           `json:"version"`
+	Flight        *domain.Flight  `json:"flight"`
+	SaveToProfile bool            `json:"save_to_profile"`
+}
+
+// Sync applies a device's offline changes to the authenticated user's flights, or a dependent's with
+// ?user_id=, then returns the flight changes after the device's cursor. A conflicting mutation comes back
+// with the server's copy of the flight so the device can resolve it and resubmit against that version. With
+// full_resync set, the device's cursor was too old and the feed restarts from the beginning
+func (sc *SyncController) Sync(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req struct {
+		Cursor    string         `json:"cursor"`
+		Limit     int            `json:"limit"`
+		Mutations []syncMutation `json:"mutations"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := bindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	mutations := make([]usecases.SyncMutation, 0, len(req.Mutations))
+	invalid := map[string]string{}
+	for i, m := range req.Mutations {
+		mutation := usecases.SyncMutation{
+			Op:            m.Op,
+			ClientRef:     m.ClientRef,
+			FlightID:      m.FlightID,
+			Version:       m.Version,
+			SaveToProfile: m.SaveToProfile,
+		}
+		if m.Op == usecases.SyncCreate || m.Op == usecases.SyncUpdate {
+			checkSyncedFlight(invalid, fmt.Sprintf("mutations[%d].flight", i), m)
+			if m.Flight != nil {
+				mutation.Flight = *m.Flight
+			}
+		}
+		mutations = append(mutations, mutation)
+	}
+	if len(invalid) > 0 {
+		c.Error(domain.NewValidationError("Invalid sync request", invalid))
+		return
+	}
+
+	result, err := sc.syncUseCase.Sync(c.Request.Context(), actorID, ownerID, req.Cursor, req.Limit, mutations)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results := make([]gin.H, 0, len(result.Results))
+	for _, outcome := range result.Results {
+		entry := gin.H{
+			"client_ref": outcome.ClientRef,
+			"op":         outcome.Op,
+			"flight_id":  outcome.FlightID,
+			"status":     outcome.Status,
+			"flight":     nil,
+		}
+		if outcome.Flight != nil {
+			entry["flight"] = flightResponse(*outcome.Flight)
+		}
+		if outcome.Err != nil {
+			entry["error"] = gin.H{"code": outcome.Err.Code, "message": outcome.Err.Message, "details": outcome.Err.Fields}
+		}
+		results = append(results, entry)
+	}
+	changes := make([]gin.H, 0, len(result.Changes))
+	for _, change := range result.Changes {
+		entry := gin.H{
+			"flight_id":  change.FlightID,
+			"version":    change.Version,
+			"updated_at": change.UpdatedAt,
+			"deleted":    change.Deleted,
+			"flight":     nil,
+		}
+		if change.Flight != nil {
+			entry["flight"] = flightResponse(*change.Flight)
+		}
+		changes = append(changes, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"results":     results,
+		"changes":     changes,
+		"cursor":      result.Cursor,
+		"has_more":    result.HasMore,
+		"full_resync": result.FullResync,
+	})
+}
+
+// checkSyncedFlight applies the checks CreateFlight and UpdateFlight make to the flight of a mutation,
+// reporting failures under the mutation's field path
+func checkSyncedFlight(invalid map[string]string, field string, m syncMutation) {
+	if m.Flight == nil {
+		invalid[field] = "required"
+		return
+	}
+	flight := *m.Flight
+	var domainErr *domain.Error
+	if err := validateFlight(&flight); errors.As(err, &domainErr) {
+		for name, rule := range domainErr.Fields {
+			invalid[field+"."+name] = rule
+		}
+	}
+	if m.Op == usecases.SyncUpdate && flight.Date.IsZero() && flight.DepartureTime == nil {
+		invalid[field+".date"] = "required"
+	}
+}
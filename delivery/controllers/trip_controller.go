@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TripController struct {
+	tripUseCase usecases.TripUseCase
+}
+
+func NewTripController(uc usecases.TripUseCase) *TripController {
+	return &TripController{
+		tripUseCase: uc,
+	}
+}
+
+// CreateTrip handles creating a new multi-leg trip. A guardian creates one for a dependent with ?user_id=
+func (tc *TripController) CreateTrip(c *gin.Context) {
+	var trip domain.Trip
+	if err := bindJSON(c, &trip); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := validateTrip(&trip); err != nil {
+		c.Error(err)
+		return
+	}
+
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	trip.ID = ""
+	trip.UserID = ownerID
+
+	if err := tc.tripUseCase.AddTrip(c.Request.Context(), actorID, &trip); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Trip created successfully",
+		"trip":    tripResponse(trip),
+	})
+}
+
+// GetTripByID retrieves a trip of the authenticated user, or of a dependent with ?user_id=
+func (tc *TripController) GetTripByID(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	trip, err := tc.tripUseCase.FetchTripByID(c.Request.Context(), actorID, ownerID, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if notModified(c, trip.Version) {
+		return
+	}
+	c.JSON(http.StatusOK, tripResponse(*trip))
+}
+
+// GetUserTrips retrieves all trips of the authenticated user, including single flights as one-leg trips
+func (tc *TripController) GetUserTrips(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	trips, err := tc.tripUseCase.FetchTripsByUserID(c.Request.Context(), actorID, ownerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	responses := make([]gin.H, 0, len(trips))
+	for _, trip := range trips {
+		responses = append(responses, tripResponse(trip))
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// UpdateTrip replaces the title and legs of a trip the authenticated user may manage. With If-Match, or a
+// version in the body, it only succeeds against that version
+func (tc *TripController) UpdateTrip(c *gin.Context) {
+	var trip domain.Trip
+	if err := bindJSON(c, &trip); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := validateTrip(&trip); err != nil {
+		c.Error(err)
+		return
+	}
+
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	trip.ID = c.Param("id")
+	trip.UserID = ownerID
+	// If-Match takes precedence over a version in the body
+	if version != 0 {
+		trip.Version = version
+	}
+
+	if err := tc.tripUseCase.UpdateTrip(c.Request.Context(), actorID, &trip); err != nil {
+		c.Error(precondition(err, version))
+		return
+	}
+	c.Header("ETag", etag(trip.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Trip updated successfully",
+		"trip":    tripResponse(trip),
+	})
+}
+
+// DeleteTrip removes a trip the authenticated user may manage, only at the If-Match version when one is given
+func (tc *TripController) DeleteTrip(c *gin.Context) {
+	actorID, ownerID, err := subject(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := tc.tripUseCase.DeleteTrip(c.Request.Context(), actorID, ownerID, c.Param("id"), version); err != nil {
+		c.Error(precondition(err, version))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trip deleted successfully"})
+}
+
+// validateTrip checks that a trip has a title and a connected, chronological sequence of complete legs
+func validateTrip(trip *domain.Trip) error {
+	fields := map[string]string{}
+	if trip.Title == "" {
+		fields["title"] = "required"
+	}
+	if len(trip.Legs) == 0 {
+		fields["legs"] = "required"
+	}
+	for i, leg := range trip.Legs {
+		prefix := fmt.Sprintf("legs[%d].", i)
+		if leg.FromCountry == "" {
+			fields[prefix+"from_country"] = "required"
+		}
+		if leg.ToCountry == "" {
+			fields[prefix+"to_country"] = "required"
+		}
+		if leg.Language == "" {
+			fields[prefix+"language"] = "required"
+		}
+		if leg.Date.IsZero() {
+			fields[prefix+"date"] = "required"
+		}
+		if len(leg.QA) != 5 {
+			fields[prefix+"qa"] = "len=5"
+		}
+		if i > 0 {
+			prev := trip.Legs[i-1]
+			if leg.FromCountry != "" && leg.FromCountry != prev.ToCountry {
+				fields[prefix+"from_country"] = "must match the previous leg's to_country"
+			}
+			if leg.Date.Before(prev.Date) {
+				fields[prefix+"date"] = "must not be before the previous leg"
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return domain.NewValidationError("Invalid trip", fields)
+	}
+	return nil
+}
+
+// tripResponse renders a trip; source tells clients whether it is a real trip or a single flight
+func tripResponse(trip domain.Trip) gin.H {
+	source := "trip"
+	if trip.FromFlight {
+		source = "flight"
+	}
+	legs := trip.Legs
+	if legs == nil {
+		legs = []domain.Leg{}
+	}
+	return gin.H{
+		"id":             trip.ID,
+		"title":          trip.Title,
+		"user_id":        trip.UserID,
+		"legs":           legs,
+		"source":         source,
+		"expires_on":     trip.ExpiresAt,
+		"expiry_warning": expiryWarning(source, trip.ExpiresAt, trip.ExpiryWarnedAt),
+		"version":        trip.Version,
+	}
+}
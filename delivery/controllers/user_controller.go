@@ -11,39 +11,41 @@ import (
 )
 
 type UserController struct {
-	userUseCase usecases.UserUseCase
+	userUseCase      usecases.UserUseCase
+	retentionUseCase usecases.RetentionUseCase
 }
 
-func NewUserController(uc usecases.UserUseCase) *UserController {
+func NewUserController(uc usecases.UserUseCase, retention usecases.RetentionUseCase) *UserController {
 	return &UserController{
-		userUseCase: uc,
+		userUseCase:      uc,
+		retentionUseCase: retention,
 	}
 }
 
 // Register handles user registration
 func (uc *UserController) Register(c *gin.Context) {
 	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &user); err != nil {
+		c.Error(err)
 		return
 	}
 
 	// Validate required fields
 	if user.Username == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+		c.Error(domain.NewValidationError("Username is required", map[string]string{"username": "required"}))
 		return
 	}
 	if user.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		c.Error(domain.NewValidationError("Email is required", map[string]string{"email": "required"}))
 		return
 	}
 	if user.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required"})
+		c.Error(domain.NewValidationError("Password is required", map[string]string{"password": "required"}))
 		return
 	}
 
-	if err := uc.userUseCase.RegisterUser(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := uc.userUseCase.RegisterUser(c.Request.Context(), &user); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -64,31 +66,31 @@ func (uc *UserController) Login(c *gin.Context) {
 		Password string `json:"password" binding:"required"`
 	}
 
-	if err := c.ShouldBindJSON(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &loginData); err != nil {
+		c.Error(err)
 		return
 	}
 
 	// Validate required fields
 	if loginData.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		c.Error(domain.NewValidationError("Email is required", map[string]string{"email": "required"}))
 		return
 	}
 	if loginData.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required"})
+		c.Error(domain.NewValidationError("Password is required", map[string]string{"password": "required"}))
 		return
 	}
 
-	user, err := uc.userUseCase.LoginUser(loginData.Email, loginData.Password)
+	user, err := uc.userUseCase.LoginUser(c.Request.Context(), loginData.Email, loginData.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// Generate JWT token with both email and user ID
-	token, err := Infrastructure.GenerateJWT(user.Email, user.ID.Hex())
+	token, err := Infrastructure.GenerateJWT(user.Email, user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.Error(err)
 		return
 	}
 
@@ -103,34 +105,52 @@ func (uc *UserController) Login(c *gin.Context) {
 	})
 }
 
+// GetProfile sends the authenticated user's profile tagged with the user's version; a client that already
+// holds that version gets 304 through If-None-Match
 func (uc *UserController) GetProfile(c *gin.Context) {
 	userID := c.GetString("user_id")
-	user, err := uc.userUseCase.GetProfile(userID)
+	user, err := uc.userUseCase.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch profile"})
+		c.Error(err)
+		return
+	}
+	if notModified(c, user.Version) {
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"username": user.Username,
-		"email":    user.Email,
-		"about":    "This app helps users schedule flights and translate queries.", // Example About
+		"username":       user.Username,
+		"email":          user.Email,
+		"retention_days": user.RetentionDays,
+		"version":        user.Version,
+		"about":          "This app helps users schedule flights and translate queries.", // Example About
 	})
 }
 
+// ChangeUsername renames the authenticated user; like the other profile changes it honours If-Match
 func (uc *UserController) ChangeUsername(c *gin.Context) {
 	userID := c.GetString("user_id")
 	var req struct {
 		NewUsername string `json:"new_username"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	if req.NewUsername == "" {
+		c.Error(domain.NewValidationError("New username is required", map[string]string{"new_username": "required"}))
+		return
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	err := uc.userUseCase.UpdateUsername(userID, req.NewUsername)
+	updated, err := uc.userUseCase.UpdateUsername(c.Request.Context(), userID, req.NewUsername, version)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(precondition(err, version))
 		return
 	}
+	c.Header("ETag", etag(updated))
 	c.JSON(http.StatusOK, gin.H{"message": "Username updated successfully"})
 }
 
@@ -141,18 +161,52 @@ func (uc *UserController) ChangePassword(c *gin.Context) {
 		NewPassword     string `json:"new_password"`
 		ConfirmPassword string `json:"confirm_password"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 	if req.NewPassword != req.ConfirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "new password and confirm password do not match"})
+		c.Error(domain.NewValidationError("new password and confirm password do not match", map[string]string{"confirm_password": "eqfield"}))
 		return
 	}
-	err := uc.userUseCase.UpdatePassword(userID, req.OldPassword, req.NewPassword)
+	version, err := ifMatch(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
+	updated, err := uc.userUseCase.UpdatePassword(c.Request.Context(), userID, req.OldPassword, req.NewPassword, version)
+	if err != nil {
+		c.Error(precondition(err, version))
+		return
+	}
+	c.Header("ETag", etag(updated))
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
 }
+
+// ChangeRetention sets how many days after a flight's date it is purged; 0 keeps flights forever
+func (uc *UserController) ChangeRetention(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req struct {
+		RetentionDays *int `json:"retention_days"`
+	}
+	if err := bindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	if req.RetentionDays == nil {
+		c.Error(domain.NewValidationError("Retention days is required", map[string]string{"retention_days": "required"}))
+		return
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	updated, err := uc.retentionUseCase.SetRetention(c.Request.Context(), userID, *req.RetentionDays, version)
+	if err != nil {
+		c.Error(precondition(err, version))
+		return
+	}
+	c.Header("ETag", etag(updated))
+	c.JSON(http.StatusOK, gin.H{"message": "Retention updated successfully", "retention_days": *req.RetentionDays})
+}
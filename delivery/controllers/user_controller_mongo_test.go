@@ -0,0 +1,39 @@
+//go:build mongo
+
+package controllers_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Run with: MONGO_URI=mongodb://localhost:27017 go test -tags mongo ./delivery/controllers/
+func TestRegisterConcurrentDuplicatesMongo(t *testing.T) {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	testConcurrentRegistration(t, func(t *testing.T) domain.UserRepository {
+		// A throwaway database per race, carrying the unique indexes from the migrations
+		db := client.Database("passme_test_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() { db.Drop(context.Background()) })
+		if _, err := repositories.RunMigrations(ctx, db); err != nil {
+			t.Fatalf("migrations: %v", err)
+		}
+		return repositories.NewUserRepository(db, repositories.DefaultTimeouts)
+	})
+}
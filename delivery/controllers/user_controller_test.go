@@ -0,0 +1,177 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/middleware"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/routers"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// registrations is how many clients race to register the same account
+const registrations = 16
+
+func TestRegisterConcurrentDuplicatesMemory(t *testing.T) {
+	testConcurrentRegistration(t, func(t *testing.T) domain.UserRepository {
+		return repositories.NewMemoryUserRepository()
+	})
+}
+
+// testConcurrentRegistration races clients that share an email, then clients that share a username, and
+// expects exactly one of each race to win while the rest get the matching 409
+func testConcurrentRegistration(t *testing.T, newRepo func(t *testing.T) domain.UserRepository) {
+	tests := []struct {
+		name string
+		body func(i int) string
+		code string
+	}{
+		{
+			name: "same email",
+			body: func(i int) string {
+				return fmt.Sprintf(`{"username":"racer%d","email":"race@example.com","password":"Passw0rd!23"}`, i)
+			},
+			code: domain.ErrEmailTaken.Code,
+		},
+		{
+			name: "same username",
+			body: func(i int) string {
+				return fmt.Sprintf(`{"username":"racer","email":"racer%d@example.com","password":"Passw0rd!23"}`, i)
+			},
+			code: domain.ErrUsernameTaken.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newUserRouter(newRepo(t))
+
+			statuses := make([]int, registrations)
+			codes := make([]string, registrations)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < registrations; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(tt.body(i)))
+					req.Header.Set("Content-Type", "application/json")
+					rec := httptest.NewRecorder()
+					router.ServeHTTP(rec, req)
+
+					statuses[i] = rec.Code
+					var body struct {
+						Error middleware.ErrorBody `json:"error"`
+					}
+					_ = json.Unmarshal(rec.Body.Bytes(), &body)
+					codes[i] = body.Error.Code
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			created := 0
+			for i, status := range statuses {
+				switch {
+				case status == http.StatusCreated:
+					created++
+				case status == http.StatusConflict && codes[i] == tt.code:
+				default:
+					t.Errorf("request %d: got %d %q, want 201 or 409 %q", i, status, codes[i], tt.code)
+				}
+			}
+			if created != 1 {
+				t.Errorf("got %d registrations, want exactly 1", created)
+			}
+		})
+	}
+}
+
+// TestProfileChangesSendNewETag chains each profile change on the ETag the previous one returned, so a
+// client never has to re-fetch the profile between edits
+func TestProfileChangesSendNewETag(t *testing.T) {
+	Infrastructure.ConfigureJWT(Infrastructure.AuthConfig{JWTSecret: strings.Repeat("s", 32), TokenTTL: time.Hour})
+	repo := repositories.NewMemoryUserRepository()
+	router := newUserRouter(repo)
+
+	register := httptest.NewRequest(http.MethodPost, "/register",
+		strings.NewReader(`{"username":"ada","email":"ada@example.com","password":"Passw0rd!23"}`))
+	register.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), register)
+	user, err := repo.FindUserByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatalf("FindUserByEmail: %v", err)
+	}
+	token, err := Infrastructure.GenerateJWT(user.Email, user.ID)
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+
+	send := func(method, path, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send(http.MethodGet, "/profile/", "", "").Header().Get("ETag")
+	if first != `"1"` {
+		t.Fatalf("profile ETag = %q, want %q", first, `"1"`)
+	}
+	current := first
+	changes := []struct {
+		path, body, want string
+	}{
+		{"/profile/username", `{"new_username":"lovelace"}`, `"2"`},
+		{"/profile/password", `{"old_password":"Passw0rd!23","new_password":"N3wPassw0rd!","confirm_password":"N3wPassw0rd!"}`, `"3"`},
+		{"/profile/retention", `{"retention_days":30}`, `"4"`},
+	}
+	for _, change := range changes {
+		rec := send(http.MethodPut, change.path, change.body, current)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("PUT %s: got %d %s, want 200", change.path, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("ETag"); got != change.want {
+			t.Fatalf("PUT %s: ETag = %q, want %q", change.path, got, change.want)
+		}
+		current = rec.Header().Get("ETag")
+	}
+
+	if rec := send(http.MethodPut, "/profile/retention", `{"retention_days":60}`, first); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with a stale If-Match: got %d, want 412", rec.Code)
+	}
+}
+
+// newUserRouter serves the user routes over repo with the production error envelope
+func newUserRouter(repo domain.UserRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	userUC := usecases.NewUserUseCase(repo, usecases.UserOptions{
+		BcryptCost:          bcrypt.MinCost,
+		RegistrationEnabled: true,
+	})
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	retentionUC := usecases.NewRetentionUseCase(repositories.NewMemoryFlightRepository(),
+		repositories.NewMemoryTripRepository(), repo, nil, usecases.RetentionOptions{})
+	routers.SetupUserRoutes(router, controllers.NewUserController(userUC, retentionUC))
+	return router
+}
@@ -2,75 +2,304 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"io/fs"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // flight timezones must resolve even on images without a zoneinfo database
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/middleware"
 	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/routers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
 	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
 	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	// Only load .env if running locally
-	if os.Getenv("RENDER") == "" {
-		err := godotenv.Load("./.env")
-		if err != nil {
-			log.Fatalf("Error loading .env file: %v", err)
+	// Optional subcommands: "migrate" applies schema migrations, "reencrypt" re-wraps data keys and
+	// rewrites every flight under the current keys, "rotate-data-keys" does the same after issuing new data keys
+	args := os.Args[1:]
+	command := "serve"
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate", "reencrypt", "rotate-data-keys":
+			command, args = args[0], args[1:]
+		}
+	}
+
+	// A local .env file is optional; real environment variables always take precedence
+	if err := godotenv.Load("./.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	cfg, printOnly, err := Infrastructure.LoadConfig(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
+		log.Fatal(err)
+	}
+	if printOnly {
+		cfg.Print(os.Stdout)
+		return
 	}
 
-	// Get MongoDB URI from the environment variables
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		log.Fatal("MONGO_URI is not set in the .env file")
+	// Structured JSON logs from here on; the standard log package is routed through the same handler
+	slog.SetDefault(Infrastructure.NewLogger(os.Stderr, cfg.Logging))
+	var effective strings.Builder
+	cfg.Print(&effective)
+	log.Printf("Effective configuration:\n%s", effective.String())
+
+	Infrastructure.ConfigureJWT(cfg.Auth)
+
+	shutdownTracing, err := Infrastructure.InitTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to initialise tracing: %v", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+	tracing := cfg.Tracing.Exporter != "none"
 
-	// Connect to MongoDB
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+	store, err := openStorage(context.Background(), cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer client.Disconnect(context.Background())
+	defer store.close()
+	if command == "migrate" {
+		log.Println("Migrations complete")
+		return
+	}
+	flightRepo, userRepo, tripRepo, shareRepo, householdRepo := store.flights, store.users, store.trips, store.shares, store.households
+
+	// Keep QA answers encrypted at rest with per-user data keys wrapped by the master key
+	var fieldCipher *Infrastructure.EnvelopeCipher
+	if cfg.Encryption.Enabled() {
+		kms, err := Infrastructure.NewLocalKMS(cfg.Encryption)
+		if err != nil {
+			log.Fatalf("Failed to load master keys: %v", err)
+		}
+		fieldCipher = Infrastructure.NewEnvelopeCipher(kms, store.dataKeys)
+		flightRepo = repositories.NewEncryptedFlightRepository(flightRepo, fieldCipher)
+		tripRepo = repositories.NewEncryptedTripRepository(tripRepo, fieldCipher)
+		userRepo = repositories.NewEncryptedUserRepository(userRepo, fieldCipher)
+	}
 
-	// Select the database
-	db := client.Database("passme")
+	if command == "reencrypt" || command == "rotate-data-keys" {
+		if fieldCipher == nil {
+			log.Fatalf("%s needs ENCRYPTION_MASTER_KEYS to be configured", command)
+		}
+		if err := reencrypt(context.Background(), flightRepo, tripRepo, userRepo, fieldCipher, command == "rotate-data-keys"); err != nil {
+			log.Fatalf("Re-encryption failed: %v", err)
+		}
+		return
+	}
 
-	// Initialize repositories
-	flightRepo := repositories.NewFlightRepository(db)
-	userRepo := repositories.NewUserRepository(db)
+	// Time every storage call and count created flights
+	if cfg.Features.Metrics {
+		flightRepo = repositories.NewInstrumentedFlightRepository(flightRepo, cfg.Storage.Backend)
+		userRepo = repositories.NewInstrumentedUserRepository(userRepo, cfg.Storage.Backend)
+		tripRepo = repositories.NewInstrumentedTripRepository(tripRepo, cfg.Storage.Backend)
+		shareRepo = repositories.NewInstrumentedShareRepository(shareRepo, cfg.Storage.Backend)
+		householdRepo = repositories.NewInstrumentedHouseholdRepository(householdRepo, cfg.Storage.Backend)
+	}
+
+	// Trace every storage call as a child of the use case span
+	if tracing {
+		flightRepo = repositories.NewTracedFlightRepository(flightRepo, cfg.Storage.Backend)
+		userRepo = repositories.NewTracedUserRepository(userRepo, cfg.Storage.Backend)
+		tripRepo = repositories.NewTracedTripRepository(tripRepo, cfg.Storage.Backend)
+		shareRepo = repositories.NewTracedShareRepository(shareRepo, cfg.Storage.Backend)
+		householdRepo = repositories.NewTracedHouseholdRepository(householdRepo, cfg.Storage.Backend)
+	}
+
+	airports, err := Infrastructure.NewAirportDirectory()
+	if err != nil {
+		log.Fatalf("Failed to load airport dataset: %v", err)
+	}
+
+	// Every use case asks the same authorizer who may act on whose data
+	authz := usecases.NewAuthorizer(
+		usecases.OwnerRule(),
+		usecases.GuardianRule(householdRepo),
+		usecases.AdminRule(cfg.Auth.AdminUserIDs),
+		usecases.SharedRule(),
+	)
 
 	// Initialize use cases
-	flightUC := usecases.NewFlightUseCase(flightRepo)
-	userUC := usecases.NewUserUseCase(userRepo)
+	flightUC := usecases.NewFlightUseCase(flightRepo, userRepo, airports, authz)
+	userUC := usecases.NewUserUseCase(userRepo, usecases.UserOptions{
+		BcryptCost:           cfg.Auth.BcryptCost,
+		RegistrationEnabled:  cfg.Features.Registration,
+		DefaultRetentionDays: cfg.Retention.DefaultDays,
+	})
+	tripUC := usecases.NewTripUseCase(tripRepo, flightRepo, userRepo, authz)
+	importUC := usecases.NewImportUseCase(airports, Infrastructure.NewBarcodeReader(), Infrastructure.NewItineraryReader(airports))
+	calendarUC := usecases.NewCalendarUseCase(flightRepo, userRepo, authz)
+	answerProfileUC := usecases.NewAnswerProfileUseCase(userRepo, authz)
+	shareUC := usecases.NewShareUseCase(shareRepo, flightRepo, Infrastructure.JWTShareSigner{}, authz, usecases.ShareOptions{
+		DefaultTTL: cfg.Sharing.DefaultTTL,
+		MaxTTL:     cfg.Sharing.MaxTTL,
+	})
+	householdUC := usecases.NewHouseholdUseCase(householdRepo, userRepo)
+	syncUC := usecases.NewSyncUseCase(flightUC, flightRepo, authz, usecases.SyncOptions{
+		SafetyLag:          cfg.Sync.SafetyLag,
+		TombstoneRetention: cfg.Sync.TombstoneRetention,
+	})
+	retentionUC := usecases.NewRetentionUseCase(flightRepo, tripRepo, userRepo, Infrastructure.LogExpiryNotifier{}, usecases.RetentionOptions{
+		Warning:            cfg.Retention.Warning,
+		TombstoneRetention: cfg.Sync.TombstoneRetention,
+	})
+	if tracing {
+		flightUC = usecases.NewTracedFlightUseCase(flightUC)
+		userUC = usecases.NewTracedUserUseCase(userUC)
+		tripUC = usecases.NewTracedTripUseCase(tripUC)
+		importUC = usecases.NewTracedImportUseCase(importUC)
+		calendarUC = usecases.NewTracedCalendarUseCase(calendarUC)
+		answerProfileUC = usecases.NewTracedAnswerProfileUseCase(answerProfileUC)
+		shareUC = usecases.NewTracedShareUseCase(shareUC)
+		householdUC = usecases.NewTracedHouseholdUseCase(householdUC)
+		syncUC = usecases.NewTracedSyncUseCase(syncUC)
+		retentionUC = usecases.NewTracedRetentionUseCase(retentionUC)
+	}
 
 	// Initialize controllers
-	flightController := controllers.NewFlightController(flightUC)
-	userController := controllers.NewUserController(userUC)
+	flightController := controllers.NewFlightController(flightUC, importUC)
+	userController := controllers.NewUserController(userUC, retentionUC)
+	tripController := controllers.NewTripController(tripUC)
+	calendarController := controllers.NewCalendarController(calendarUC, cfg.Server.PublicURL, cfg.Calendar.CardURL)
+	answerProfileController := controllers.NewAnswerProfileController(answerProfileUC)
+	shareController := controllers.NewShareController(shareUC, cfg.Server.PublicURL)
+	householdController := controllers.NewHouseholdController(householdUC)
+	syncController := controllers.NewSyncController(syncUC)
+	// Readiness probes storage only: this service calls no translation engine, so there is nothing else to check
+	healthController := controllers.NewHealthController(2*time.Second, store.readiness...)
+
+	// Set up the Gin router; request logging and panic recovery use the structured logger
+	if cfg.Logging.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
 
-	// Set up the Gin router
-	r := gin.Default()
+	// Start a server span per request, continuing any W3C trace context sent by the client; calendar feed
+	// and share link requests are left out because their URL path is a secret token
+	if tracing {
+		r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+			return !strings.HasPrefix(r.URL.Path, "/calendar/") && !strings.HasPrefix(r.URL.Path, "/shared/")
+		})))
+	}
 
 	// Apply CORS middleware
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowOrigins: cfg.CORS.AllowedOrigins,
+		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		// Browsers only send and read the conditional request headers when they are listed here
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match", middleware.RequestIDHeader},
+		ExposeHeaders: []string{"Content-Length", "ETag", middleware.RequestIDHeader},
 	}))
 
+	// Record request metrics; registered first so it sees the final status. They are scraped from the
+	// separate metrics listener below
+	if cfg.Features.Metrics {
+		r.Use(Infrastructure.MetricsMiddleware())
+	}
+
+	// Tag every request with an ID, log it, and render errors as a JSON envelope
+	r.Use(middleware.RequestID(), middleware.RequestLogger(), middleware.Recovery(), middleware.ErrorHandler())
+
 	// Set up the routes
+	routers.SetupHealthRoutes(r, healthController)
 	routers.SetupUserRoutes(r, userController)
 	routers.SetupFlightRoutes(r, flightController)
+	routers.SetupTripRoutes(r, tripController)
+	routers.SetupCalendarRoutes(r, calendarController)
+	routers.SetupAnswerProfileRoutes(r, answerProfileController)
+	routers.SetupShareRoutes(r, shareController)
+	routers.SetupHouseholdRoutes(r, householdController)
+	routers.SetupSyncRoutes(r, syncController)
 
 	// Start the server
-	log.Println("Server is running at :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Warn about and purge expired flights in the background until shutdown begins. The store closes only
+	// after the worker has returned, whichever way main exits
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		runRetentionWorker(ctx, retentionUC, cfg.Retention.SweepInterval)
+	}()
+	defer func() {
+		stop()
+		<-workerDone
+	}()
+
+	serverErr := make(chan error, 2)
+	go func() {
+		log.Printf("Server is running at %s", srv.Addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Serve /metrics on its own port so it is only reachable where the operator exposes it
+	var metricsSrv *http.Server
+	if cfg.Features.Metrics {
+		metricsSrv = &http.Server{
+			Addr:              ":" + strconv.Itoa(cfg.Server.MetricsPort),
+			Handler:           Infrastructure.MetricsHandler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			log.Printf("Metrics are served at %s/metrics", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Failed to start server: %v", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	// Stop advertising readiness and give load balancers time to notice, then drain in-flight requests
+	// before the deferred disconnects run
+	log.Printf("Shutting down: readiness fails for %s, then requests drain for up to %s", cfg.Server.DrainDelay, cfg.Server.ShutdownTimeout)
+	healthController.SetDraining()
+	time.Sleep(cfg.Server.DrainDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(shutdownCtx)
 	}
+	log.Println("Server stopped")
 }
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// StatusClientClosedRequest is the non-standard status used when the client goes away mid-request
+const StatusClientClosedRequest = 499
+
+// ErrorBody is the stable JSON shape of every error response
+type ErrorBody struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// ErrorHandler renders the last error attached to the context as a JSON error envelope
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		// The request logger records the underlying error; clients only see the envelope
+		status, body := describe(err)
+		body.RequestID = c.GetString(RequestIDKey)
+		c.AbortWithStatusJSON(status, gin.H{"error": body})
+	}
+}
+
+// describe maps an error to its HTTP status and envelope, hiding details of unexpected failures
+func describe(err error) (int, ErrorBody) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, ErrorBody{Code: "request_cancelled", Message: "request cancelled"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, ErrorBody{Code: "timeout", Message: "operation timed out"}
+	}
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		return http.StatusInternalServerError, ErrorBody{Code: "internal_error", Message: "internal server error"}
+	}
+
+	body := ErrorBody{Code: domainErr.Code, Message: domainErr.Message, Details: domainErr.Fields}
+	switch domainErr.Kind {
+	case domain.KindInvalid:
+		return http.StatusBadRequest, body
+	case domain.KindUnauthenticated:
+		return http.StatusUnauthorized, body
+	case domain.KindForbidden:
+		return http.StatusForbidden, body
+	case domain.KindNotFound:
+		return http.StatusNotFound, body
+	case domain.KindConflict:
+		return http.StatusConflict, body
+	case domain.KindPrecondition:
+		return http.StatusPreconditionFailed, body
+	}
+	return http.StatusInternalServerError, ErrorBody{Code: "internal_error", Message: "internal server error"}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logger returns the default logger tagged as the HTTP layer
+func logger() *slog.Logger {
+	return slog.Default().With("layer", "http")
+}
+
+// RequestLogger writes one structured line per request, using the route template so IDs and tokens in paths stay out of logs
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if userID := c.GetString("user_id"); userID != "" {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "error", c.Errors.Last().Err)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger().Log(c.Request.Context(), level, "request completed", attrs...)
+	}
+}
+
+// Recovery turns a panic into a logged 500 with the standard error envelope
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger().ErrorContext(c.Request.Context(), "panic recovered", "panic", recovered, "stack", string(debug.Stack()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": ErrorBody{
+					Code:      "internal_error",
+					Message:   "internal server error",
+					RequestID: c.GetString(RequestIDKey),
+				}})
+			}
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+// reencryptPageSize is how many flights, trips or answer profiles are rewritten per page
+const reencryptPageSize = 100
+
+// reencrypt re-wraps data keys under the current master key, optionally rotates every user's data key,
+// then rewrites each flight, trip and answer profile so its answers are sealed with the newest data key.
+// Answers stored before encryption was enabled are encrypted on the way. Flights and trips are resealed in
+// place, so their versions stay put and clients see no edit.
+func reencrypt(ctx context.Context, flights domain.FlightRepository, trips domain.TripRepository, users domain.UserRepository, cipher *Infrastructure.EnvelopeCipher, rotate bool) error {
+	rewrapped, err := cipher.RewrapDataKeys(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Re-wrapped %d data keys under the current master key", rewrapped)
+
+	if rotate {
+		rotated, err := cipher.RotateDataKeys(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rotated data keys for %d users", rotated)
+	}
+
+	rewritten, skipped := 0, 0
+	after := ""
+	for {
+		page, err := flights.ListFlights(ctx, after, reencryptPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		for i := range page {
+			// A flight edited or deleted since it was read needs no rewrite: an edit already used the newest data key
+			err := flights.ResealFlight(ctx, &page[i])
+			switch {
+			case errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrFlightNotFound):
+				skipped++
+			case err != nil:
+				return err
+			default:
+				rewritten++
+			}
+		}
+		after = page[len(page)-1].ID
+	}
+	log.Printf("Re-encrypted %d flights, skipped %d changed while the job ran", rewritten, skipped)
+
+	rewritten, skipped = 0, 0
+	after = ""
+	for {
+		page, err := trips.ListTrips(ctx, after, reencryptPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		for i := range page {
+			err := trips.ResealTrip(ctx, &page[i])
+			switch {
+			case errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrTripNotFound):
+				skipped++
+			case err != nil:
+				return err
+			default:
+				rewritten++
+			}
+		}
+		after = page[len(page)-1].ID
+	}
+	log.Printf("Re-encrypted %d trips, skipped %d changed while the job ran", rewritten, skipped)
+
+	rewritten = 0
+	after = ""
+	for {
+		page, err := users.ListAnswerProfiles(ctx, after, reencryptPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, profile := range page {
+			if err := users.SaveProfileAnswers(ctx, profile.UserID, profile.Answers); err != nil {
+				return err
+			}
+		}
+		rewritten += len(page)
+		after = page[len(page)-1].UserID
+	}
+	log.Printf("Re-encrypted %d answer profiles", rewritten)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+)
+
+// racingTrips edits the first trip of every page right after listing it, as a user would while the job runs
+type racingTrips struct {
+	domain.TripRepository
+}
+
+func (r racingTrips) ListTrips(ctx context.Context, afterID string, limit int) ([]domain.Trip, error) {
+	page, err := r.TripRepository.ListTrips(ctx, afterID, limit)
+	if err != nil || len(page) == 0 {
+		return page, err
+	}
+	edit := page[0]
+	edit.Legs = append([]domain.Leg(nil), page[0].Legs...)
+	edit.Title = "Edited while re-encrypting"
+	return page, r.TripRepository.UpdateTrip(ctx, &edit)
+}
+
+func TestReencryptSkipsTripsEditedMeanwhile(t *testing.T) {
+	ctx := context.Background()
+	kms, err := Infrastructure.NewLocalKMS(Infrastructure.EncryptionConfig{
+		MasterKeys:   []string{"k1:" + base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		CurrentKeyID: "k1",
+	})
+	if err != nil {
+		t.Fatalf("NewLocalKMS: %v", err)
+	}
+	cipher := Infrastructure.NewEnvelopeCipher(kms, repositories.NewMemoryDataKeyRepository())
+
+	// Answers written before encryption was enabled
+	rawFlights, rawTrips := repositories.NewMemoryFlightRepository(), repositories.NewMemoryTripRepository()
+	date := time.Date(2026, 12, 1, 10, 0, 0, 0, time.UTC)
+	qa := []domain.QA{{QuestionID: "passport_number", Question: "Passport number?", Answer: "P1234567"}}
+	flight := &domain.Flight{Title: "Lisbon", FromCountry: "US", ToCountry: "PT", Date: date, UserID: "owner", QA: qa}
+	if err := rawFlights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+	var trips []*domain.Trip
+	for _, title := range []string{"First", "Second"} {
+		trip := &domain.Trip{Title: title, UserID: "owner", Legs: []domain.Leg{{FromCountry: "US", ToCountry: "PT", Date: date, QA: qa}}}
+		if err := rawTrips.CreateTrip(ctx, trip); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+		trips = append(trips, trip)
+	}
+
+	err = reencrypt(ctx,
+		repositories.NewEncryptedFlightRepository(rawFlights, cipher),
+		racingTrips{repositories.NewEncryptedTripRepository(rawTrips, cipher)},
+		repositories.NewMemoryUserRepository(), cipher, false)
+	if err != nil {
+		t.Fatalf("reencrypt: %v", err)
+	}
+
+	sealed := func(answer string) bool { return strings.HasPrefix(answer, "enc:v1:") }
+	gotFlight, err := rawFlights.GetFlightByID(ctx, "owner", flight.ID)
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	if !sealed(gotFlight.QA[0].Answer) || gotFlight.Version != flight.Version || !gotFlight.UpdatedAt.Equal(flight.UpdatedAt) {
+		t.Errorf("flight has answer %q at version %d, want it sealed at version %d", gotFlight.QA[0].Answer, gotFlight.Version, flight.Version)
+	}
+
+	// ListTrips orders by ID, so the racing edit hits whichever trip sorts first
+	edited, untouched := trips[0], trips[1]
+	if untouched.ID < edited.ID {
+		edited, untouched = untouched, edited
+	}
+	got, err := rawTrips.GetTripByID(ctx, "owner", edited.ID)
+	if err != nil {
+		t.Fatalf("GetTripByID: %v", err)
+	}
+	if got.Title != "Edited while re-encrypting" || got.Version != edited.Version+1 || !sealed(got.Legs[0].QA[0].Answer) {
+		t.Errorf("edited trip = %q at version %d with answer %q, want the user's edit kept and sealed", got.Title, got.Version, got.Legs[0].QA[0].Answer)
+	}
+	got, err = rawTrips.GetTripByID(ctx, "owner", untouched.ID)
+	if err != nil {
+		t.Fatalf("GetTripByID: %v", err)
+	}
+	if got.Version != untouched.Version || !sealed(got.Legs[0].QA[0].Answer) {
+		t.Errorf("untouched trip has answer %q at version %d, want it sealed at version %d", got.Legs[0].QA[0].Answer, got.Version, untouched.Version)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+)
+
+// runRetentionWorker sweeps for expiring flights immediately and then every interval until ctx is cancelled
+func runRetentionWorker(ctx context.Context, retention usecases.RetentionUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := retention.Sweep(ctx, time.Now().UTC())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Retention sweep failed: %v", err)
+		} else if result.Warned > 0 || result.Purged > 0 || result.Tombstones > 0 {
+			log.Printf("Retention sweep warned %d, purged %d flights and pruned %d tombstones", result.Warned, result.Purged, result.Tombstones)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
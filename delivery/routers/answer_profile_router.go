@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+func SetupAnswerProfileRoutes(router *gin.Engine, controller *controllers.AnswerProfileController) {
+	router.GET("/questions", controller.ListQuestions)
+
+	auth := router.Group("/profile/answers")
+	auth.Use(Infrastructure.AuthMiddleware())
+	{
+		auth.GET("", controller.GetAnswers)
+		auth.PUT("", controller.SaveAnswers)
+		auth.DELETE("/:question_id", controller.DeleteAnswer)
+	}
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+func SetupCalendarRoutes(router *gin.Engine, controller *controllers.CalendarController) {
+	// The feed authenticates by the secret token in its URL, so it sits outside the JWT middleware
+	router.GET("/calendar/:token", controller.Feed)
+
+	auth := router.Group("")
+	auth.Use(Infrastructure.AuthMiddleware())
+	{
+		auth.GET("/flights/:id/ics", controller.ExportFlight)
+
+		auth.POST("/profile/calendar-token", controller.RotateCalendarToken)
+		auth.DELETE("/profile/calendar-token", controller.RevokeCalendarToken)
+	}
+}
@@ -12,10 +12,20 @@ func SetupFlightRoutes(router *gin.Engine, controller *controllers.FlightControl
 	{
 		flights.POST("", controller.CreateFlight)
 
+		flights.POST("/import/boarding-pass", controller.ImportBoardingPass)
+
+		flights.POST("/import/itinerary", controller.ImportItinerary)
+
 		flights.GET("", controller.GetUserFlights)
 
+		flights.GET("/expiring", controller.GetExpiringFlights)
+
 		flights.GET("/:id", controller.GetFlightByID)
 
+		flights.PUT("/:id", controller.UpdateFlight)
+
+		flights.POST("/:id/clone", controller.CloneFlight)
+
 		flights.DELETE("/:id", controller.DeleteFlight)
 	}
 }
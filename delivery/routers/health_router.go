@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+)
+
+func SetupHealthRoutes(router *gin.Engine, controller *controllers.HealthController) {
+	router.GET("/healthz", controller.Liveness)
+	router.GET("/readyz", controller.Readiness)
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+func SetupHouseholdRoutes(router *gin.Engine, controller *controllers.HouseholdController) {
+	households := router.Group("/households")
+	households.Use(Infrastructure.AuthMiddleware())
+	{
+		households.POST("", controller.CreateHousehold)
+		households.GET("", controller.ListHouseholds)
+		households.GET("/:id", controller.GetHousehold)
+		households.POST("/:id/dependents", controller.AddDependent)
+		households.POST("/:id/members", controller.InviteMember)
+		households.POST("/:id/accept", controller.AcceptInvitation)
+		households.DELETE("/:id/members/:user_id", controller.RemoveMember)
+	}
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+func SetupShareRoutes(router *gin.Engine, controller *controllers.ShareController) {
+	// Share links authenticate by the signed token in their URL, so they sit outside the JWT middleware
+	router.GET("/shared/:token", controller.Shared)
+
+	auth := router.Group("/flights/:id/shares")
+	auth.Use(Infrastructure.AuthMiddleware())
+	{
+		auth.POST("", controller.CreateShare)
+		auth.GET("", controller.ListShares)
+		auth.DELETE("/:share_id", controller.RevokeShare)
+	}
+}
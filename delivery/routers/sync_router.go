@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+func SetupSyncRoutes(router *gin.Engine, controller *controllers.SyncController) {
+	auth := router.Group("/sync")
+	auth.Use(Infrastructure.AuthMiddleware())
+	{
+		auth.POST("", controller.Sync)
+	}
+}
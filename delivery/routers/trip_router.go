@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+func SetupTripRoutes(router *gin.Engine, controller *controllers.TripController) {
+	trips := router.Group("/trips")
+	trips.Use(Infrastructure.AuthMiddleware())
+	{
+		trips.POST("", controller.CreateTrip)
+
+		trips.GET("", controller.GetUserTrips)
+
+		trips.GET("/:id", controller.GetTripByID)
+
+		trips.PUT("/:id", controller.UpdateTrip)
+
+		trips.DELETE("/:id", controller.DeleteTrip)
+	}
+}
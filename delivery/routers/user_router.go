@@ -8,7 +8,7 @@ import (
 
 func SetupUserRoutes(router *gin.Engine, controller *controllers.UserController) {
 	router.POST("/register", controller.Register)
-	router.POST("/login", controller.Login)
+	router.POST("/login", Infrastructure.LoginMetrics(), controller.Login)
 
 	auth := router.Group("/profile")
 	auth.Use(Infrastructure.AuthMiddleware())
@@ -16,5 +16,6 @@ func SetupUserRoutes(router *gin.Engine, controller *controllers.UserController)
 		auth.GET("/", controller.GetProfile)
 		auth.PUT("/username", controller.ChangeUsername)
 		auth.PUT("/password", controller.ChangePassword)
+		auth.PUT("/retention", controller.ChangeRetention)
 	}
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/delivery/controllers"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// storage holds the repositories of the configured backend together with its readiness checks
+type storage struct {
+	flights    domain.FlightRepository
+	users      domain.UserRepository
+	trips      domain.TripRepository
+	shares     domain.ShareRepository
+	households domain.HouseholdRepository
+	dataKeys   domain.DataKeyRepository
+	readiness  []controllers.ReadinessCheck
+	close      func()
+}
+
+// openStorage connects to the configured backend and applies any pending schema migrations
+func openStorage(ctx context.Context, cfg *Infrastructure.Config) (*storage, error) {
+	// Per-operation deadlines for repository calls
+	timeouts := repositories.Timeouts{
+		Read:  cfg.Storage.ReadTimeout,
+		Write: cfg.Storage.WriteTimeout,
+	}
+
+	switch cfg.Storage.Backend {
+	case "memory":
+		log.Println("Using in-memory storage; data is lost on restart")
+		return &storage{
+			flights:    repositories.NewMemoryFlightRepository(),
+			users:      repositories.NewMemoryUserRepository(),
+			trips:      repositories.NewMemoryTripRepository(),
+			shares:     repositories.NewMemoryShareRepository(),
+			households: repositories.NewMemoryHouseholdRepository(),
+			dataKeys:   repositories.NewMemoryDataKeyRepository(),
+			close:      func() {},
+		}, nil
+
+	case "mongo":
+		// Connect to MongoDB
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.Storage.MongoURI))
+		if err != nil {
+			return nil, err
+		}
+		closeClient := func() { client.Disconnect(context.Background()) }
+
+		// Select the database and apply pending schema migrations
+		db := client.Database(cfg.Storage.MongoDatabase)
+		applied, err := repositories.RunMigrations(ctx, db)
+		if err != nil {
+			closeClient()
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %d: %s", m.Version, m.Description)
+		}
+
+		return &storage{
+			flights:    repositories.NewFlightRepository(db, timeouts),
+			users:      repositories.NewUserRepository(db, timeouts),
+			trips:      repositories.NewTripRepository(db, timeouts),
+			shares:     repositories.NewShareRepository(db, timeouts),
+			households: repositories.NewHouseholdRepository(db, timeouts),
+			dataKeys:   repositories.NewDataKeyRepository(db, timeouts),
+			readiness: []controllers.ReadinessCheck{{
+				Name:  "mongo",
+				Check: func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) },
+			}},
+			close: closeClient,
+		}, nil
+
+	case "postgres", "sqlite":
+		dialect := repositories.Dialect(cfg.Storage.Backend)
+		db, err := repositories.OpenSQL(ctx, dialect, cfg.Storage.SQLDSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s database: %v", dialect, err)
+		}
+
+		// Apply pending schema migrations
+		applied, err := repositories.RunSQLMigrations(ctx, db, dialect)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %d: %s", m.Version, m.Description)
+		}
+
+		return &storage{
+			flights:    repositories.NewSQLFlightRepository(db, dialect, timeouts),
+			users:      repositories.NewSQLUserRepository(db, dialect, timeouts),
+			trips:      repositories.NewSQLTripRepository(db, dialect, timeouts),
+			shares:     repositories.NewSQLShareRepository(db, dialect, timeouts),
+			households: repositories.NewSQLHouseholdRepository(db, dialect, timeouts),
+			dataKeys:   repositories.NewSQLDataKeyRepository(db, dialect, timeouts),
+			readiness: []controllers.ReadinessCheck{{
+				Name:  string(dialect),
+				Check: db.PingContext,
+			}},
+			close: func() { db.Close() },
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+}
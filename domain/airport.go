@@ -0,0 +1,16 @@
+package domain
+
+// Airport is one entry of the airport dataset used to enrich flights
+type Airport struct {
+	IATA        string
+	Name        string
+	City        string
+	Country     string
+	CountryCode string
+	Timezone    string
+}
+
+// AirportDirectory looks airports up by their three-letter IATA code
+type AirportDirectory interface {
+	LookupAirport(code string) (Airport, bool)
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"log/slog"
+	"time"
+)
+
+// ProfileAnswer is a saved answer to a template question, kept in the language the traveller wrote it in;
+// each flight's card is translated from it into the flight's language
+type ProfileAnswer struct {
+	QuestionID string    `bson:"question_id" json:"question_id"`
+	Answer     string    `bson:"answer" json:"answer"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// LogValue keeps the answer out of logs
+func (a ProfileAnswer) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("question_id", a.QuestionID),
+		slog.String("answer", "[REDACTED]"),
+	)
+}
+
+// AnswerProfile is a user's library of saved answers, sorted by question ID
+type AnswerProfile struct {
+	UserID  string          `json:"user_id"`
+	Answers []ProfileAnswer `json:"answers"`
+}
+
+// Answer returns the saved answer to a template question
+func (p AnswerProfile) Answer(questionID string) (ProfileAnswer, bool) {
+	for _, answer := range p.Answers {
+		if answer.QuestionID == questionID {
+			return answer, true
+		}
+	}
+	return ProfileAnswer{}, false
+}
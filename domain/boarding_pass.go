@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BoardingPass holds the mandatory items of an IATA Bar Coded Boarding Pass (Resolution 792)
+type BoardingPass struct {
+	PassengerName    string            `json:"passenger_name"`
+	LastName         string            `json:"last_name"`
+	FirstName        string            `json:"first_name"`
+	ElectronicTicket bool              `json:"electronic_ticket"`
+	Legs             []BoardingPassLeg `json:"legs"`
+}
+
+// BoardingPassLeg is one flight segment of a boarding pass
+type BoardingPassLeg struct {
+	PNR             string `json:"pnr"`
+	FromAirport     string `json:"from_airport"`
+	ToAirport       string `json:"to_airport"`
+	Carrier         string `json:"carrier"`
+	FlightNumber    string `json:"flight_number"`
+	DayOfYear       int    `json:"day_of_year"`
+	Compartment     string `json:"compartment"`
+	Seat            string `json:"seat"`
+	CheckInSequence string `json:"check_in_sequence"`
+	PassengerStatus string `json:"passenger_status"`
+}
+
+// LogValue keeps the passenger's name and booking reference out of logs
+func (p BoardingPass) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("passenger_name", "[REDACTED]"),
+		slog.Int("legs", len(p.Legs)),
+	)
+}
+
+// BarcodeDecoder extracts the text of a boarding-pass barcode from an uploaded image
+type BarcodeDecoder interface {
+	DecodeBarcode(ctx context.Context, image []byte) (string, error)
+}
+
+// Field widths of the BCBP mandatory items
+const (
+	bcbpHeaderLength     = 23 // format code, leg count, passenger name and e-ticket indicator
+	bcbpLegLength        = 37 // PNR through the size of the leg's conditional items
+	bcbpPassengerNameLen = 20
+)
+
+// ParseBoardingPass parses the text of a BCBP barcode, skipping the conditional and security items
+func ParseBoardingPass(data string) (*BoardingPass, error) {
+	data = strings.TrimRight(data, "\r\n")
+	if len(data) < bcbpHeaderLength+bcbpLegLength || data[0] != 'M' {
+		return nil, invalidBoardingPass("data is not an IATA BCBP boarding pass")
+	}
+	legs := int(data[1] - '0')
+	if legs < 1 || legs > 4 {
+		return nil, invalidBoardingPass("number of legs must be between 1 and 4")
+	}
+
+	name := strings.TrimSpace(data[2 : 2+bcbpPassengerNameLen])
+	pass := &BoardingPass{
+		PassengerName:    name,
+		ElectronicTicket: data[22] == 'E',
+	}
+	pass.LastName, pass.FirstName, _ = strings.Cut(name, "/")
+
+	offset := bcbpHeaderLength
+	for i := 0; i < legs; i++ {
+		if len(data) < offset+bcbpLegLength {
+			return nil, invalidBoardingPass(fmt.Sprintf("leg %d is truncated", i+1))
+		}
+		field := data[offset : offset+bcbpLegLength]
+		leg, err := parseBoardingPassLeg(field)
+		if err != nil {
+			return nil, invalidBoardingPass(fmt.Sprintf("leg %d: %s", i+1, err))
+		}
+		conditional, err := strconv.ParseUint(field[35:37], 16, 8)
+		if err != nil {
+			return nil, invalidBoardingPass(fmt.Sprintf("leg %d: conditional item size is not hexadecimal", i+1))
+		}
+		offset += bcbpLegLength + int(conditional)
+		if offset > len(data) {
+			return nil, invalidBoardingPass(fmt.Sprintf("leg %d is truncated", i+1))
+		}
+		pass.Legs = append(pass.Legs, leg)
+	}
+	return pass, nil
+}
+
+// parseBoardingPassLeg reads the repeated mandatory items of one leg
+func parseBoardingPassLeg(field string) (BoardingPassLeg, error) {
+	leg := BoardingPassLeg{
+		PNR:             strings.TrimSpace(field[0:7]),
+		FromAirport:     strings.TrimSpace(field[7:10]),
+		ToAirport:       strings.TrimSpace(field[10:13]),
+		Carrier:         strings.TrimSpace(field[13:16]),
+		Compartment:     strings.TrimSpace(field[24:25]),
+		Seat:            strings.TrimLeft(strings.TrimSpace(field[25:29]), "0"),
+		CheckInSequence: strings.TrimLeft(strings.TrimSpace(field[29:34]), "0"),
+		PassengerStatus: strings.TrimSpace(field[34:35]),
+	}
+	if len(leg.FromAirport) != 3 || len(leg.ToAirport) != 3 {
+		return leg, fmt.Errorf("airport codes must be three letters")
+	}
+	if leg.Carrier == "" {
+		return leg, fmt.Errorf("operating carrier is missing")
+	}
+
+	// The flight number is four digits, zero padded, with an optional operational suffix
+	number := strings.TrimSpace(field[16:21])
+	digits := strings.TrimLeft(strings.TrimRight(number, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"), "0")
+	suffix := strings.TrimLeft(number, "0123456789")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" || len(suffix) > 1 {
+		return leg, fmt.Errorf("flight number %q is invalid", number)
+	}
+	leg.FlightNumber = leg.Carrier + digits + suffix
+
+	if day := strings.TrimSpace(field[21:24]); day != "" {
+		n, err := strconv.Atoi(day)
+		if err != nil || n < 1 || n > 366 {
+			return leg, fmt.Errorf("date of flight %q is not a day of the year", day)
+		}
+		leg.DayOfYear = n
+	}
+	return leg, nil
+}
+
+// FlightDate resolves the leg's day of the year to the date nearest to now, since BCBP omits the year
+func (l BoardingPassLeg) FlightDate(now time.Time) time.Time {
+	if l.DayOfYear == 0 {
+		return time.Time{}
+	}
+	now = now.UTC()
+	var best time.Time
+	for year := now.Year() - 1; year <= now.Year()+1; year++ {
+		date := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, l.DayOfYear-1)
+		if date.Year() != year {
+			continue // day 366 in a non-leap year
+		}
+		if best.IsZero() || absDuration(date.Sub(now)) < absDuration(best.Sub(now)) {
+			best = date
+		}
+	}
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func invalidBoardingPass(message string) *Error {
+	return NewValidationError("Invalid boarding pass: "+message, map[string]string{"data": "bcbp"})
+}
@@ -0,0 +1,105 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+const (
+	passHeader = "M1DESMARAIS/LUC       E"
+	firstLeg   = "ABC123 YULFRAAC 0834 226F001A0025 100"
+	secondLeg  = "DEF456 FRAGVALH 3664 227C012C0002 100"
+)
+
+func TestParseBoardingPass(t *testing.T) {
+	pass, err := domain.ParseBoardingPass(passHeader + firstLeg + "\r\n")
+	if err != nil {
+		t.Fatalf("ParseBoardingPass: %v", err)
+	}
+	if pass.LastName != "DESMARAIS" || pass.FirstName != "LUC" || !pass.ElectronicTicket {
+		t.Errorf("passenger = %q/%q e-ticket %v, want DESMARAIS/LUC with an e-ticket", pass.LastName, pass.FirstName, pass.ElectronicTicket)
+	}
+	want := domain.BoardingPassLeg{
+		PNR: "ABC123", FromAirport: "YUL", ToAirport: "FRA", Carrier: "AC", FlightNumber: "AC834",
+		DayOfYear: 226, Compartment: "F", Seat: "1A", CheckInSequence: "25", PassengerStatus: "1",
+	}
+	if len(pass.Legs) != 1 || pass.Legs[0] != want {
+		t.Errorf("legs = %+v, want [%+v]", pass.Legs, want)
+	}
+}
+
+func TestParseMultiLegBoardingPass(t *testing.T) {
+	// The first leg carries six bytes of conditional items, which the parser must skip to find the second
+	conditional := "06" + ">10000"
+	data := "M2" + passHeader[2:] + firstLeg[:35] + conditional + secondLeg
+	pass, err := domain.ParseBoardingPass(data)
+	if err != nil {
+		t.Fatalf("ParseBoardingPass: %v", err)
+	}
+	if len(pass.Legs) != 2 {
+		t.Fatalf("parsed %d legs, want 2", len(pass.Legs))
+	}
+	if got := pass.Legs[1]; got.FlightNumber != "LH3664" || got.FromAirport != "FRA" || got.ToAirport != "GVA" || got.DayOfYear != 227 {
+		t.Errorf("second leg = %+v, want LH3664 FRA-GVA on day 227", got)
+	}
+}
+
+func TestParseBoardingPassRejectsMalformedData(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		message string
+	}{
+		{"not BCBP", "hello", "not an IATA BCBP"},
+		{"leg count", "M5" + passHeader[2:] + firstLeg, "number of legs"},
+		{"size field not hex", passHeader + firstLeg[:35] + "ZZ", "not hexadecimal"},
+		{"size field past the end", passHeader + firstLeg[:35] + "1F" + ">10", "leg 1 is truncated"},
+		{"missing second leg", "M2" + passHeader[2:] + firstLeg, "leg 2 is truncated"},
+		{"airport codes", passHeader + strings.Replace(firstLeg, "YUL", "Y  ", 1), "airport codes"},
+		{"flight number", passHeader + strings.Replace(firstLeg, "0834 ", "08X4 ", 1), "flight number"},
+		{"day of year", passHeader + strings.Replace(firstLeg, "226", "367", 1), "day of the year"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.ParseBoardingPass(tt.data)
+			var derr *domain.Error
+			if !errors.As(err, &derr) || derr.Kind != domain.KindInvalid {
+				t.Fatalf("ParseBoardingPass = %v, want a validation error", err)
+			}
+			if !strings.Contains(derr.Message, tt.message) {
+				t.Errorf("message %q does not mention %q", derr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestBoardingPassLegFlightDate(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name      string
+		dayOfYear int
+		now       time.Time
+		want      time.Time
+	}{
+		{"same year", 226, day(2026, time.August, 1), day(2026, time.August, 14)},
+		{"early January seen in late December", 2, day(2026, time.December, 30), day(2027, time.January, 2)},
+		{"late December seen in early January", 365, day(2027, time.January, 3), day(2026, time.December, 31)},
+		{"day 366 falls in the leap year", 366, day(2029, time.January, 2), day(2028, time.December, 31)},
+		{"day 60 is 29 February in a leap year", 60, day(2028, time.March, 1), day(2028, time.February, 29)},
+		{"no date", 0, day(2026, time.August, 1), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leg := domain.BoardingPassLeg{DayOfYear: tt.dayOfYear}
+			if got := leg.FlightDate(tt.now); !got.Equal(tt.want) {
+				t.Errorf("FlightDate(%v) = %v, want %v", tt.now.Format(time.DateOnly), got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
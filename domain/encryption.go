@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DataKey is one version of a user's data encryption key, stored only wrapped by a master key
+type DataKey struct {
+	UserID      string    `bson:"user_id" json:"user_id"`
+	Version     int       `bson:"version" json:"version"`
+	MasterKeyID string    `bson:"master_key_id" json:"master_key_id"`
+	WrappedKey  []byte    `bson:"wrapped_key" json:"-"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+type DataKeyRepository interface {
+	// GetDataKeys returns every version of the user's data key, oldest first
+	GetDataKeys(ctx context.Context, userID string) ([]DataKey, error)
+	// CreateDataKey stores a new version and fails with ErrDataKeyExists if another writer got there first
+	CreateDataKey(ctx context.Context, key *DataKey) error
+	// UpdateDataKey replaces the wrapped key and master key ID of an existing version
+	UpdateDataKey(ctx context.Context, key *DataKey) error
+	ListDataKeys(ctx context.Context) ([]DataKey, error)
+}
+
+// FieldCipher encrypts individual field values under the owning user's data key
+type FieldCipher interface {
+	Encrypt(ctx context.Context, userID, plaintext string) (string, error)
+	Decrypt(ctx context.Context, userID, ciphertext string) (string, error)
+}
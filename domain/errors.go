@@ -0,0 +1,79 @@
+package domain
+
+import "fmt"
+
+// Kind classifies an Error so the delivery layer can pick a status without inspecting codes
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthenticated
+	KindForbidden
+	KindNotFound
+	KindConflict
+	// KindPrecondition is a failed If-Match precondition on a conditional request
+	KindPrecondition
+)
+
+// Error is a domain failure with a stable machine-readable code that clients can localize
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a domain error with the same code, so sentinels match derived errors
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Sentinel errors shared by the repositories, use cases and controllers
+var (
+	ErrValidation            = &Error{Kind: KindInvalid, Code: "validation_failed", Message: "request validation failed"}
+	ErrUnauthenticated       = &Error{Kind: KindUnauthenticated, Code: "unauthenticated", Message: "user not authenticated"}
+	ErrInvalidToken          = &Error{Kind: KindUnauthenticated, Code: "invalid_token", Message: "invalid or expired token"}
+	ErrInvalidCredentials    = &Error{Kind: KindUnauthenticated, Code: "invalid_credentials", Message: "invalid email or password"}
+	ErrIncorrectPassword     = &Error{Kind: KindInvalid, Code: "incorrect_password", Message: "incorrect old password"}
+	ErrForbidden             = &Error{Kind: KindForbidden, Code: "forbidden", Message: "you don't have permission to access this resource"}
+	ErrUserNotFound          = &Error{Kind: KindNotFound, Code: "user_not_found", Message: "user not found"}
+	ErrFlightNotFound        = &Error{Kind: KindNotFound, Code: "flight_not_found", Message: "flight not found"}
+	ErrVersionConflict       = &Error{Kind: KindConflict, Code: "version_conflict", Message: "changed since it was read; fetch the latest version and retry"}
+	ErrPreconditionFailed    = &Error{Kind: KindPrecondition, Code: "precondition_failed", Message: "the resource no longer matches If-Match; fetch it again and retry"}
+	ErrEmailTaken            = &Error{Kind: KindConflict, Code: "email_taken", Message: "user with this email already exists"}
+	ErrUsernameTaken         = &Error{Kind: KindConflict, Code: "username_taken", Message: "username already taken"}
+	ErrRegistrationDisabled  = &Error{Kind: KindForbidden, Code: "registration_disabled", Message: "registration is currently disabled"}
+	ErrTripNotFound          = &Error{Kind: KindNotFound, Code: "trip_not_found", Message: "trip not found"}
+	ErrTripReadOnly          = &Error{Kind: KindConflict, Code: "trip_read_only", Message: "this trip is a single flight; change it through /flights"}
+	ErrDataKeyExists         = &Error{Kind: KindConflict, Code: "data_key_exists", Message: "data key version already exists"}
+	ErrDataKeyNotFound       = &Error{Kind: KindNotFound, Code: "data_key_not_found", Message: "data key not found"}
+	ErrBarcodeNotFound       = &Error{Kind: KindInvalid, Code: "barcode_not_found", Message: "no readable boarding-pass barcode found in the image"}
+	ErrNoItineraryFlights    = &Error{Kind: KindInvalid, Code: "itinerary_no_flights", Message: "no flights were found in the uploaded itinerary"}
+	ErrProfileAnswerNotFound = &Error{Kind: KindNotFound, Code: "profile_answer_not_found", Message: "no saved answer for this question"}
+	ErrCalendarNotFound      = &Error{Kind: KindNotFound, Code: "calendar_not_found", Message: "calendar feed not found or revoked"}
+	ErrShareNotFound         = &Error{Kind: KindNotFound, Code: "share_not_found", Message: "share link not found, expired or revoked"}
+	ErrHouseholdNotFound     = &Error{Kind: KindNotFound, Code: "household_not_found", Message: "household not found"}
+	ErrMemberNotFound        = &Error{Kind: KindNotFound, Code: "household_member_not_found", Message: "household member not found"}
+	ErrMemberExists          = &Error{Kind: KindConflict, Code: "household_member_exists", Message: "user is already a member of this household or invited to it"}
+	ErrLastGuardian          = &Error{Kind: KindConflict, Code: "household_last_guardian", Message: "a household with other members needs at least one guardian"}
+	ErrClientRefExists       = &Error{Kind: KindConflict, Code: "client_ref_exists", Message: "a flight was already created for this client_ref"}
+)
+
+// NewValidationError returns a validation failure carrying per-field details
+func NewValidationError(message string, fields map[string]string) *Error {
+	return &Error{Kind: KindInvalid, Code: ErrValidation.Code, Message: message, Fields: fields}
+}
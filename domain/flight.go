@@ -1,13 +1,26 @@
 package domain
 
 import (
+	"context"
+	"log/slog"
 	"time"
 )
 
 // Define a new type to hold a question and its corresponding answer
 type QA struct {
-	Question string `bson:"question" json:"question"`
-	Answer   string `bson:"answer" json:"answer"`
+	// QuestionID names the template question this pair answers, if any, so the answer profile can fill it
+	QuestionID string `bson:"question_id,omitempty" json:"question_id,omitempty"`
+	Question   string `bson:"question" json:"question"`
+	Answer     string `bson:"answer" json:"answer"`
+}
+
+// LogValue keeps answers out of logs; they hold passport numbers and addresses
+func (qa QA) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("question_id", qa.QuestionID),
+		slog.String("question", qa.Question),
+		slog.String("answer", "[REDACTED]"),
+	)
 }
 
 type Flight struct {
@@ -19,18 +32,98 @@ type Flight struct {
 	UserID      string    `bson:"user_id" json:"user_id"`
 	Language    string    `bson:"language" json:"language"`
 	QA          []QA      `bson:"qa" json:"qa"`
+	// ExpiresAt is when the flight is purged under its owner's retention setting; nil keeps it forever
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_on,omitempty"`
+	// ExpiryWarnedAt records when the owner was told about the upcoming purge
+	ExpiryWarnedAt *time.Time `bson:"expiry_warned_at,omitempty" json:"-"`
+	// FlightNumber is the IATA designator such as "ET302"; AirlineCode is its two-character prefix
+	FlightNumber string `bson:"flight_number,omitempty" json:"flight_number,omitempty"`
+	AirlineCode  string `bson:"airline_code,omitempty" json:"airline_code,omitempty"`
+	// OriginAirport and DestinationAirport are IATA codes; when set they decide FromCountry and ToCountry
+	OriginAirport      string `bson:"origin_airport,omitempty" json:"origin_airport,omitempty"`
+	DestinationAirport string `bson:"destination_airport,omitempty" json:"destination_airport,omitempty"`
+	// DepartureTime and ArrivalTime are stored as instants; their IANA timezones say how to show them locally
+	DepartureTime     *time.Time `bson:"departure_time,omitempty" json:"departure_time,omitempty"`
+	DepartureTimezone string     `bson:"departure_timezone,omitempty" json:"departure_timezone,omitempty"`
+	ArrivalTime       *time.Time `bson:"arrival_time,omitempty" json:"arrival_time,omitempty"`
+	ArrivalTimezone   string     `bson:"arrival_timezone,omitempty" json:"arrival_timezone,omitempty"`
+	// Version counts the writes to a flight and UpdatedAt is when the last one happened; the repository
+	// sets both. Flights stored before versioning start at version 1 and the Unix epoch
+	Version   int64     `bson:"version" json:"version"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	// ClientRef is the device's reference for a flight created through sync, unique per user so a retried
+	// create finds the flight instead of making a second one. It is set on create and kept by every update
+	ClientRef string `bson:"client_ref,omitempty" json:"-"`
 }
 
-type FlightRepository interface {
-	CreateFlight(flight *Flight) error
-	GetFlightByID(id string) (*Flight, error)
-	DeleteFlight(id string) error
-	GetFlightsByUserID(userID string) ([]Flight, error)
+// LogValue logs a flight's identity and route without its answers
+func (f Flight) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", f.ID),
+		slog.String("user_id", f.UserID),
+		slog.String("from_country", f.FromCountry),
+		slog.String("to_country", f.ToCountry),
+		slog.Int("qa_count", len(f.QA)),
+	)
+}
+
+// FlightChange is one entry of a user's change feed: a flight as last written, or a tombstone left when
+// it was deleted
+type FlightChange struct {
+	FlightID  string
+	Version   int64
+	UpdatedAt time.Time
+	Deleted   bool
+	// Flight is nil for a tombstone
+	Flight *Flight
 }
 
-type FlightUseCase interface {
-	AddFlight(flight *Flight) error
-	FetchFlightByID(id string) (*Flight, error)
-	DeleteFlight(id string) error
-	FetchFlightsByUserID(userID string) ([]Flight, error)
+// ChangeCursor is a position in a change feed, which is ordered by UpdatedAt and then by flight ID. The
+// zero cursor is the start of the feed
+type ChangeCursor struct {
+	UpdatedAt time.Time
+	FlightID  string
+}
+
+// After reports whether a change comes after the cursor
+func (c ChangeCursor) After(change FlightChange) bool {
+	if !change.UpdatedAt.Equal(c.UpdatedAt) {
+		return change.UpdatedAt.After(c.UpdatedAt)
+	}
+	return change.FlightID > c.FlightID
+}
+
+type FlightRepository interface {
+	// CreateFlight stores a flight at version 1. It reports ErrClientRefExists when the user already has a
+	// flight with the same non-empty ClientRef
+	CreateFlight(ctx context.Context, flight *Flight) error
+	// GetFlightByID, DeleteFlight and UpdateFlight only match flights owned by userID (flight.UserID for
+	// UpdateFlight) and report any other flight as ErrFlightNotFound
+	GetFlightByID(ctx context.Context, userID, id string) (*Flight, error)
+	// DeleteFlight removes a flight and leaves a tombstone in the change feed. A non-zero version must match
+	// the stored one or ErrVersionConflict is returned
+	DeleteFlight(ctx context.Context, userID, id string, version int64) error
+	GetFlightsByUserID(ctx context.Context, userID string) ([]Flight, error)
+	// UpdateFlight replaces a flight and sets its new Version and UpdatedAt. A non-zero flight.Version must
+	// match the stored one or ErrVersionConflict is returned
+	UpdateFlight(ctx context.Context, flight *Flight) error
+	// ResealFlight rewrites the stored QA answers of a flight, e.g. under a new data key, without moving it to a
+	// new version or changing UpdatedAt. A non-zero flight.Version must match the stored one or
+	// ErrVersionConflict is returned
+	ResealFlight(ctx context.Context, flight *Flight) error
+	// GetFlightChanges returns up to limit of the user's flights and tombstones written after the cursor,
+	// in feed order
+	GetFlightChanges(ctx context.Context, userID string, after ChangeCursor, limit int) ([]FlightChange, error)
+	// GetFlightByClientRef finds the user's flight created with the given ClientRef
+	GetFlightByClientRef(ctx context.Context, userID, clientRef string) (*Flight, error)
+	// PruneTombstones drops the tombstones of flights deleted before the given time and returns how many
+	// it dropped
+	PruneTombstones(ctx context.Context, before time.Time) (int, error)
+	// ListFlights pages through every flight ordered by ID, starting after afterID
+	ListFlights(ctx context.Context, afterID string, limit int) ([]Flight, error)
+	// SetFlightExpiry records when a flight expires and when its owner was warned about it. It is bookkeeping
+	// rather than an edit, so it leaves Version and UpdatedAt alone and the flight stays out of the change feed
+	SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error
+	// FindExpiringFlights returns every flight that expires at or before the given time, soonest first
+	FindExpiringFlights(ctx context.Context, before time.Time) ([]Flight, error)
 }
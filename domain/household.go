@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// HouseholdRole is what a member may do in a household
+type HouseholdRole string
+
+const (
+	// RoleGuardian manages the household and the flights and answers of its dependents
+	RoleGuardian HouseholdRole = "guardian"
+	// RoleMember belongs to the household but manages only their own flights
+	RoleMember HouseholdRole = "member"
+	// RoleDependent has their flights and answers managed by the household's guardians
+	RoleDependent HouseholdRole = "dependent"
+)
+
+// Valid reports whether r is one of the known roles
+func (r HouseholdRole) Valid() bool {
+	return r == RoleGuardian || r == RoleMember || r == RoleDependent
+}
+
+// MemberStatus tracks an invitation; invited members have no rights until they accept
+type MemberStatus string
+
+const (
+	MemberInvited MemberStatus = "invited"
+	MemberActive  MemberStatus = "active"
+)
+
+// HouseholdMember is a user's place in a household. Dependents added by a guardian have an account of their
+// own that cannot sign in, so their flights and answers are kept apart from the guardian's
+type HouseholdMember struct {
+	UserID  string        `bson:"user_id" json:"user_id"`
+	Name    string        `bson:"name" json:"name"`
+	Role    HouseholdRole `bson:"role" json:"role"`
+	Status  MemberStatus  `bson:"status" json:"status"`
+	AddedAt time.Time     `bson:"added_at" json:"added_at"`
+}
+
+// Household groups a family or other party so guardians can manage flights for the people they travel with
+type Household struct {
+	ID        string            `bson:"_id,omitempty" json:"id"`
+	Name      string            `bson:"name" json:"name"`
+	Members   []HouseholdMember `bson:"members" json:"members"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+}
+
+// Member returns the household's record of a user
+func (h Household) Member(userID string) (HouseholdMember, bool) {
+	for _, member := range h.Members {
+		if member.UserID == userID {
+			return member, true
+		}
+	}
+	return HouseholdMember{}, false
+}
+
+// HasRole reports whether the user is an active member with the given role
+func (h Household) HasRole(userID string, role HouseholdRole) bool {
+	member, ok := h.Member(userID)
+	return ok && member.Status == MemberActive && member.Role == role
+}
+
+type HouseholdRepository interface {
+	CreateHousehold(ctx context.Context, household *Household) error
+	GetHouseholdByID(ctx context.Context, id string) (*Household, error)
+	// GetHouseholdsByUserID returns every household the user belongs to or is invited to, oldest first
+	GetHouseholdsByUserID(ctx context.Context, userID string) ([]Household, error)
+	DeleteHousehold(ctx context.Context, id string) error
+	// AddHouseholdMember fails with ErrMemberExists when the user is already a member or invited
+	AddHouseholdMember(ctx context.Context, householdID string, member HouseholdMember) error
+	// UpdateHouseholdMember replaces the role and status of an existing member
+	UpdateHouseholdMember(ctx context.Context, householdID string, member HouseholdMember) error
+	// RemoveHouseholdMember deletes the household along with its last member. It fails with ErrLastGuardian
+	// instead of removing the only active guardian while anyone else, dependents included, is still listed,
+	// and the check and the removal happen in one write so concurrent removals cannot both pass it
+	RemoveHouseholdMember(ctx context.Context, householdID, userID string) error
+}
+
+// CanRemove reports whether the household keeps an active guardian, or has no one left, without the user
+func (h Household) CanRemove(userID string) bool {
+	remaining, guardians := 0, 0
+	for _, member := range h.Members {
+		if member.UserID == userID {
+			continue
+		}
+		remaining++
+		if member.Role == RoleGuardian && member.Status == MemberActive {
+			guardians++
+		}
+	}
+	return remaining == 0 || guardians > 0 || !h.HasRole(userID, RoleGuardian)
+}
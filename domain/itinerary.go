@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ItinerarySegment is one flight found in an airline confirmation email or calendar file
+type ItinerarySegment struct {
+	// Source says where the flight was found: "json-ld", "microdata", "ics" or "text"
+	Source             string `json:"source"`
+	ReservationNumber  string `json:"reservation_number,omitempty"`
+	PassengerName      string `json:"passenger_name,omitempty"`
+	FlightNumber       string `json:"flight_number"`
+	OriginAirport      string `json:"origin_airport,omitempty"`
+	DestinationAirport string `json:"destination_airport,omitempty"`
+	// Date is the departure day; it is set even when the itinerary gives no departure time
+	Date              time.Time  `json:"date"`
+	DepartureTime     *time.Time `json:"departure_time,omitempty"`
+	DepartureTimezone string     `json:"departure_timezone,omitempty"`
+	ArrivalTime       *time.Time `json:"arrival_time,omitempty"`
+	ArrivalTimezone   string     `json:"arrival_timezone,omitempty"`
+}
+
+// LogValue keeps the passenger's name and booking reference out of logs
+func (s ItinerarySegment) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("source", s.Source),
+		slog.String("flight_number", s.FlightNumber),
+		slog.String("origin_airport", s.OriginAirport),
+		slog.String("destination_airport", s.DestinationAirport),
+	)
+}
+
+// ItineraryReader extracts flights from an uploaded .eml email or .ics calendar file
+type ItineraryReader interface {
+	ReadItinerary(ctx context.Context, data []byte) ([]ItinerarySegment, error)
+}
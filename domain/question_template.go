@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// QuestionTemplate is a question that arrival and immigration cards ask, identified across flights by a
+// stable ID so its answer can be reused
+type QuestionTemplate struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+	// DateSensitive answers, such as the length of stay, change with every trip and are never saved to
+	// the answer profile
+	DateSensitive bool `json:"date_sensitive"`
+}
+
+// questionTemplates is the catalogue of template questions, keyed by ID
+var questionTemplates = map[string]QuestionTemplate{
+	"full_name":              {ID: "full_name", Question: "Full name"},
+	"date_of_birth":          {ID: "date_of_birth", Question: "Date of birth"},
+	"place_of_birth":         {ID: "place_of_birth", Question: "Place of birth"},
+	"nationality":            {ID: "nationality", Question: "Passport nationality"},
+	"passport_number":        {ID: "passport_number", Question: "Passport number"},
+	"passport_expiry":        {ID: "passport_expiry", Question: "Passport expiry date"},
+	"occupation":             {ID: "occupation", Question: "Occupation"},
+	"home_address":           {ID: "home_address", Question: "Home address"},
+	"phone_number":           {ID: "phone_number", Question: "Phone number"},
+	"email":                  {ID: "email", Question: "Email address"},
+	"purpose_of_visit":       {ID: "purpose_of_visit", Question: "Purpose of visit"},
+	"address_in_destination": {ID: "address_in_destination", Question: "Address during your stay"},
+	"length_of_stay":         {ID: "length_of_stay", Question: "Length of stay", DateSensitive: true},
+	"arrival_date":           {ID: "arrival_date", Question: "Date of arrival", DateSensitive: true},
+	"departure_date":         {ID: "departure_date", Question: "Date of departure", DateSensitive: true},
+}
+
+// countryQuestionTemplates holds the templates a destination's arrival card words or flags differently,
+// keyed by ISO 3166 alpha-2 code; anything a country does not list comes from the global catalogue
+var countryQuestionTemplates = map[string]map[string]QuestionTemplate{
+	"AU": {
+		"address_in_destination": {ID: "address_in_destination", Question: "Intended address in Australia"},
+	},
+	"JP": {
+		"address_in_destination": {ID: "address_in_destination", Question: "Intended address in Japan"},
+		"length_of_stay":         {ID: "length_of_stay", Question: "Intended length of stay in Japan", DateSensitive: true},
+	},
+	"TH": {
+		"address_in_destination": {ID: "address_in_destination", Question: "Residence in Thailand"},
+		"length_of_stay":         {ID: "length_of_stay", Question: "Length of stay in Thailand", DateSensitive: true},
+	},
+	"US": {
+		"address_in_destination": {ID: "address_in_destination", Question: "Address while in the United States"},
+	},
+}
+
+// LookupQuestionTemplate returns the template question with the given ID
+func LookupQuestionTemplate(id string) (QuestionTemplate, bool) {
+	template, ok := questionTemplates[id]
+	return template, ok
+}
+
+// LookupCountryQuestionTemplate returns the template question with the given ID as the destination country
+// asks it, falling back to the global catalogue
+func LookupCountryQuestionTemplate(country, id string) (QuestionTemplate, bool) {
+	if template, ok := countryQuestionTemplates[strings.ToUpper(strings.TrimSpace(country))][id]; ok {
+		return template, true
+	}
+	return LookupQuestionTemplate(id)
+}
+
+// QuestionTemplates lists the catalogue sorted by ID
+func QuestionTemplates() []QuestionTemplate {
+	templates := make([]QuestionTemplate, 0, len(questionTemplates))
+	for _, template := range questionTemplates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates
+}
+
+// IsDateSensitive reports whether a QA pair's answer depends on the travel dates on a flight to country: it
+// names a template that is date-sensitive there, or was typed without one but asks a date-sensitive
+// template's question word for word, in the destination's wording or the global one
+func IsDateSensitive(qa QA, country string) bool {
+	if qa.QuestionID != "" {
+		template, ok := LookupCountryQuestionTemplate(country, qa.QuestionID)
+		return ok && template.DateSensitive
+	}
+	question := strings.TrimSpace(qa.Question)
+	for _, catalogue := range []map[string]QuestionTemplate{countryQuestionTemplates[strings.ToUpper(strings.TrimSpace(country))], questionTemplates} {
+		for _, template := range catalogue {
+			if template.DateSensitive && strings.EqualFold(question, template.Question) {
+				return true
+			}
+		}
+	}
+	return false
+}
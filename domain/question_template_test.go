@@ -0,0 +1,47 @@
+package domain_test
+
+import (
+	"testing"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+func TestIsDateSensitive(t *testing.T) {
+	tests := []struct {
+		name    string
+		qa      domain.QA
+		country string
+		want    bool
+	}{
+		{"global template by ID", domain.QA{QuestionID: "length_of_stay"}, "PT", true},
+		{"stable template by ID", domain.QA{QuestionID: "passport_number"}, "PT", false},
+		{"country template by ID", domain.QA{QuestionID: "length_of_stay"}, "jp", true},
+		{"unknown ID", domain.QA{QuestionID: "favourite_colour"}, "JP", false},
+		{"global wording", domain.QA{Question: " date of arrival "}, "PT", true},
+		{"destination wording", domain.QA{Question: "Intended length of stay in Japan"}, "JP", true},
+		{"another destination's wording", domain.QA{Question: "Intended length of stay in Japan"}, "TH", false},
+		{"global wording on a flight with a country template", domain.QA{Question: "Length of stay"}, "JP", true},
+		{"no destination", domain.QA{Question: "Length of stay"}, "", true},
+		{"free text", domain.QA{Question: "Hotel name"}, "JP", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := domain.IsDateSensitive(tt.qa, tt.country); got != tt.want {
+				t.Errorf("IsDateSensitive(%+v, %q) = %v, want %v", tt.qa, tt.country, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupCountryQuestionTemplate(t *testing.T) {
+	if template, ok := domain.LookupCountryQuestionTemplate("US", "address_in_destination"); !ok || template.Question != "Address while in the United States" {
+		t.Errorf("US address template = %+v, %v; want the US wording", template, ok)
+	}
+	global, _ := domain.LookupQuestionTemplate("passport_number")
+	if template, ok := domain.LookupCountryQuestionTemplate("US", "passport_number"); !ok || template != global {
+		t.Errorf("US passport template = %+v, %v; want the global %+v", template, ok, global)
+	}
+	if _, ok := domain.LookupCountryQuestionTemplate("US", "favourite_colour"); ok {
+		t.Error("an unknown ID resolved to a template")
+	}
+}
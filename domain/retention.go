@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MaxRetentionDays caps the retention setting at roughly ten years
+const MaxRetentionDays = 3650
+
+// FlightExpiry returns when a flight on the given date expires under a retention of retentionDays, or nil to keep it
+func FlightExpiry(date time.Time, retentionDays int) *time.Time {
+	if retentionDays <= 0 {
+		return nil
+	}
+	expiresAt := date.UTC().AddDate(0, 0, retentionDays)
+	return &expiresAt
+}
+
+// TripExpiry returns when a trip expires under a retention of retentionDays, counted from its last leg, or nil
+// to keep it
+func TripExpiry(trip Trip, retentionDays int) *time.Time {
+	return FlightExpiry(trip.EndDate(), retentionDays)
+}
+
+// ExpiryNotifier passes on a warning that a flight or trip is about to be purged beyond the app itself, which
+// shows it from the warned mark
+type ExpiryNotifier interface {
+	NotifyFlightExpiring(ctx context.Context, flight Flight, expiresAt time.Time) error
+	NotifyTripExpiring(ctx context.Context, trip Trip, expiresAt time.Time) error
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// FlightShare is a read-only link to one flight that its owner hands to a companion or travel agent. The
+// link carries a signed token naming the share; the share itself records whether the link still works
+type FlightShare struct {
+	ID       string `bson:"_id,omitempty" json:"id"`
+	FlightID string `bson:"flight_id" json:"flight_id"`
+	UserID   string `bson:"user_id" json:"user_id"`
+	// Label reminds the owner who the link was given to
+	Label          string     `bson:"label,omitempty" json:"label,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt      time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt      *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	AccessCount    int        `bson:"access_count" json:"access_count"`
+	LastAccessedAt *time.Time `bson:"last_accessed_at,omitempty" json:"last_accessed_at,omitempty"`
+}
+
+// Active reports whether the link still opens the flight at the given time
+func (s FlightShare) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type ShareRepository interface {
+	CreateShare(ctx context.Context, share *FlightShare) error
+	GetShareByID(ctx context.Context, id string) (*FlightShare, error)
+	// GetSharesByFlightID returns a flight's shares, newest first
+	GetSharesByFlightID(ctx context.Context, flightID string) ([]FlightShare, error)
+	RevokeShare(ctx context.Context, id string, revokedAt time.Time) error
+	// RecordShareAccess counts one opening of the link and remembers when it happened
+	RecordShareAccess(ctx context.Context, id string, accessedAt time.Time) error
+}
+
+// ShareTokenSigner issues and checks the tokens in share links, so forged or expired links are turned away
+// without a lookup
+type ShareTokenSigner interface {
+	SignShareToken(shareID string, expiresAt time.Time) (string, error)
+	// VerifyShareToken returns the share ID of a genuine, unexpired token
+	VerifyShareToken(token string) (string, error)
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Leg is one hop of a trip; intermediate legs end in the transit countries
+type Leg struct {
+	FromCountry string    `bson:"from_country" json:"from_country"`
+	ToCountry   string    `bson:"to_country" json:"to_country"`
+	Date        time.Time `bson:"date" json:"date"`
+	Language    string    `bson:"language" json:"language"`
+	QA          []QA      `bson:"qa" json:"qa"`
+}
+
+// Trip is an ordered sequence of legs owned by a single user
+type Trip struct {
+	ID     string `bson:"_id,omitempty" json:"id"`
+	Title  string `bson:"title" json:"title"`
+	UserID string `bson:"user_id" json:"user_id"`
+	Legs   []Leg  `bson:"legs" json:"legs"`
+	// ExpiresAt is when the trip is purged under its owner's retention setting, counted from its last leg;
+	// nil keeps it forever
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_on,omitempty"`
+	// ExpiryWarnedAt records when the owner was told about the upcoming purge
+	ExpiryWarnedAt *time.Time `bson:"expiry_warned_at,omitempty" json:"-"`
+	// Version counts the writes to a trip; the repository maintains it
+	Version int64 `bson:"version" json:"version"`
+	// FromFlight marks a single flight presented as a one-leg trip; it is never stored
+	FromFlight bool `bson:"-" json:"-"`
+}
+
+// LogValue logs a trip's identity and route without its answers
+func (t Trip) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", t.ID),
+		slog.String("user_id", t.UserID),
+		slog.Int("leg_count", len(t.Legs)),
+	)
+}
+
+// StartDate is the date of the first leg, used to order trips
+func (t Trip) StartDate() time.Time {
+	if len(t.Legs) == 0 {
+		return time.Time{}
+	}
+	return t.Legs[0].Date
+}
+
+// EndDate is the date of the last leg, from which the trip's expiry is counted
+func (t Trip) EndDate() time.Time {
+	if len(t.Legs) == 0 {
+		return time.Time{}
+	}
+	return t.Legs[len(t.Legs)-1].Date
+}
+
+// TripFromFlight presents a single flight as a one-leg trip with the same ID
+func TripFromFlight(flight Flight) Trip {
+	return Trip{
+		ID:     flight.ID,
+		Title:  flight.Title,
+		UserID: flight.UserID,
+		Legs: []Leg{{
+			FromCountry: flight.FromCountry,
+			ToCountry:   flight.ToCountry,
+			Date:        flight.Date,
+			Language:    flight.Language,
+			QA:          flight.QA,
+		}},
+		ExpiresAt:      flight.ExpiresAt,
+		ExpiryWarnedAt: flight.ExpiryWarnedAt,
+		Version:        flight.Version,
+		FromFlight:     true,
+	}
+}
+
+type TripRepository interface {
+	// CreateTrip stores a trip at version 1
+	CreateTrip(ctx context.Context, trip *Trip) error
+	// GetTripByID, DeleteTrip and UpdateTrip only match trips owned by userID (trip.UserID for UpdateTrip)
+	// and report any other trip as ErrTripNotFound
+	GetTripByID(ctx context.Context, userID, id string) (*Trip, error)
+	// GetTripsByUserID returns the user's trips ordered by the date of their first leg
+	GetTripsByUserID(ctx context.Context, userID string) ([]Trip, error)
+	// UpdateTrip replaces a trip and sets its new Version. A non-zero trip.Version must match the stored one
+	// or ErrVersionConflict is returned
+	UpdateTrip(ctx context.Context, trip *Trip) error
+	// ResealTrip rewrites the stored QA answers of a trip's legs without moving it to a new version. A non-zero
+	// trip.Version must match the stored one or ErrVersionConflict is returned
+	ResealTrip(ctx context.Context, trip *Trip) error
+	// DeleteTrip removes a trip. A non-zero version must match the stored one or ErrVersionConflict is returned
+	DeleteTrip(ctx context.Context, userID, id string, version int64) error
+	// ListTrips pages through every trip ordered by ID, starting after afterID
+	ListTrips(ctx context.Context, afterID string, limit int) ([]Trip, error)
+	// SetTripExpiry records when a trip expires and when its owner was warned about it. It is bookkeeping
+	// rather than an edit, so it leaves Version alone
+	SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error
+	// FindExpiringTrips returns every trip that expires at or before the given time, soonest first
+	FindExpiringTrips(ctx context.Context, before time.Time) ([]Trip, error)
+}
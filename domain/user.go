@@ -1,19 +1,46 @@
 package domain
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"context"
+	"log/slog"
+)
 
 type User struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Username string             `bson:"username" json:"username" binding:"required"`
-	Password string             `bson:"password,omitempty" json:"password" binding:"required"`
-	Email    string             `bson:"email" json:"email" binding:"required,email"`
+	ID       string `bson:"-" json:"id"`
+	Username string `bson:"username" json:"username" binding:"required"`
+	Password string `bson:"password,omitempty" json:"password" binding:"required"`
+	Email    string `bson:"email" json:"email" binding:"required,email"`
+	// RetentionDays is how long after a flight's date it is kept; zero keeps flights forever
+	RetentionDays int `bson:"retention_days" json:"retention_days"`
+	// CalendarTokenHash is the SHA-256 of the secret in the user's calendar feed URL; empty when no feed is issued
+	CalendarTokenHash string `bson:"calendar_token_hash,omitempty" json:"-"`
+	// Version counts writes to the user record, not to the answer profile; the repository sets it
+	Version int64 `bson:"version" json:"version"`
+}
+
+// LogValue logs only the user's ID so emails and password hashes never reach the logs
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", u.ID))
 }
 
 type UserRepository interface {
-	CreateUser(user *User) error
-	FindUserByEmail(email string) (*User, error)
-	FindUserByUsername(username string) (*User, error)
-	FindUserByID(id string) (*User, error)
-	UpdateUsername(id, newUsername string) error
-	UpdatePassword(id, hashedPassword string) error
+	CreateUser(ctx context.Context, user *User) error
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	FindUserByUsername(ctx context.Context, username string) (*User, error)
+	FindUserByID(ctx context.Context, id string) (*User, error)
+	// UpdateUsername, UpdatePassword and UpdateRetention bump the user's version and return the new one. A
+	// non-zero version must match the stored one or ErrVersionConflict is returned; zero writes unconditionally
+	UpdateUsername(ctx context.Context, id, newUsername string, version int64) (int64, error)
+	UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (int64, error)
+	UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (int64, error)
+	// UpdateCalendarToken stores the hash of a new calendar feed token; an empty hash revokes the feed
+	UpdateCalendarToken(ctx context.Context, id, tokenHash string) error
+	FindUserByCalendarToken(ctx context.Context, tokenHash string) (*User, error)
+	// GetAnswerProfile returns the user's saved answers; a user with none has an empty profile
+	GetAnswerProfile(ctx context.Context, userID string) (*AnswerProfile, error)
+	// SaveProfileAnswers inserts or replaces answers by question ID, leaving the user's other answers alone
+	SaveProfileAnswers(ctx context.Context, userID string, answers []ProfileAnswer) error
+	DeleteProfileAnswer(ctx context.Context, userID, questionID string) error
+	// ListAnswerProfiles pages through the non-empty profiles in user ID order, for maintenance jobs
+	ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) ([]AnswerProfile, error)
 }
@@ -0,0 +1,60 @@
+package Infrastructure
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// airportsCSV is a curated subset of the OurAirports dataset covering the hubs our travellers use;
+// rows are iata,name,city,country,country_code,timezone
+//
+//go:embed data/airports.csv
+var airportsCSV []byte
+
+// AirportDirectory serves airport lookups from the embedded dataset
+type AirportDirectory struct {
+	airports map[string]domain.Airport
+}
+
+// NewAirportDirectory parses the embedded dataset, checking every timezone against the tz database
+func NewAirportDirectory() (*AirportDirectory, error) {
+	records, err := csv.NewReader(bytes.NewReader(airportsCSV)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading airport dataset: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("airport dataset is empty")
+	}
+
+	airports := make(map[string]domain.Airport, len(records)-1)
+	for i, record := range records[1:] {
+		if len(record) != 6 {
+			return nil, fmt.Errorf("airport dataset line %d: expected 6 fields, got %d", i+2, len(record))
+		}
+		airport := domain.Airport{
+			IATA:        strings.ToUpper(record[0]),
+			Name:        record[1],
+			City:        record[2],
+			Country:     record[3],
+			CountryCode: record[4],
+			Timezone:    record[5],
+		}
+		if _, err := time.LoadLocation(airport.Timezone); err != nil {
+			return nil, fmt.Errorf("airport dataset line %d: %w", i+2, err)
+		}
+		airports[airport.IATA] = airport
+	}
+	return &AirportDirectory{airports: airports}, nil
+}
+
+// LookupAirport returns the airport with the given IATA code, ignoring case
+func (d *AirportDirectory) LookupAirport(code string) (domain.Airport, bool) {
+	airport, ok := d.airports[strings.ToUpper(strings.TrimSpace(code))]
+	return airport, ok
+}
@@ -1,32 +1,41 @@
 package Infrastructure
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing or malformed"})
+			tokenFailures.WithLabelValues("missing").Inc()
+			c.Error(domain.ErrUnauthenticated)
+			c.Abort()
 			return
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		claims, err := ValidateToken(token)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			tokenFailures.WithLabelValues("invalid").Inc()
+			c.Error(domain.ErrInvalidToken)
+			c.Abort()
 			return
 		}
 
 		// Extract user ID from claims
 		if userID, ok := claims["user_id"].(string); ok {
 			c.Set("user_id", userID)
+			trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("user.id", userID))
 		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			tokenFailures.WithLabelValues("claims").Inc()
+			c.Error(domain.ErrInvalidToken)
+			c.Abort()
 			return
 		}
 
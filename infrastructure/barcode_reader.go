@@ -0,0 +1,66 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/qrcode"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// maxBarcodePixels bounds the decoded image size so a small compressed upload cannot exhaust memory
+const maxBarcodePixels = 25_000_000
+
+// BarcodeReader decodes boarding-pass barcodes in process: PDF417 as printed on paper passes, and the
+// Aztec and QR codes airlines use on mobile passes
+type BarcodeReader struct{}
+
+// NewBarcodeReader returns a reader for PNG, JPEG and GIF images
+func NewBarcodeReader() *BarcodeReader {
+	return &BarcodeReader{}
+}
+
+// DecodeBarcode returns the text of the first barcode found in the image
+func (r *BarcodeReader) DecodeBarcode(ctx context.Context, data []byte) (string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", domain.NewValidationError("Image must be a PNG, JPEG or GIF", map[string]string{"image": "image_format"})
+	}
+	if config.Width*config.Height > maxBarcodePixels {
+		return "", domain.NewValidationError("Image is too large", map[string]string{"image": "max_pixels"})
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", domain.NewValidationError("Image could not be decoded", map[string]string{"image": "image_format"})
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+	for _, reader := range []gozxing.Reader{aztec.NewAztecReader(), qrcode.NewQRCodeReader()} {
+		if result, err := reader.Decode(bitmap, map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}); err == nil {
+			return result.GetText(), nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+
+	// The PDF417 reader rotates its matrix, so it gets its own rather than the shared bitmap's
+	matrix, err := gozxing.NewHybridBinarizer(gozxing.NewLuminanceSourceFromImage(img)).GetBlackMatrix()
+	if err != nil {
+		return "", err
+	}
+	if text, err := decodePDF417(matrix); err == nil {
+		return text, nil
+	}
+	return "", domain.ErrBarcodeNotFound
+}
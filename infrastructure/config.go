@@ -0,0 +1,537 @@
+package Infrastructure
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the service needs, loaded from defaults, a YAML file, the environment and flags
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Encryption EncryptionConfig `yaml:"encryption"`
+	Retention  RetentionConfig  `yaml:"retention"`
+	Calendar   CalendarConfig   `yaml:"calendar"`
+	Sharing    SharingConfig    `yaml:"sharing"`
+	Sync       SyncConfig       `yaml:"sync"`
+	CORS       CORSConfig       `yaml:"cors"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Logging    LoggingConfig    `yaml:"logging"`
+	Features   FeatureConfig    `yaml:"features"`
+}
+
+type ServerConfig struct {
+	Port            int           `yaml:"port"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// DrainDelay is how long /readyz reports draining before the listener closes, so load balancers stop
+	// sending new requests first
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// MetricsPort is the separate listener for /metrics, kept off the public port
+	MetricsPort int `yaml:"metrics_port"`
+	// PublicURL is the externally visible base URL used in links handed to other apps; empty derives it from each request
+	PublicURL string `yaml:"public_url"`
+}
+
+type StorageConfig struct {
+	Backend       string        `yaml:"backend"`
+	MongoURI      string        `yaml:"mongo_uri"`
+	MongoDatabase string        `yaml:"mongo_database"`
+	SQLDSN        string        `yaml:"sql_dsn"`
+	ReadTimeout   time.Duration `yaml:"read_timeout"`
+	WriteTimeout  time.Duration `yaml:"write_timeout"`
+}
+
+type AuthConfig struct {
+	JWTSecret          string        `yaml:"jwt_secret"`
+	JWTPreviousSecrets []string      `yaml:"jwt_previous_secrets"`
+	TokenTTL           time.Duration `yaml:"token_ttl"`
+	BcryptCost         int           `yaml:"bcrypt_cost"`
+	// AdminUserIDs may view and manage every user's flights, trips and answers
+	AdminUserIDs []string `yaml:"admin_user_ids"`
+}
+
+// EncryptionConfig lists master keys as "id:base64key"; QA answers are encrypted only when keys are configured
+type EncryptionConfig struct {
+	MasterKeys   []string `yaml:"master_keys"`
+	CurrentKeyID string   `yaml:"current_key_id"`
+}
+
+// Enabled reports whether field-level encryption is configured
+func (e EncryptionConfig) Enabled() bool {
+	return len(e.MasterKeys) > 0
+}
+
+// RetentionConfig controls how long flights are kept after their date and how the purge worker runs
+type RetentionConfig struct {
+	DefaultDays   int           `yaml:"default_days"`
+	Warning       time.Duration `yaml:"warning"`
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+}
+
+// CalendarConfig controls the iCalendar export; CardURL is the link each event carries back to the flight's
+// answer card, with {id} replaced by the flight ID
+type CalendarConfig struct {
+	CardURL string `yaml:"card_url"`
+}
+
+// SharingConfig bounds the lifetime of read-only flight share links
+type SharingConfig struct {
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+	MaxTTL     time.Duration `yaml:"max_ttl"`
+}
+
+// SyncConfig controls the change feed offline devices sync from
+type SyncConfig struct {
+	// SafetyLag is how much of the feed each sync reads again, covering writes that commit late
+	SafetyLag time.Duration `yaml:"safety_lag"`
+	// TombstoneRetention is how long deletes stay in the feed; 0 keeps them forever
+	TombstoneRetention time.Duration `yaml:"tombstone_retention"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	File        string  `yaml:"file"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type FeatureConfig struct {
+	Registration bool `yaml:"registration"`
+	Metrics      bool `yaml:"metrics"`
+}
+
+// DefaultConfig returns the settings used when nothing overrides them
+func DefaultConfig() Config {
+	return Config{
+		Server: ServerConfig{Port: 8080, MetricsPort: 9090, ShutdownTimeout: 15 * time.Second, DrainDelay: 5 * time.Second},
+		Storage: StorageConfig{
+			Backend:       "mongo",
+			MongoDatabase: "passme",
+			ReadTimeout:   5 * time.Second,
+			WriteTimeout:  10 * time.Second,
+		},
+		Auth: AuthConfig{
+			TokenTTL:   24 * time.Hour,
+			BcryptCost: bcrypt.DefaultCost,
+		},
+		Retention: RetentionConfig{
+			Warning:       7 * 24 * time.Hour,
+			SweepInterval: time.Hour,
+		},
+		Calendar: CalendarConfig{CardURL: "passme://flights/{id}"},
+		Sharing: SharingConfig{
+			DefaultTTL: 7 * 24 * time.Hour,
+			MaxTTL:     90 * 24 * time.Hour,
+		},
+		Sync: SyncConfig{
+			SafetyLag:          time.Minute,
+			TombstoneRetention: 30 * 24 * time.Hour,
+		},
+		CORS: CORSConfig{AllowedOrigins: []string{"*"}},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			File:        "traces.jsonl",
+			ServiceName: "passme-api",
+			SampleRatio: 1,
+		},
+		Logging:  LoggingConfig{Level: "info", Format: "json"},
+		Features: FeatureConfig{Registration: true, Metrics: true},
+	}
+}
+
+// setting binds one config field to an environment variable and an optional command-line flag
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	get    func(c *Config) string
+	set    func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{env: "PORT", flag: "port", usage: "HTTP listen port",
+		get: func(c *Config) string { return strconv.Itoa(c.Server.Port) },
+		set: func(c *Config, v string) error { return parseInt(v, &c.Server.Port) }},
+	{env: "METRICS_PORT", usage: "listen port for Prometheus metrics, separate from the public API",
+		get: func(c *Config) string { return strconv.Itoa(c.Server.MetricsPort) },
+		set: func(c *Config, v string) error { return parseInt(v, &c.Server.MetricsPort) }},
+	{env: "SHUTDOWN_TIMEOUT", usage: "how long to drain in-flight requests on shutdown",
+		get: func(c *Config) string { return c.Server.ShutdownTimeout.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Server.ShutdownTimeout) }},
+	{env: "DRAIN_DELAY", usage: "how long readiness fails before the listener closes on shutdown",
+		get: func(c *Config) string { return c.Server.DrainDelay.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Server.DrainDelay) }},
+	{env: "PUBLIC_URL", usage: "externally visible base URL, such as https://api.example.com, for calendar feed links",
+		get: func(c *Config) string { return c.Server.PublicURL },
+		set: func(c *Config, v string) error { c.Server.PublicURL = v; return nil }},
+	{env: "STORAGE", flag: "storage", usage: "storage backend: mongo, postgres, sqlite or memory",
+		get: func(c *Config) string { return c.Storage.Backend },
+		set: func(c *Config, v string) error { c.Storage.Backend = v; return nil }},
+	{env: "MONGO_URI", usage: "MongoDB connection string", secret: true,
+		get: func(c *Config) string { return c.Storage.MongoURI },
+		set: func(c *Config, v string) error { c.Storage.MongoURI = v; return nil }},
+	{env: "MONGO_DB", usage: "MongoDB database name",
+		get: func(c *Config) string { return c.Storage.MongoDatabase },
+		set: func(c *Config, v string) error { c.Storage.MongoDatabase = v; return nil }},
+	{env: "SQL_DSN", usage: "PostgreSQL or SQLite data source name", secret: true,
+		get: func(c *Config) string { return c.Storage.SQLDSN },
+		set: func(c *Config, v string) error { c.Storage.SQLDSN = v; return nil }},
+	{env: "STORAGE_READ_TIMEOUT", usage: "deadline for a single read operation",
+		get: func(c *Config) string { return c.Storage.ReadTimeout.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Storage.ReadTimeout) }},
+	{env: "STORAGE_WRITE_TIMEOUT", usage: "deadline for a single write operation",
+		get: func(c *Config) string { return c.Storage.WriteTimeout.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Storage.WriteTimeout) }},
+	{env: "JWT_SECRET", usage: "HMAC key used to sign access tokens", secret: true,
+		get: func(c *Config) string { return c.Auth.JWTSecret },
+		set: func(c *Config, v string) error { c.Auth.JWTSecret = v; return nil }},
+	{env: "JWT_PREVIOUS_SECRETS", usage: "comma-separated retired keys still accepted for validation", secret: true,
+		get: func(c *Config) string { return strings.Join(c.Auth.JWTPreviousSecrets, ",") },
+		set: func(c *Config, v string) error { c.Auth.JWTPreviousSecrets = splitList(v); return nil }},
+	{env: "JWT_TTL", usage: "lifetime of issued access tokens",
+		get: func(c *Config) string { return c.Auth.TokenTTL.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Auth.TokenTTL) }},
+	{env: "BCRYPT_COST", usage: "bcrypt work factor for password hashes",
+		get: func(c *Config) string { return strconv.Itoa(c.Auth.BcryptCost) },
+		set: func(c *Config, v string) error { return parseInt(v, &c.Auth.BcryptCost) }},
+	{env: "ADMIN_USER_IDS", usage: "comma-separated IDs of users allowed to act on any user's data",
+		get: func(c *Config) string { return strings.Join(c.Auth.AdminUserIDs, ",") },
+		set: func(c *Config, v string) error { c.Auth.AdminUserIDs = splitList(v); return nil }},
+	{env: "ENCRYPTION_MASTER_KEYS", usage: "comma-separated id:base64 master keys that wrap per-user data keys", secret: true,
+		get: func(c *Config) string { return strings.Join(c.Encryption.MasterKeys, ",") },
+		set: func(c *Config, v string) error { c.Encryption.MasterKeys = splitList(v); return nil }},
+	{env: "ENCRYPTION_MASTER_KEY_ID", usage: "ID of the master key used to wrap new data keys",
+		get: func(c *Config) string { return c.Encryption.CurrentKeyID },
+		set: func(c *Config, v string) error { c.Encryption.CurrentKeyID = v; return nil }},
+	{env: "RETENTION_DEFAULT_DAYS", usage: "days after a flight's date that new users keep it; 0 keeps flights forever",
+		get: func(c *Config) string { return strconv.Itoa(c.Retention.DefaultDays) },
+		set: func(c *Config, v string) error { return parseInt(v, &c.Retention.DefaultDays) }},
+	{env: "RETENTION_WARNING", usage: "how long before a purge the owner is warned",
+		get: func(c *Config) string { return c.Retention.Warning.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Retention.Warning) }},
+	{env: "RETENTION_SWEEP_INTERVAL", usage: "how often expired flights are looked for",
+		get: func(c *Config) string { return c.Retention.SweepInterval.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Retention.SweepInterval) }},
+	{env: "CALENDAR_CARD_URL", usage: "link from each calendar event to its answer card; {id} is replaced by the flight ID",
+		get: func(c *Config) string { return c.Calendar.CardURL },
+		set: func(c *Config, v string) error { c.Calendar.CardURL = v; return nil }},
+	{env: "SHARE_DEFAULT_TTL", usage: "lifetime of a flight share link when the owner does not choose one",
+		get: func(c *Config) string { return c.Sharing.DefaultTTL.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Sharing.DefaultTTL) }},
+	{env: "SHARE_MAX_TTL", usage: "longest lifetime an owner may give a flight share link",
+		get: func(c *Config) string { return c.Sharing.MaxTTL.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Sharing.MaxTTL) }},
+	{env: "SYNC_SAFETY_LAG", usage: "how much of the change feed each sync reads again; must exceed STORAGE_WRITE_TIMEOUT",
+		get: func(c *Config) string { return c.Sync.SafetyLag.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Sync.SafetyLag) }},
+	{env: "SYNC_TOMBSTONE_RETENTION", usage: "how long deleted flights stay in the change feed; older cursors get a full resync, 0 keeps them forever",
+		get: func(c *Config) string { return c.Sync.TombstoneRetention.String() },
+		set: func(c *Config, v string) error { return parseDuration(v, &c.Sync.TombstoneRetention) }},
+	{env: "CORS_ORIGINS", usage: "comma-separated list of allowed CORS origins",
+		get: func(c *Config) string { return strings.Join(c.CORS.AllowedOrigins, ",") },
+		set: func(c *Config, v string) error { c.CORS.AllowedOrigins = splitList(v); return nil }},
+	{env: "TRACING_EXPORTER", flag: "tracing", usage: "trace exporter: none, stdout, file or otlp",
+		get: func(c *Config) string { return c.Tracing.Exporter },
+		set: func(c *Config, v string) error { c.Tracing.Exporter = v; return nil }},
+	{env: "TRACING_FILE", usage: "file that the file exporter appends spans to",
+		get: func(c *Config) string { return c.Tracing.File },
+		set: func(c *Config, v string) error { c.Tracing.File = v; return nil }},
+	{env: "TRACING_SERVICE_NAME", usage: "service.name resource attribute",
+		get: func(c *Config) string { return c.Tracing.ServiceName },
+		set: func(c *Config, v string) error { c.Tracing.ServiceName = v; return nil }},
+	{env: "TRACING_SAMPLE_RATIO", usage: "fraction of new traces to sample, from 0 to 1",
+		get: func(c *Config) string { return strconv.FormatFloat(c.Tracing.SampleRatio, 'f', -1, 64) },
+		set: func(c *Config, v string) error { return parseFloat(v, &c.Tracing.SampleRatio) }},
+	{env: "LOG_LEVEL", flag: "log-level", usage: "minimum log level: debug, info, warn or error",
+		get: func(c *Config) string { return c.Logging.Level },
+		set: func(c *Config, v string) error { c.Logging.Level = v; return nil }},
+	{env: "LOG_FORMAT", usage: "log output format: json or text",
+		get: func(c *Config) string { return c.Logging.Format },
+		set: func(c *Config, v string) error { c.Logging.Format = v; return nil }},
+	{env: "FEATURE_REGISTRATION", usage: "allow new users to register",
+		get: func(c *Config) string { return strconv.FormatBool(c.Features.Registration) },
+		set: func(c *Config, v string) error { return parseBool(v, &c.Features.Registration) }},
+	{env: "FEATURE_METRICS", usage: "expose Prometheus metrics on /metrics at METRICS_PORT",
+		get: func(c *Config) string { return strconv.FormatBool(c.Features.Metrics) },
+		set: func(c *Config, v string) error { return parseBool(v, &c.Features.Metrics) }},
+}
+
+// LoadConfig builds the configuration from defaults, then the YAML file named by -config or CONFIG_FILE,
+// then environment variables, then flags. It reports whether -print-config was requested.
+func LoadConfig(args []string) (*Config, bool, error) {
+	fs := flag.NewFlagSet("passme", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+	flagValues := map[string]*string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			flagValues[s.flag] = fs.String(s.flag, "", s.usage+" (env "+s.env+")")
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	cfg := DefaultConfig()
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, false, fmt.Errorf("error reading config file: %v", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, false, fmt.Errorf("error parsing config file %s: %v", *configFile, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&cfg, value); err != nil {
+				return nil, false, fmt.Errorf("invalid %s: %v", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.set(&cfg, *flagValues[s.flag]); err != nil {
+					flagErr = fmt.Errorf("invalid -%s: %v", s.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, false, flagErr
+	}
+
+	// The in-memory backend is for offline development, so it may run with a throwaway signing key
+	if cfg.Auth.JWTSecret == "" && cfg.Storage.Backend == "memory" {
+		cfg.Auth.JWTSecret = randomSecret()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, false, err
+	}
+	return &cfg, *printConfig, nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, "PORT must be between 1 and 65535")
+	}
+	if c.Features.Metrics {
+		if c.Server.MetricsPort < 1 || c.Server.MetricsPort > 65535 {
+			problems = append(problems, "METRICS_PORT must be between 1 and 65535")
+		} else if c.Server.MetricsPort == c.Server.Port {
+			problems = append(problems, "METRICS_PORT must differ from PORT so metrics stay off the public listener")
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT must be positive")
+	}
+	if c.Server.DrainDelay < 0 {
+		problems = append(problems, "DRAIN_DELAY must not be negative")
+	}
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, "PUBLIC_URL must be an absolute http or https URL")
+		}
+	}
+	switch c.Storage.Backend {
+	case "mongo":
+		if c.Storage.MongoURI == "" {
+			problems = append(problems, "MONGO_URI is required for the mongo backend")
+		}
+		if c.Storage.MongoDatabase == "" {
+			problems = append(problems, "MONGO_DB is required for the mongo backend")
+		}
+	case "postgres", "sqlite":
+		if c.Storage.SQLDSN == "" {
+			problems = append(problems, "SQL_DSN is required for the "+c.Storage.Backend+" backend")
+		}
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("STORAGE %q is not one of mongo, postgres, sqlite or memory", c.Storage.Backend))
+	}
+	if c.Storage.ReadTimeout < 0 || c.Storage.WriteTimeout < 0 {
+		problems = append(problems, "storage timeouts must not be negative")
+	}
+	if len(c.Auth.JWTSecret) < 32 {
+		problems = append(problems, "JWT_SECRET must be at least 32 characters")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		problems = append(problems, "JWT_TTL must be positive")
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		problems = append(problems, fmt.Sprintf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if c.Encryption.Enabled() {
+		if keys, err := parseMasterKeys(c.Encryption.MasterKeys); err != nil {
+			problems = append(problems, "ENCRYPTION_MASTER_KEYS: "+err.Error())
+		} else if _, ok := keys[c.Encryption.CurrentKeyID]; !ok {
+			problems = append(problems, "ENCRYPTION_MASTER_KEY_ID must name one of ENCRYPTION_MASTER_KEYS")
+		}
+	}
+	if c.Retention.DefaultDays < 0 || c.Retention.DefaultDays > domain.MaxRetentionDays {
+		problems = append(problems, fmt.Sprintf("RETENTION_DEFAULT_DAYS must be between 0 and %d", domain.MaxRetentionDays))
+	}
+	if c.Retention.Warning < 0 {
+		problems = append(problems, "RETENTION_WARNING must not be negative")
+	}
+	if c.Retention.SweepInterval <= 0 {
+		problems = append(problems, "RETENTION_SWEEP_INTERVAL must be positive")
+	}
+	if !strings.Contains(c.Calendar.CardURL, "{id}") {
+		problems = append(problems, "CALENDAR_CARD_URL must contain {id}")
+	}
+	if c.Sharing.DefaultTTL <= 0 || c.Sharing.MaxTTL <= 0 {
+		problems = append(problems, "SHARE_DEFAULT_TTL and SHARE_MAX_TTL must be positive")
+	} else if c.Sharing.DefaultTTL > c.Sharing.MaxTTL {
+		problems = append(problems, "SHARE_DEFAULT_TTL must not exceed SHARE_MAX_TTL")
+	}
+	if c.Sync.SafetyLag <= c.Storage.WriteTimeout {
+		problems = append(problems, "SYNC_SAFETY_LAG must exceed STORAGE_WRITE_TIMEOUT")
+	}
+	if c.Sync.TombstoneRetention < 0 {
+		problems = append(problems, "SYNC_TOMBSTONE_RETENTION must not be negative")
+	} else if c.Sync.TombstoneRetention > 0 && c.Sync.TombstoneRetention <= c.Sync.SafetyLag {
+		problems = append(problems, "SYNC_TOMBSTONE_RETENTION must exceed SYNC_SAFETY_LAG")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	case "file":
+		if c.Tracing.File == "" {
+			problems = append(problems, "TRACING_FILE is required for the file exporter")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("TRACING_EXPORTER %q is not one of none, stdout, file or otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL %q is not one of debug, info, warn or error", c.Logging.Level))
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT %q is not one of json or text", c.Logging.Format))
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		problems = append(problems, "CORS_ORIGINS must list at least one origin")
+	}
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Print writes the effective configuration with secrets redacted
+func (c *Config) Print(w io.Writer) {
+	for _, s := range settings {
+		value := s.get(c)
+		if s.secret {
+			value = redact(value)
+		}
+		fmt.Fprintf(w, "%s=%s\n", s.env, value)
+	}
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)\S+`)
+
+// redact hides a secret while keeping enough shape to tell whether it is set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+	if dsnPassword.MatchString(value) {
+		return dsnPassword.ReplaceAllString(value, "${1}xxxxx")
+	}
+	return "[REDACTED]"
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseInt(value string, out *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*out = n
+	return nil
+}
+
+func parseBool(value string, out *bool) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*out = b
+	return nil
+}
+
+func parseFloat(value string, out *float64) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	*out = f
+	return nil
+}
+
+func parseDuration(value string, out *time.Duration) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*out = d
+	return nil
+}
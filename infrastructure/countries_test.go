@@ -0,0 +1,23 @@
+package Infrastructure
+
+import "testing"
+
+func TestCountryLabel(t *testing.T) {
+	tests := []struct {
+		country string
+		want    string
+	}{
+		{"FR", "FR"},
+		{"fr", "FR"},
+		{" us ", "US"},
+		{"France", "other"},
+		{"ZZ", "other"},
+		{"", "other"},
+		{`"},evil{x="`, "other"},
+	}
+	for _, tt := range tests {
+		if got := countryLabel(tt.country); got != tt.want {
+			t.Errorf("countryLabel(%q) = %q, want %q", tt.country, got, tt.want)
+		}
+	}
+}
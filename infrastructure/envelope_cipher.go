@@ -0,0 +1,229 @@
+package Infrastructure
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// ciphertextPrefix marks values written by EnvelopeCipher; anything else is treated as legacy plaintext
+const ciphertextPrefix = "enc:v1:"
+
+// dataKeyCacheTTL bounds how long unwrapped data keys stay in memory, so rotations made elsewhere are picked up
+const dataKeyCacheTTL = 5 * time.Minute
+
+// EnvelopeCipher encrypts fields with AES-256-GCM under per-user data keys that are wrapped by a master key.
+// Ciphertexts look like "enc:v1:<data key version>:<base64 nonce+sealed>" and are bound to the owning user.
+type EnvelopeCipher struct {
+	kms  KeyWrapper
+	keys domain.DataKeyRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedUserKeys
+}
+
+type cachedUserKeys struct {
+	current  int
+	versions map[int][]byte
+	loaded   time.Time
+}
+
+func NewEnvelopeCipher(kms KeyWrapper, keys domain.DataKeyRepository) *EnvelopeCipher {
+	return &EnvelopeCipher{
+		kms:   kms,
+		keys:  keys,
+		cache: map[string]cachedUserKeys{},
+	}
+}
+
+// Encrypt seals plaintext under the user's current data key, creating the first key on demand
+func (e *EnvelopeCipher) Encrypt(ctx context.Context, userID, plaintext string) (string, error) {
+	keys, err := e.userKeys(ctx, userID, true)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(keys.versions[keys.current])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(userID))
+	return ciphertextPrefix + strconv.Itoa(keys.current) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt; values without the ciphertext prefix are returned unchanged
+func (e *EnvelopeCipher) Decrypt(ctx context.Context, userID, ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, ciphertextPrefix) {
+		return ciphertext, nil
+	}
+	versionText, encoded, ok := strings.Cut(strings.TrimPrefix(ciphertext, ciphertextPrefix), ":")
+	if !ok {
+		return "", errors.New("malformed ciphertext")
+	}
+	version, err := strconv.Atoi(versionText)
+	if err != nil {
+		return "", errors.New("malformed ciphertext version")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("malformed ciphertext encoding")
+	}
+
+	keys, err := e.userKeys(ctx, userID, false)
+	if err != nil {
+		return "", err
+	}
+	key, ok := keys.versions[version]
+	if !ok {
+		// The version may have been created by another instance since the cache was filled
+		e.forget(userID)
+		if keys, err = e.userKeys(ctx, userID, false); err != nil {
+			return "", err
+		}
+		if key, ok = keys.versions[version]; !ok {
+			return "", fmt.Errorf("data key version %d for user %s: %w", version, userID, domain.ErrDataKeyNotFound)
+		}
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("ciphertext is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(userID))
+	if err != nil {
+		return "", fmt.Errorf("error decrypting field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// RotateDataKeys adds a new data key version for every user that has one; later writes use the new version
+func (e *EnvelopeCipher) RotateDataKeys(ctx context.Context) (int, error) {
+	all, err := e.keys.ListDataKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	latest := map[string]int{}
+	for _, key := range all {
+		if key.Version > latest[key.UserID] {
+			latest[key.UserID] = key.Version
+		}
+	}
+
+	rotated := 0
+	for userID, version := range latest {
+		if err := e.createDataKey(ctx, userID, version+1); err != nil && !errors.Is(err, domain.ErrDataKeyExists) {
+			return rotated, err
+		}
+		e.forget(userID)
+		rotated++
+	}
+	return rotated, nil
+}
+
+// RewrapDataKeys re-wraps every data key held under a retired master key with the current one
+func (e *EnvelopeCipher) RewrapDataKeys(ctx context.Context) (int, error) {
+	all, err := e.keys.ListDataKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, key := range all {
+		if key.MasterKeyID == e.kms.CurrentKeyID() {
+			continue
+		}
+		plain, err := e.kms.Unwrap(ctx, key.MasterKeyID, key.WrappedKey)
+		if err != nil {
+			return rewrapped, fmt.Errorf("error unwrapping data key %s/%d: %v", key.UserID, key.Version, err)
+		}
+		if key.MasterKeyID, key.WrappedKey, err = e.kms.Wrap(ctx, plain); err != nil {
+			return rewrapped, err
+		}
+		if err := e.keys.UpdateDataKey(ctx, &key); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+	return rewrapped, nil
+}
+
+// userKeys returns the user's unwrapped data keys, creating version 1 when create is set and none exist
+func (e *EnvelopeCipher) userKeys(ctx context.Context, userID string, create bool) (cachedUserKeys, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[userID]
+	e.mu.Unlock()
+	if ok && time.Since(cached.loaded) < dataKeyCacheTTL {
+		return cached, nil
+	}
+
+	stored, err := e.keys.GetDataKeys(ctx, userID)
+	if err != nil {
+		return cachedUserKeys{}, err
+	}
+	if len(stored) == 0 {
+		if !create {
+			return cachedUserKeys{}, fmt.Errorf("no data key for user %s: %w", userID, domain.ErrDataKeyNotFound)
+		}
+		if err := e.createDataKey(ctx, userID, 1); err != nil && !errors.Is(err, domain.ErrDataKeyExists) {
+			return cachedUserKeys{}, err
+		}
+		if stored, err = e.keys.GetDataKeys(ctx, userID); err != nil {
+			return cachedUserKeys{}, err
+		}
+	}
+
+	keys := cachedUserKeys{versions: map[int][]byte{}, loaded: time.Now()}
+	for _, key := range stored {
+		plain, err := e.kms.Unwrap(ctx, key.MasterKeyID, key.WrappedKey)
+		if err != nil {
+			return cachedUserKeys{}, fmt.Errorf("error unwrapping data key %s/%d: %v", userID, key.Version, err)
+		}
+		keys.versions[key.Version] = plain
+		if key.Version > keys.current {
+			keys.current = key.Version
+		}
+	}
+
+	e.mu.Lock()
+	e.cache[userID] = keys
+	e.mu.Unlock()
+	return keys, nil
+}
+
+func (e *EnvelopeCipher) createDataKey(ctx context.Context, userID string, version int) error {
+	plain := make([]byte, 32)
+	if _, err := rand.Read(plain); err != nil {
+		return err
+	}
+	keyID, wrapped, err := e.kms.Wrap(ctx, plain)
+	if err != nil {
+		return err
+	}
+	return e.keys.CreateDataKey(ctx, &domain.DataKey{
+		UserID:      userID,
+		Version:     version,
+		MasterKeyID: keyID,
+		WrappedKey:  wrapped,
+		CreatedAt:   time.Now().UTC(),
+	})
+}
+
+func (e *EnvelopeCipher) forget(userID string) {
+	e.mu.Lock()
+	delete(e.cache, userID)
+	e.mu.Unlock()
+}
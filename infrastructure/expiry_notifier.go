@@ -0,0 +1,32 @@
+package Infrastructure
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// LogExpiryNotifier records expiry warnings for operators. Users see the warning in the app: the sweep marks
+// the flight or trip as warned, which fills its expiry_warning; warned flights are also listed under
+// GET /flights/expiring
+type LogExpiryNotifier struct{}
+
+func (LogExpiryNotifier) NotifyFlightExpiring(ctx context.Context, flight domain.Flight, expiresAt time.Time) error {
+	slog.Default().InfoContext(ctx, "flight expiring",
+		"layer", "notifications",
+		"user_id", flight.UserID,
+		"flight_id", flight.ID,
+		"expires_on", expiresAt.Format(time.RFC3339))
+	return nil
+}
+
+func (LogExpiryNotifier) NotifyTripExpiring(ctx context.Context, trip domain.Trip, expiresAt time.Time) error {
+	slog.Default().InfoContext(ctx, "trip expiring",
+		"layer", "notifications",
+		"user_id", trip.UserID,
+		"trip_id", trip.ID,
+		"expires_on", expiresAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,197 @@
+package Infrastructure
+
+import (
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// icsProperty is one content line of an iCalendar component
+type icsProperty struct {
+	params map[string]string
+	value  string
+}
+
+// fromCalendar returns the flights among a calendar's events. Airlines and travel apps put the flight
+// number and airports in the summary or location, so those are read with the text templates' patterns
+func (r *ItineraryReader) fromCalendar(calendar string) []domain.ItinerarySegment {
+	var segments []domain.ItinerarySegment
+	var event map[string]icsProperty
+	depth := 0 // nesting inside the current VEVENT, such as a VALARM
+	for _, line := range unfoldICS(calendar) {
+		name, prop, ok := parseICSLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event, depth = map[string]icsProperty{}, 0
+		case event == nil:
+		case name == "BEGIN":
+			depth++
+		case name == "END" && depth > 0:
+			depth--
+		case name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if segment, ok := r.calendarEvent(event); ok {
+				segments = append(segments, segment)
+			}
+			event = nil
+		case depth == 0:
+			if _, seen := event[name]; !seen {
+				event[name] = prop
+			}
+		}
+	}
+	return segments
+}
+
+// calendarEvent turns an event into a segment when it names a flight
+func (r *ItineraryReader) calendarEvent(event map[string]icsProperty) (domain.ItinerarySegment, bool) {
+	summary := unescapeICS(event["SUMMARY"].value)
+	location := unescapeICS(event["LOCATION"].value)
+	description := unescapeICS(event["DESCRIPTION"].value)
+	segment := domain.ItinerarySegment{Source: "ics"}
+
+	for _, text := range []string{summary, location + "\n" + description} {
+		for _, m := range textFlightNumber.FindAllStringSubmatch(text, -1) {
+			if m[2] == "" || !textCommonWords[m[1]] {
+				segment.FlightNumber = m[1] + m[3]
+				break
+			}
+		}
+		if segment.FlightNumber != "" {
+			break
+		}
+	}
+	airports := r.textAirports(summary + "\n" + location)
+	if len(airports) < 2 {
+		airports = r.textAirports(summary + "\n" + location + "\n" + description)
+	}
+	if len(airports) >= 2 {
+		segment.OriginAirport, segment.DestinationAirport = airports[0], airports[1]
+	}
+	mentionsFlight := strings.Contains(strings.ToLower(summary), "flight")
+	if segment.FlightNumber == "" || (len(airports) < 2 && !mentionsFlight) {
+		return segment, false
+	}
+
+	start, ok := event["DTSTART"]
+	if !ok {
+		return segment, false
+	}
+	segment.DepartureTime, segment.DepartureTimezone, segment.Date = r.icsTime(start, segment.OriginAirport)
+	if end, ok := event["DTEND"]; ok && segment.DepartureTime != nil {
+		segment.ArrivalTime, segment.ArrivalTimezone, _ = r.icsTime(end, segment.DestinationAirport)
+	}
+	return segment, !segment.Date.IsZero()
+}
+
+// icsTime reads a DTSTART or DTEND: a UTC or TZID time is an instant, a floating time is local to the
+// airport, and a DATE value gives only the day
+func (r *ItineraryReader) icsTime(prop icsProperty, airport string) (*time.Time, string, time.Time) {
+	value := strings.TrimSpace(prop.value)
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len("20060102") {
+		day, err := time.Parse("20060102", value)
+		if err != nil {
+			return nil, "", time.Time{}
+		}
+		return nil, "", day
+	}
+
+	var t time.Time
+	var err error
+	tz := strings.Trim(prop.params["TZID"], `"`)
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	case tz != "":
+		loc, locErr := time.LoadLocation(tz)
+		if locErr != nil {
+			// Outlook writes Windows zone names; treat those times as local to the airport
+			wall, err := time.Parse("20060102T150405", value)
+			if err != nil {
+				return nil, "", time.Time{}
+			}
+			local, zone := r.localIn(wall, airport)
+			return local, zone, segmentDate(wall)
+		}
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	default:
+		wall, err := time.Parse("20060102T150405", value)
+		if err != nil {
+			return nil, "", time.Time{}
+		}
+		local, zone := r.localIn(wall, airport)
+		return local, zone, segmentDate(wall)
+	}
+	if err != nil {
+		return nil, "", time.Time{}
+	}
+
+	if loc, zone := r.airportZone(airport); loc != nil && (tz == "" || tz == "UTC") {
+		t, tz = t.In(loc), zone
+	}
+	if tz == "UTC" {
+		tz = ""
+	}
+	return &t, tz, segmentDate(t)
+}
+
+// unfoldICS joins folded continuation lines and splits the calendar into content lines
+func unfoldICS(calendar string) []string {
+	calendar = strings.ReplaceAll(calendar, "\r\n", "\n")
+	calendar = strings.ReplaceAll(calendar, "\n ", "")
+	calendar = strings.ReplaceAll(calendar, "\n\t", "")
+	return strings.Split(calendar, "\n")
+}
+
+// parseICSLine splits "NAME;PARAM=value:VALUE", allowing quoted parameter values to contain ':' and ';'
+func parseICSLine(line string) (string, icsProperty, bool) {
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return "", icsProperty{}, false
+	}
+
+	prop := icsProperty{params: map[string]string{}, value: line[colon+1:]}
+	fields := splitUnquoted(line[:colon], ';')
+	for _, param := range fields[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = value
+	}
+	return strings.ToUpper(fields[0]), prop, true
+}
+
+func splitUnquoted(s string, sep byte) []string {
+	var fields []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, s[start:])
+}
+
+var icsUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeICS(value string) string {
+	return icsUnescaper.Replace(value)
+}
@@ -0,0 +1,252 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// maxMIMEDepth bounds how deeply nested multiparts and forwarded messages are followed
+const maxMIMEDepth = 8
+
+// ItineraryReader finds flights in airline confirmation emails and calendar files. Structured data wins:
+// schema.org FlightReservation markup (JSON-LD or microdata) and calendar events are used when present,
+// and the plain-text airline templates are only tried when an email carries neither
+type ItineraryReader struct {
+	airports domain.AirportDirectory
+	now      func() time.Time
+}
+
+// NewItineraryReader returns a reader that resolves airports, and local times given without an offset,
+// against the airport dataset
+func NewItineraryReader(airports domain.AirportDirectory) *ItineraryReader {
+	return &ItineraryReader{airports: airports, now: time.Now}
+}
+
+// itineraryParts collects the readable bodies of an email by kind
+type itineraryParts struct {
+	html      []string
+	text      []string
+	calendars []string
+}
+
+// ReadItinerary accepts a raw RFC 5322 email, as saved by "Download message" or "Save as .eml", or an
+// iCalendar file
+func (r *ItineraryReader) ReadItinerary(ctx context.Context, data []byte) ([]domain.ItinerarySegment, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	var parts itineraryParts
+	if isCalendar(data) {
+		parts.calendars = append(parts.calendars, string(data))
+	} else {
+		msg, err := mail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			return nil, domain.NewValidationError("File must be an email (.eml) or a calendar (.ics)",
+				map[string]string{"file": "itinerary_format"})
+		}
+		if err := collectMIMEParts(&parts, textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+			return nil, domain.NewValidationError("Email could not be read: "+err.Error(),
+				map[string]string{"file": "itinerary_format"})
+		}
+	}
+
+	var segments []domain.ItinerarySegment
+	for _, page := range parts.html {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		segments = append(segments, r.fromHTML(page)...)
+	}
+	for _, calendar := range parts.calendars {
+		segments = append(segments, r.fromCalendar(calendar)...)
+	}
+	if len(segments) == 0 {
+		for _, page := range parts.html {
+			segments = append(segments, r.fromText(htmlText(page))...)
+		}
+		for _, text := range parts.text {
+			segments = append(segments, r.fromText(text)...)
+		}
+	}
+
+	segments = mergeSegments(segments)
+	if len(segments) == 0 {
+		return nil, domain.ErrNoItineraryFlights
+	}
+	return segments, nil
+}
+
+// isCalendar reports whether data is an iCalendar object rather than an email
+func isCalendar(data []byte) bool {
+	head := bytes.ToUpper(bytes.TrimSpace(data[:min(len(data), 64)]))
+	return bytes.HasPrefix(head, []byte("BEGIN:VCALENDAR"))
+}
+
+// collectMIMEParts walks a message body, following multiparts and forwarded messages, and keeps the
+// HTML, plain-text and calendar parts decoded to UTF-8
+func collectMIMEParts(parts *itineraryParts, header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxMIMEDepth {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	body = decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := collectMIMEParts(parts, part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	case mediaType == "message/rfc822":
+		msg, err := mail.ReadMessage(body)
+		if err != nil {
+			return nil // a malformed forwarded message is skipped, not fatal
+		}
+		return collectMIMEParts(parts, textproto.MIMEHeader(msg.Header), msg.Body, depth+1)
+	}
+
+	isICS := mediaType == "text/calendar" || mediaType == "application/ics" || strings.HasSuffix(attachmentName(header), ".ics")
+	if mediaType != "text/html" && mediaType != "text/plain" && !isICS {
+		return nil
+	}
+	if decoded, err := charset.NewReaderLabel(params["charset"], body); err == nil && params["charset"] != "" {
+		body = decoded
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case isICS:
+		parts.calendars = append(parts.calendars, string(content))
+	case mediaType == "text/html":
+		parts.html = append(parts.html, string(content))
+	default:
+		parts.text = append(parts.text, string(content))
+	}
+	return nil
+}
+
+// decodeTransferEncoding undoes base64 or quoted-printable; other encodings are passed through
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// newlineStripper drops line breaks so base64 bodies wrapped at 76 columns decode
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			kept = append(kept, b)
+		}
+	}
+	return len(kept), err
+}
+
+// attachmentName returns the lower-cased file name of a part, from Content-Disposition or Content-Type
+func attachmentName(header textproto.MIMEHeader) string {
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return strings.ToLower(params["filename"])
+	}
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil {
+		return strings.ToLower(params["name"])
+	}
+	return ""
+}
+
+// mergeSegments collapses the same flight found twice, such as in both the HTML and an attached .ics,
+// keeping the first and filling its gaps from the others
+func mergeSegments(segments []domain.ItinerarySegment) []domain.ItinerarySegment {
+	var merged []domain.ItinerarySegment
+	index := map[string]int{}
+	for _, segment := range segments {
+		key := segment.FlightNumber + "|" + segment.Date.Format(time.DateOnly)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, segment)
+			continue
+		}
+		kept := &merged[i]
+		fill(&kept.ReservationNumber, segment.ReservationNumber)
+		fill(&kept.PassengerName, segment.PassengerName)
+		fill(&kept.OriginAirport, segment.OriginAirport)
+		fill(&kept.DestinationAirport, segment.DestinationAirport)
+		if kept.DepartureTime == nil {
+			kept.DepartureTime, kept.DepartureTimezone = segment.DepartureTime, segment.DepartureTimezone
+		}
+		if kept.ArrivalTime == nil {
+			kept.ArrivalTime, kept.ArrivalTimezone = segment.ArrivalTime, segment.ArrivalTimezone
+		}
+	}
+	return merged
+}
+
+func fill(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// localIn reads the wall clock of t as a time at the airport, for itineraries that give local times
+// without an offset; it returns nil when the airport is unknown
+func (r *ItineraryReader) localIn(t time.Time, airportCode string) (*time.Time, string) {
+	loc, tz := r.airportZone(airportCode)
+	if loc == nil {
+		return nil, ""
+	}
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+	return &local, tz
+}
+
+// airportZone returns the location and IANA name of an airport's timezone, or nil when it is unknown
+func (r *ItineraryReader) airportZone(code string) (*time.Location, string) {
+	airport, ok := r.airports.LookupAirport(code)
+	if !ok {
+		return nil, ""
+	}
+	loc, err := time.LoadLocation(airport.Timezone)
+	if err != nil {
+		return nil, ""
+	}
+	return loc, airport.Timezone
+}
+
+// segmentDate is the departure day in the departure's own zone, so an evening flight keeps its date
+func segmentDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
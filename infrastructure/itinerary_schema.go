@@ -0,0 +1,330 @@
+package Infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// schema.org markup, as airlines embed it in confirmation emails for mail clients to show trip cards.
+// JSON-LD and microdata are both turned into the same map form: "@type" plus the item's properties.
+
+// fromHTML returns the flights described by FlightReservation items in the page
+func (r *ItineraryReader) fromHTML(page string) []domain.ItinerarySegment {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return nil
+	}
+
+	var segments []domain.ItinerarySegment
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.DataAtom == atom.Script && strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") {
+				var value any
+				if json.Unmarshal([]byte(nodeText(n)), &value) == nil {
+					segments = append(segments, r.fromSchema(value, "json-ld")...)
+				}
+				return
+			}
+			if hasAttr(n, "itemscope") && attr(n, "itemprop") == "" {
+				segments = append(segments, r.fromSchema(microdataItem(n), "microdata")...)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return segments
+}
+
+// fromSchema searches a schema.org value, including @graph lists and wrappers such as EmailMessage,
+// for FlightReservation items
+func (r *ItineraryReader) fromSchema(value any, source string) []domain.ItinerarySegment {
+	switch v := value.(type) {
+	case []any:
+		var segments []domain.ItinerarySegment
+		for _, item := range v {
+			segments = append(segments, r.fromSchema(item, source)...)
+		}
+		return segments
+	case map[string]any:
+		if !hasSchemaType(v, "FlightReservation") {
+			var segments []domain.ItinerarySegment
+			for _, item := range v {
+				segments = append(segments, r.fromSchema(item, source)...)
+			}
+			return segments
+		}
+		var segments []domain.ItinerarySegment
+		for _, flight := range schemaList(v["reservationFor"]) {
+			if segment, ok := r.flightReservation(v, flight, source); ok {
+				segments = append(segments, segment)
+			}
+		}
+		return segments
+	}
+	return nil
+}
+
+// flightReservation maps a FlightReservation and one of its Flights onto a segment
+func (r *ItineraryReader) flightReservation(reservation map[string]any, flight map[string]any, source string) (domain.ItinerarySegment, bool) {
+	segment := domain.ItinerarySegment{
+		Source:             source,
+		ReservationNumber:  schemaString(reservation["reservationNumber"]),
+		OriginAirport:      schemaAirport(flight["departureAirport"]),
+		DestinationAirport: schemaAirport(flight["arrivalAirport"]),
+	}
+	if name, ok := reservation["underName"].(map[string]any); ok {
+		segment.PassengerName = schemaString(name["name"])
+	} else {
+		segment.PassengerName = schemaString(reservation["underName"])
+	}
+
+	// flightNumber is often just the digits, with the carrier given separately
+	number := strings.ToUpper(strings.ReplaceAll(schemaString(flight["flightNumber"]), " ", ""))
+	if number != "" && number[0] >= '0' && number[0] <= '9' {
+		if airline, ok := flight["airline"].(map[string]any); ok {
+			number = strings.ToUpper(schemaString(airline["iataCode"])) + number
+		}
+	}
+	if number == "" {
+		return segment, false
+	}
+	segment.FlightNumber = number
+
+	segment.DepartureTime, segment.DepartureTimezone = r.schemaTime(schemaString(flight["departureTime"]), segment.OriginAirport)
+	segment.ArrivalTime, segment.ArrivalTimezone = r.schemaTime(schemaString(flight["arrivalTime"]), segment.DestinationAirport)
+	switch {
+	case segment.DepartureTime != nil:
+		segment.Date = segmentDate(*segment.DepartureTime)
+	default:
+		// A departure without a usable time still pins the date
+		if t, err := time.Parse(time.DateOnly, first(schemaString(flight["departureTime"]), 10)); err == nil {
+			segment.Date = t
+		}
+	}
+	return segment, true
+}
+
+// schemaTime parses an ISO 8601 date-time; one without an offset is local time at the airport
+func (r *ItineraryReader) schemaTime(value, airport string) (*time.Time, string) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if loc, tz := r.airportZone(airport); loc != nil {
+				t = t.In(loc)
+				return &t, tz
+			}
+			return &t, ""
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return r.localIn(t, airport)
+		}
+	}
+	return nil, ""
+}
+
+// schemaAirport returns the IATA code of an Airport item, or of a bare string that is one
+func schemaAirport(value any) string {
+	if airport, ok := value.(map[string]any); ok {
+		value = airport["iataCode"]
+	}
+	code := strings.ToUpper(strings.TrimSpace(schemaString(value)))
+	if len(code) != 3 {
+		return ""
+	}
+	return code
+}
+
+// hasSchemaType matches "@type" against a schema.org type, with or without a vocabulary prefix
+func hasSchemaType(item map[string]any, want string) bool {
+	for _, t := range schemaList(item["@type"]) {
+		if name, _ := t["@value"].(string); name == want {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaList normalises a property that may be one value or a list into items; bare strings are
+// wrapped as {"@value": ...} with any vocabulary prefix removed
+func schemaList(value any) []map[string]any {
+	var values []any
+	if list, ok := value.([]any); ok {
+		values = list
+	} else if value != nil {
+		values = []any{value}
+	}
+
+	items := make([]map[string]any, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case map[string]any:
+			items = append(items, v)
+		case string:
+			items = append(items, map[string]any{"@value": v[strings.LastIndexAny(v, "/:")+1:]})
+		}
+	}
+	return items
+}
+
+// schemaString renders a scalar property, taking the first of a list
+func schemaString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case []any:
+		if len(v) > 0 {
+			return schemaString(v[0])
+		}
+	case map[string]any:
+		return schemaString(v["@value"])
+	}
+	return ""
+}
+
+// microdataItem converts an itemscope element into the JSON-LD map form
+func microdataItem(n *html.Node) map[string]any {
+	item := map[string]any{}
+	if itemType := strings.Fields(attr(n, "itemtype")); len(itemType) > 0 {
+		item["@type"] = itemType[0]
+	}
+	var walk func(c *html.Node)
+	walk = func(c *html.Node) {
+		for ; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			props := strings.Fields(attr(c, "itemprop"))
+			if len(props) == 0 {
+				if !hasAttr(c, "itemscope") {
+					walk(c.FirstChild)
+				}
+				continue
+			}
+			var value any
+			if hasAttr(c, "itemscope") {
+				value = microdataItem(c)
+			} else {
+				value = microdataValue(c)
+				walk(c.FirstChild)
+			}
+			for _, prop := range props {
+				if _, seen := item[prop]; !seen {
+					item[prop] = value
+				}
+			}
+		}
+	}
+	walk(n.FirstChild)
+	return item
+}
+
+// microdataValue reads a property value the way the microdata spec assigns it per element
+func microdataValue(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.Meta:
+		return attr(n, "content")
+	case atom.A, atom.Link, atom.Area:
+		return attr(n, "href")
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Iframe, atom.Embed:
+		return attr(n, "src")
+	case atom.Time:
+		if hasAttr(n, "datetime") {
+			return attr(n, "datetime")
+		}
+	case atom.Data, atom.Meter:
+		return attr(n, "value")
+	}
+	if hasAttr(n, "content") {
+		return attr(n, "content")
+	}
+	return strings.Join(strings.Fields(nodeText(n)), " ")
+}
+
+// htmlText flattens a page to text, breaking lines at block elements and table cells
+func htmlText(page string) string {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return page
+	}
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Head:
+				return
+			case atom.Td, atom.Th:
+				b.WriteString(" ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Br, atom.P, atom.Div, atom.Tr, atom.Li, atom.Table, atom.H1, atom.H2, atom.H3, atom.H4:
+				b.WriteString("\n")
+			}
+		}
+	}
+	walk(doc)
+	return b.String()
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, name string) bool {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+func first(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
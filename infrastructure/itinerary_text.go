@@ -0,0 +1,236 @@
+package Infrastructure
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// Plain-text airline templates. Confirmation emails without markup still follow a few layouts: a flight
+// number, then the two airports, the departure date and the departure and arrival times, for example
+//
+//	ET 308  Addis Ababa (ADD) - Nairobi (NBO)  Sat 12 Mar 2027  08:30 - 10:45
+//	LH400 FRA-JFK 14.03.2027 10:05 13:05
+//
+// A flight is only taken when both airports are in the airport dataset, which keeps stray codes out.
+
+var (
+	textFlightNumber = regexp.MustCompile(`\b([A-Z][A-Z0-9]|[0-9][A-Z])( ?)([0-9]{1,4}[A-Z]?)\b`)
+	textAirportParen = regexp.MustCompile(`\(([A-Z]{3})\)`)
+	textAirportBare  = regexp.MustCompile(`\b[A-Z]{3}\b`)
+	textTime         = regexp.MustCompile(`(?i)\b([01]?[0-9]|2[0-3]):([0-5][0-9])(?:\s*([ap])\.?m\b\.?)?`)
+
+	textDateISO      = regexp.MustCompile(`\b(20[0-9]{2})-([01][0-9])-([0-3][0-9])\b`)
+	textDateDotted   = regexp.MustCompile(`\b([0-3]?[0-9])\.([01]?[0-9])\.(20[0-9]{2})\b`)
+	textDateDayMonth = regexp.MustCompile(`(?i)\b([0-3]?[0-9])[ \-]?(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?(?:[ \-,]+(20[0-9]{2})\b|([0-9]{2})\b)?`)
+	textDateMonthDay = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.? ([0-3]?[0-9])(?:st|nd|rd|th)?,? (20[0-9]{2})\b`)
+)
+
+// textCommonWords are two-letter words that look like a carrier code when an all-caps template puts a
+// number after them, as in "DEPART AT 0830"
+var textCommonWords = map[string]bool{
+	"AM": true, "AN": true, "AS": true, "AT": true, "BY": true, "IN": true, "IS": true, "IT": true,
+	"NO": true, "OF": true, "ON": true, "OR": true, "PM": true, "TO": true, "UP": true, "US": true,
+}
+
+var textMonths = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// textWindowLength caps how far after a flight number its airports, dates and times are looked for
+const textWindowLength = 500
+
+// fromText finds flights laid out in the usual airline templates
+func (r *ItineraryReader) fromText(text string) []domain.ItinerarySegment {
+	type candidate struct {
+		number     string
+		start, end int
+	}
+	var candidates []candidate
+	for _, m := range textFlightNumber.FindAllStringSubmatchIndex(text, -1) {
+		carrier, space := text[m[2]:m[3]], m[5] > m[4]
+		if m[1] < len(text) && text[m[1]] == ':' {
+			continue // a time such as "A1 12:30" in a reference, not a flight
+		}
+		if space && textCommonWords[carrier] {
+			continue
+		}
+		number := carrier + text[m[6]:m[7]]
+		if len(candidates) > 0 && candidates[len(candidates)-1].number == number {
+			continue // the same flight repeated, as in a heading and then its details
+		}
+		candidates = append(candidates, candidate{number: number, start: m[0], end: m[1]})
+	}
+
+	var segments []domain.ItinerarySegment
+	for i, c := range candidates {
+		from := strings.LastIndexByte(text[:c.start], '\n') + 1
+		if i > 0 && from < candidates[i-1].end {
+			from = candidates[i-1].end
+		}
+		to := min(len(text), c.end+textWindowLength)
+		if i+1 < len(candidates) && candidates[i+1].start < to {
+			to = candidates[i+1].start
+		}
+		if segment, ok := r.textSegment(c.number, text[from:to]); ok {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// textSegment reads the airports, date and times of one flight from the text around its number
+func (r *ItineraryReader) textSegment(number, window string) (domain.ItinerarySegment, bool) {
+	segment := domain.ItinerarySegment{Source: "text", FlightNumber: number}
+	airports := r.textAirports(window)
+	if len(airports) < 2 {
+		return segment, false
+	}
+	segment.OriginAirport, segment.DestinationAirport = airports[0], airports[1]
+
+	dates := r.textDates(window)
+	if len(dates) == 0 {
+		return segment, true
+	}
+	segment.Date = dates[0]
+
+	times := textTimes(window)
+	if len(times) == 0 {
+		return segment, true
+	}
+	departure := segment.Date.Add(times[0])
+	segment.DepartureTime, segment.DepartureTimezone = r.localIn(departure, segment.OriginAirport)
+	if len(times) > 1 {
+		arrivalDate := segment.Date
+		if len(dates) > 1 && !dates[1].Before(segment.Date) {
+			arrivalDate = dates[1]
+		}
+		arrival := arrivalDate.Add(times[1])
+		if !arrival.After(departure) {
+			arrival = arrival.AddDate(0, 0, 1) // an overnight flight that only gives the arrival time
+		}
+		segment.ArrivalTime, segment.ArrivalTimezone = r.localIn(arrival, segment.DestinationAirport)
+	}
+	return segment, true
+}
+
+// textAirports returns the distinct known airport codes in order, preferring codes in parentheses
+func (r *ItineraryReader) textAirports(text string) []string {
+	var codes []string
+	add := func(code string) {
+		if _, ok := r.airports.LookupAirport(code); !ok {
+			return
+		}
+		for _, seen := range codes {
+			if seen == code {
+				return
+			}
+		}
+		codes = append(codes, code)
+	}
+	for _, m := range textAirportParen.FindAllStringSubmatch(text, -1) {
+		add(m[1])
+	}
+	if len(codes) < 2 {
+		codes = codes[:0]
+		for _, code := range textAirportBare.FindAllString(text, -1) {
+			add(code)
+		}
+	}
+	return codes
+}
+
+// textDates returns the dates in the text in the order they appear, as UTC midnights
+func (r *ItineraryReader) textDates(text string) []time.Time {
+	type found struct {
+		at   int
+		date time.Time
+	}
+	var dates []found
+	add := func(at, year int, month time.Month, day int) {
+		if year == 0 {
+			year = nearestYear(month, day, r.now())
+		}
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if date.Month() == month && date.Day() == day {
+			dates = append(dates, found{at: at, date: date})
+		}
+	}
+
+	for _, m := range textDateISO.FindAllStringSubmatchIndex(text, -1) {
+		add(m[0], atoi(text[m[2]:m[3]]), time.Month(atoi(text[m[4]:m[5]])), atoi(text[m[6]:m[7]]))
+	}
+	for _, m := range textDateDotted.FindAllStringSubmatchIndex(text, -1) {
+		add(m[0], atoi(text[m[6]:m[7]]), time.Month(atoi(text[m[4]:m[5]])), atoi(text[m[2]:m[3]]))
+	}
+	for _, m := range textDateDayMonth.FindAllStringSubmatchIndex(text, -1) {
+		year := 0
+		if m[6] >= 0 {
+			year = atoi(text[m[6]:m[7]])
+		} else if m[8] >= 0 {
+			year = 2000 + atoi(text[m[8]:m[9]])
+		}
+		add(m[0], year, textMonths[strings.ToLower(text[m[4]:m[5]])], atoi(text[m[2]:m[3]]))
+	}
+	for _, m := range textDateMonthDay.FindAllStringSubmatchIndex(text, -1) {
+		add(m[0], atoi(text[m[6]:m[7]]), textMonths[strings.ToLower(text[m[2]:m[3]])], atoi(text[m[4]:m[5]]))
+	}
+
+	sort.SliceStable(dates, func(i, j int) bool { return dates[i].at < dates[j].at })
+	result := make([]time.Time, len(dates))
+	for i, d := range dates {
+		result[i] = d.date
+	}
+	return result
+}
+
+// textTimes returns the clock times in the text as offsets from midnight
+func textTimes(text string) []time.Duration {
+	var times []time.Duration
+	for _, m := range textTime.FindAllStringSubmatch(text, -1) {
+		hour, minute := atoi(m[1]), atoi(m[2])
+		switch strings.ToLower(m[3]) {
+		case "a":
+			if hour == 12 {
+				hour = 0
+			}
+		case "p":
+			if hour < 12 {
+				hour += 12
+			}
+		}
+		times = append(times, time.Duration(hour)*time.Hour+time.Duration(minute)*time.Minute)
+	}
+	return times
+}
+
+// nearestYear completes a date given without a year with the year that puts it closest to now
+func nearestYear(month time.Month, day int, now time.Time) int {
+	best := now.Year()
+	for year := now.Year() - 1; year <= now.Year()+1; year++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		current := time.Date(best, month, day, 0, 0, 0, 0, time.UTC)
+		if absDuration(date.Sub(now)) < absDuration(current.Sub(now)) {
+			best = year
+		}
+	}
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -1,18 +1,35 @@
 package Infrastructure
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtKey = []byte("secret_key")
+var (
+	jwtKey          []byte
+	jwtPreviousKeys [][]byte
+	jwtTTL          = 24 * time.Hour
+)
+
+// ConfigureJWT sets the signing key, the retired keys still accepted during rotation, and the token lifetime
+func ConfigureJWT(cfg AuthConfig) {
+	jwtKey = []byte(cfg.JWTSecret)
+	jwtPreviousKeys = nil
+	for _, secret := range cfg.JWTPreviousSecrets {
+		jwtPreviousKeys = append(jwtPreviousKeys, []byte(secret))
+	}
+	jwtTTL = cfg.TokenTTL
+}
 
 func GenerateJWT(email string, userID string) (string, error) {
 	claims := jwt.MapClaims{
 		"email":   email,
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"exp":     time.Now().Add(jwtTTL).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -20,8 +37,22 @@ func GenerateJWT(email string, userID string) (string, error) {
 }
 
 func ValidateToken(tokenStr string) (jwt.MapClaims, error) {
+	claims, err := validateWithKey(tokenStr, jwtKey)
+	for _, key := range jwtPreviousKeys {
+		if err == nil {
+			break
+		}
+		claims, err = validateWithKey(tokenStr, key)
+	}
+	return claims, err
+}
+
+func validateWithKey(tokenStr string, key []byte) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key, nil
 	})
 
 	if err != nil {
@@ -33,3 +64,46 @@ func ValidateToken(tokenStr string) (jwt.MapClaims, error) {
 	}
 	return nil, jwt.ErrSignatureInvalid
 }
+
+// shareAudience marks share-link tokens. They are signed with a key derived from the JWT secret, so a share
+// token never passes as a login and a login never opens a share
+const shareAudience = "flight-share"
+
+// JWTShareSigner signs share-link tokens with the configured JWT secrets
+type JWTShareSigner struct{}
+
+// SignShareToken returns a token naming the share that stops verifying at expiresAt
+func (JWTShareSigner) SignShareToken(shareID string, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"aud": shareAudience,
+		"sid": shareID,
+		"exp": expiresAt.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(shareKey(jwtKey))
+}
+
+// VerifyShareToken accepts tokens signed under the current or a previous JWT secret
+func (JWTShareSigner) VerifyShareToken(tokenStr string) (string, error) {
+	claims, err := validateWithKey(tokenStr, shareKey(jwtKey))
+	for _, key := range jwtPreviousKeys {
+		if err == nil {
+			break
+		}
+		claims, err = validateWithKey(tokenStr, shareKey(key))
+	}
+	if err != nil {
+		return "", err
+	}
+	shareID, _ := claims["sid"].(string)
+	if !claims.VerifyAudience(shareAudience, true) || shareID == "" {
+		return "", errors.New("not a share token")
+	}
+	return shareID, nil
+}
+
+func shareKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(shareAudience))
+	return mac.Sum(nil)
+}
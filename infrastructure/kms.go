@@ -0,0 +1,101 @@
+package Infrastructure
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyWrapper protects data keys with a master key held outside the database
+type KeyWrapper interface {
+	// CurrentKeyID names the master key that Wrap uses
+	CurrentKeyID() string
+	Wrap(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKMS is a KeyWrapper backed by AES-256-GCM master keys from configuration, standing in for a cloud KMS
+type LocalKMS struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewLocalKMS builds a LocalKMS from the configured master keys; retired keys are kept for unwrapping only
+func NewLocalKMS(cfg EncryptionConfig) (*LocalKMS, error) {
+	keys, err := parseMasterKeys(cfg.MasterKeys)
+	if err != nil {
+		return nil, err
+	}
+	kms := &LocalKMS{current: cfg.CurrentKeyID, keys: map[string]cipher.AEAD{}}
+	for id, key := range keys {
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %v", id, err)
+		}
+		kms.keys[id] = aead
+	}
+	if _, ok := kms.keys[kms.current]; !ok {
+		return nil, fmt.Errorf("current master key %q is not configured", kms.current)
+	}
+	return kms, nil
+}
+
+func (k *LocalKMS) CurrentKeyID() string {
+	return k.current
+}
+
+// Wrap encrypts a data key under the current master key, binding the ciphertext to the key ID
+func (k *LocalKMS) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return k.current, aead.Seal(nonce, nonce, dataKey, []byte(k.current)), nil
+}
+
+// Unwrap decrypts a data key that was wrapped under the named master key
+func (k *LocalKMS) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not configured", keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped data key is truncated")
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, []byte(keyID))
+}
+
+// parseMasterKeys decodes "id:base64key" entries into 32-byte keys
+func parseMasterKeys(entries []string) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("master key entry must look like id:base64key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q is not valid base64: %v", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %q must be 32 bytes, got %d", id, len(key))
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
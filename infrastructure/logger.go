@@ -0,0 +1,139 @@
+package Infrastructure
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sensitiveKeyParts mark attributes whose values are never written to the log. A key is sensitive when it,
+// or a group it is logged under, contains one of them, so "answers", "qa", "user.email" and "new_password"
+// are all caught
+var sensitiveKeyParts = []string{
+	"email",
+	"password",
+	"token",
+	"authorization",
+	"secret",
+	"answer",
+	"qa",
+	"passport",
+	"address",
+	"mongo_uri",
+	"dsn",
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-_.=]+`)
+)
+
+// NewLogger builds the structured logger: JSON or text output, PII redaction, and request/trace IDs from the context
+func NewLogger(w io.Writer, cfg LoggingConfig) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// redactAttr blanks sensitive keys and scrubs emails and bearer tokens out of free-form strings. Numbers and
+// flags under a sensitive key, like qa_count, cannot carry the data itself and are kept
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKey(groups, a.Key) {
+		switch a.Value.Kind() {
+		case slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool, slog.KindDuration:
+			return a
+		}
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, RedactText(a.Value.String()))
+	}
+	if err, ok := a.Value.Any().(error); ok {
+		return slog.String(a.Key, RedactText(err.Error()))
+	}
+	return a
+}
+
+// sensitiveKey reports whether the key or any enclosing group names sensitive data
+func sensitiveKey(groups []string, key string) bool {
+	if sensitiveName(key) {
+		return true
+	}
+	for _, group := range groups {
+		if sensitiveName(group) {
+			return true
+		}
+	}
+	return false
+}
+
+func sensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactText removes email addresses and bearer tokens from a message
+func RedactText(s string) string {
+	s = emailPattern.ReplaceAllString(s, redacted)
+	return bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID stores the request ID so every log line written with the context carries it
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by ContextWithRequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and trace identifiers found in the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestIDFromContext(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	r.Message = RedactText(r.Message)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
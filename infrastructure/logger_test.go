@@ -0,0 +1,60 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+func TestNewLoggerRedacts(t *testing.T) {
+	const (
+		email    = "ada@example.com"
+		token    = "eyJhbGciOiJIUzI1NiJ9.c2VjcmV0.sig"
+		passport = "P1234567"
+		address  = "12 Rua Augusta, Lisbon"
+	)
+	qa := []domain.QA{{Question: "Passport number?", Answer: passport}, {Question: "Address in Portugal?", Answer: address}}
+
+	for _, format := range []string{"json", "text"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(&buf, LoggingConfig{Level: "debug", Format: format})
+			ctx := context.Background()
+
+			logger.InfoContext(ctx, "login for "+email+" with Bearer "+token)
+			logger.InfoContext(ctx, "flat keys", "email", email, "Authorization", "Bearer "+token, "new_password", "hunter2")
+			logger.InfoContext(ctx, "answers", "answers", qa, "qa", qa[0], "answer_text", passport, "home_address", address)
+			logger.InfoContext(ctx, "nested", slog.Group("user", slog.String("email", email), slog.String("id", "u1")),
+				"user.email", email)
+			logger.InfoContext(ctx, "grouped answers", slog.Group("answers", slog.String("text", passport)))
+			logger.InfoContext(ctx, "free text", "note", "contact "+email, "error", errors.New("token Bearer "+token+" rejected"))
+			logger.With("session_token", token).InfoContext(ctx, "with attrs")
+
+			out := buf.String()
+			for _, secret := range []string{email, token, passport, address, "hunter2"} {
+				if strings.Contains(out, secret) {
+					t.Errorf("log output contains %q:\n%s", secret, out)
+				}
+			}
+			for _, kept := range []string{"u1", "flat keys", "grouped answers"} {
+				if !strings.Contains(out, kept) {
+					t.Errorf("log output lost %q:\n%s", kept, out)
+				}
+			}
+		})
+	}
+}
+
+func TestNewLoggerKeepsCounts(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogger(&buf, LoggingConfig{Level: "info"}).Info("flight saved", "qa_count", 5, "flight", domain.Flight{ID: "f1", QA: []domain.QA{{Answer: "P1234567"}}})
+	out := buf.String()
+	if !strings.Contains(out, `"qa_count":5`) || strings.Contains(out, "P1234567") {
+		t.Errorf("log output = %s, want qa_count kept and no answers", out)
+	}
+}
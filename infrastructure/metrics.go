@@ -0,0 +1,139 @@
+package Infrastructure
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// metricsRegistry holds every collector exposed on /metrics
+var metricsRegistry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "passme",
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "passme",
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "passme",
+		Name:      "auth_logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	tokenFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "passme",
+		Name:      "auth_token_validation_failures_total",
+		Help:      "Rejected bearer tokens by reason.",
+	}, []string{"reason"})
+
+	flightsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "passme",
+		Name:      "flights_created_total",
+		Help:      "Flights created by destination ISO 3166-1 alpha-2 country code, or other.",
+	}, []string{"to_country"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "passme",
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Storage operation latency by backend, repository, operation and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"backend", "repository", "operation", "outcome"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, logins, tokenFailures, flightsCreated, repositoryDuration,
+	)
+}
+
+// MetricsHandler serves the Prometheus exposition format; it is meant for the internal metrics listener,
+// not the public API
+func MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// MetricsMiddleware records request counts and latency labelled by route template rather than raw path
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// LoginMetrics counts login outcomes from the response or the error left by the login handler
+func LoginMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			if c.Writer.Status() == http.StatusOK {
+				logins.WithLabelValues("success").Inc()
+			}
+			return
+		}
+		switch err := c.Errors.Last().Err; {
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			logins.WithLabelValues("failure").Inc()
+		case errors.Is(err, domain.ErrValidation):
+			logins.WithLabelValues("invalid").Inc()
+		default:
+			logins.WithLabelValues("error").Inc()
+		}
+	}
+}
+
+// RecordFlightCreated counts a stored flight against its destination. The country comes from the client,
+// so unknown values share one label instead of each starting a new series
+func RecordFlightCreated(toCountry string) {
+	flightsCreated.WithLabelValues(countryLabel(toCountry)).Inc()
+}
+
+// ObserveRepositoryOperation records how long a storage call took and how it ended
+func ObserveRepositoryOperation(backend, repository, operation string, start time.Time, err error) {
+	repositoryDuration.WithLabelValues(backend, repository, operation, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// outcome buckets an error into a low-cardinality label
+func outcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		switch domainErr.Kind {
+		case domain.KindNotFound:
+			return "not_found"
+		case domain.KindConflict:
+			return "conflict"
+		}
+	}
+	return "error"
+}
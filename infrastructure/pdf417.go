@@ -0,0 +1,592 @@
+package Infrastructure
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+)
+
+// The PDF417 reader handles symbols that are upright or turned by a multiple of 90 degrees, which covers
+// screenshots, wallet passes and flatbed scans of paper boarding passes; skewed photos are not supported
+
+const (
+	pdf417Modulus  = 929
+	pdf417Modules  = 17
+	pdf417MaxWords = 928
+	// pdf417StopPrefix is the first 17 modules of the 18-module stop pattern
+	pdf417StopPrefix = 0x3fa29 >> 1
+)
+
+var errPDF417NotFound = errors.New("no PDF417 symbol found")
+
+var (
+	// pdf417StartRatios and pdf417StopRatios are the bar and space widths, in modules, of the guard patterns
+	pdf417StartRatios = []float64{8, 1, 1, 1, 1, 1, 1, 3}
+	pdf417StopRatios  = []float64{7, 1, 1, 3, 1, 1, 1, 2, 1}
+
+	pdf417MixedChars = "0123456789&\r\t,:#-.$/+%*=^"
+	pdf417PunctChars = ";<>@[\\]_`~!\r\t,:\n-.$/\"|*()?{}'"
+)
+
+// pdf417Symbol is a decoded codeword together with the cluster (0, 1 or 2 for clusters 0, 3 and 6) it came from
+type pdf417Symbol struct {
+	cluster int
+	value   int
+}
+
+// pdf417Lookup maps every bar/space pattern back to its codeword
+var pdf417Lookup = func() map[uint32]pdf417Symbol {
+	lookup := make(map[uint32]pdf417Symbol, 3*pdf417Modulus)
+	for cluster, patterns := range pdf417Patterns {
+		for value, pattern := range patterns {
+			lookup[pattern] = pdf417Symbol{cluster: cluster, value: value}
+		}
+	}
+	return lookup
+}()
+
+// pdf417Run is a horizontal stretch of same-coloured pixels
+type pdf417Run struct {
+	x, width int
+	black    bool
+}
+
+// pdf417Line is one scanline that crossed a whole row, from the start pattern to the stop pattern
+type pdf417Line struct {
+	row   int
+	left  pdf417Symbol
+	right pdf417Symbol
+	data  []int // -1 marks a codeword that could not be read
+}
+
+// decodePDF417 reads the first PDF417 symbol in the matrix, trying each right-angle orientation; it rotates matrix in place
+func decodePDF417(matrix *gozxing.BitMatrix) (string, error) {
+	for turn := 0; turn < 4; turn++ {
+		switch turn {
+		case 1:
+			matrix.Rotate180()
+		case 2:
+			matrix.Rotate90()
+		case 3:
+			matrix.Rotate180()
+		}
+		if text, err := decodeUprightPDF417(matrix); err == nil {
+			return text, nil
+		}
+	}
+	return "", errPDF417NotFound
+}
+
+// decodeUprightPDF417 scans every pixel row, votes on the codeword of each cell and error-corrects the result
+func decodeUprightPDF417(matrix *gozxing.BitMatrix) (string, error) {
+	var lines []pdf417Line
+	for y := 0; y < matrix.GetHeight(); y++ {
+		if line, ok := scanPDF417Line(matrix, y); ok {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", errPDF417NotFound
+	}
+
+	// Row indicators spread the symbol's dimensions over three rows: rows/3, the error correction level
+	// with rows%3, and the column count
+	var rowGroups, levelAndRows, columns = map[int]int{}, map[int]int{}, map[int]int{}
+	for _, line := range lines {
+		left, right := line.left.value%30, line.right.value%30
+		switch line.left.cluster {
+		case 0:
+			rowGroups[left]++
+			columns[right]++
+		case 1:
+			levelAndRows[left]++
+			rowGroups[right]++
+		case 2:
+			columns[left]++
+			levelAndRows[right]++
+		}
+	}
+	levelRows, ok1 := mostVoted(levelAndRows)
+	cols, ok2 := mostVoted(columns)
+	if !ok1 || !ok2 {
+		return "", errPDF417NotFound
+	}
+
+	// Some encoders write a row count into the left indicator that disagrees with the right one, so every
+	// candidate is tried and the error correction decides
+	for _, groups := range byVotes(rowGroups) {
+		if text, err := assemblePDF417(lines, 3*groups+levelRows%3+1, cols+1, levelRows/3); err == nil {
+			return text, nil
+		}
+	}
+	return "", errPDF417NotFound
+}
+
+// assemblePDF417 votes on every cell of a symbol with the given dimensions, corrects errors and decodes the data
+func assemblePDF417(lines []pdf417Line, rows, cols, level int) (string, error) {
+	numEC := 2 << level
+	if rows < 3 || rows > 90 || level > 8 || rows*cols > pdf417MaxWords || numEC >= rows*cols {
+		return "", errPDF417NotFound
+	}
+
+	votes := make([]map[int]int, rows*cols)
+	for _, line := range lines {
+		if line.row >= rows || len(line.data) != cols {
+			continue
+		}
+		for col, value := range line.data {
+			if value < 0 {
+				continue
+			}
+			cell := line.row*cols + col
+			if votes[cell] == nil {
+				votes[cell] = map[int]int{}
+			}
+			votes[cell][value]++
+		}
+	}
+	codewords := make([]int, rows*cols)
+	for i, cell := range votes {
+		codewords[i], _ = mostVoted(cell)
+	}
+
+	if err := correctPDF417(codewords, numEC); err != nil {
+		return "", err
+	}
+	length := codewords[0]
+	if length < 1 || length > len(codewords)-numEC {
+		return "", errPDF417NotFound
+	}
+	return decodePDF417Codewords(codewords[1:length])
+}
+
+// scanPDF417Line reads the row crossed by pixel row y, keeping it only if it runs from start to stop pattern
+// and every codeword belongs to the same cluster
+func scanPDF417Line(matrix *gozxing.BitMatrix, y int) (pdf417Line, bool) {
+	runs := pixelRuns(matrix, y)
+	for i := 0; i+len(pdf417StartRatios) <= len(runs); i++ {
+		if !runs[i].black {
+			continue
+		}
+		unit, ok := matchGuard(runs[i:i+len(pdf417StartRatios)], pdf417StartRatios)
+		if !ok {
+			continue
+		}
+		symbols, ok := readPDF417Symbols(matrix, y, runs, i+len(pdf417StartRatios), unit)
+		if !ok || len(symbols) < 3 {
+			return pdf417Line{}, false
+		}
+
+		left, right := symbols[0], symbols[len(symbols)-1]
+		if left.value < 0 || right.value < 0 || left.cluster != right.cluster || left.value/30 != right.value/30 {
+			return pdf417Line{}, false
+		}
+		line := pdf417Line{row: 3*(left.value/30) + left.cluster, left: left, right: right}
+		for _, symbol := range symbols[1 : len(symbols)-1] {
+			if symbol.cluster != left.cluster {
+				symbol.value = -1
+			}
+			line.data = append(line.data, symbol.value)
+		}
+		return line, true
+	}
+	return pdf417Line{}, false
+}
+
+// readPDF417Symbols reads codewords from runs[next:] until the stop pattern, resynchronising on the
+// expected codeword width so a single noisy bar costs one codeword rather than the rest of the row
+func readPDF417Symbols(matrix *gozxing.BitMatrix, y int, runs []pdf417Run, next int, unit float64) ([]pdf417Symbol, bool) {
+	var symbols []pdf417Symbol
+	for len(symbols) <= 32 {
+		if next+len(pdf417StopRatios) <= len(runs) && runs[next].black {
+			if _, ok := matchGuard(runs[next:next+len(pdf417StopRatios)], pdf417StopRatios); ok {
+				return symbols, true
+			}
+		}
+		if next >= len(runs) || !runs[next].black {
+			return nil, false
+		}
+
+		start := runs[next].x
+		width := unit * pdf417Modules
+		if next+8 <= len(runs) {
+			measured := float64(runs[next+7].x + runs[next+7].width - start)
+			if math.Abs(measured-width) <= 0.25*width {
+				width = measured
+				unit = measured / pdf417Modules
+			}
+		}
+
+		pattern := samplePattern(matrix, y, float64(start), width)
+		if pattern == pdf417StopPrefix {
+			return symbols, true
+		}
+		symbol, ok := pdf417Lookup[pattern]
+		if !ok {
+			symbol = pdf417Symbol{cluster: -1, value: -1}
+		}
+		symbols = append(symbols, symbol)
+
+		// The next codeword begins at the first bar closest to where this one should end
+		end := float64(start) + width
+		best := -1
+		for j := next + 1; j < len(runs) && float64(runs[j].x) <= end+2*unit; j++ {
+			if runs[j].black && math.Abs(float64(runs[j].x)-end) <= 2*unit && (best < 0 || math.Abs(float64(runs[j].x)-end) < math.Abs(float64(runs[best].x)-end)) {
+				best = j
+			}
+		}
+		if best < 0 {
+			return nil, false
+		}
+		next = best
+	}
+	return nil, false
+}
+
+// samplePattern reads 17 modules starting at x, taking the majority of the pixels above, on and below
+// the centre of each module so isolated specks do not flip it
+func samplePattern(matrix *gozxing.BitMatrix, y int, x, width float64) uint32 {
+	var pattern uint32
+	module := width / pdf417Modules
+	for i := 0; i < pdf417Modules; i++ {
+		pattern <<= 1
+		px := int(x + (float64(i)+0.5)*module)
+		if px >= matrix.GetWidth() {
+			continue
+		}
+		black := 0
+		for dy := -1; dy <= 1; dy++ {
+			if py := min(max(y+dy, 0), matrix.GetHeight()-1); matrix.Get(px, py) {
+				black++
+			}
+		}
+		if black >= 2 {
+			pattern |= 1
+		}
+	}
+	return pattern
+}
+
+// matchGuard reports whether the runs have the given width ratios and returns the module width
+func matchGuard(runs []pdf417Run, ratios []float64) (float64, bool) {
+	var total, modules float64
+	for i, run := range runs {
+		total += float64(run.width)
+		modules += ratios[i]
+	}
+	unit := total / modules
+	if unit < 1 {
+		return 0, false
+	}
+	for i, run := range runs {
+		if math.Abs(float64(run.width)/unit-ratios[i]) > 0.5+0.15*ratios[i] {
+			return 0, false
+		}
+	}
+	return unit, true
+}
+
+// pixelRuns splits pixel row y into alternating runs of black and white
+func pixelRuns(matrix *gozxing.BitMatrix, y int) []pdf417Run {
+	var runs []pdf417Run
+	width := matrix.GetWidth()
+	for x := 0; x < width; {
+		black := matrix.Get(x, y)
+		start := x
+		for x < width && matrix.Get(x, y) == black {
+			x++
+		}
+		runs = append(runs, pdf417Run{x: start, width: x - start, black: black})
+	}
+	return runs
+}
+
+// mostVoted returns the value with the most votes, preferring the smaller value on ties
+func mostVoted(votes map[int]int) (int, bool) {
+	best, count := 0, 0
+	for value, n := range votes {
+		if n > count || (n == count && value < best) {
+			best, count = value, n
+		}
+	}
+	return best, count > 0
+}
+
+// byVotes returns the voted values, most votes first
+func byVotes(votes map[int]int) []int {
+	values := make([]int, 0, len(votes))
+	for value := range votes {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if votes[values[i]] != votes[values[j]] {
+			return votes[values[i]] > votes[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// correctPDF417 fixes up to numEC/2 wrong codewords in place using the Reed-Solomon code over GF(929)
+// with generator roots 3^1..3^numEC
+func correctPDF417(codewords []int, numEC int) error {
+	syndromes := make([]int, numEC)
+	clean := true
+	for i := range syndromes {
+		root := gfPow(3, i+1)
+		s := 0
+		for _, c := range codewords {
+			s = (s*root + c) % pdf417Modulus
+		}
+		syndromes[i] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return nil
+	}
+
+	// Berlekamp-Massey finds the error locator Λ(x) = Π(1 - X_k x)
+	locator, previous := []int{1}, []int{1}
+	errs, shift, lastDiscrepancy := 0, 1, 1
+	for k := 0; k < numEC; k++ {
+		d := syndromes[k]
+		for i := 1; i <= errs && i < len(locator); i++ {
+			d = (d + locator[i]*syndromes[k-i]) % pdf417Modulus
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+		coef := d * gfInverse(lastDiscrepancy) % pdf417Modulus
+		saved := append([]int(nil), locator...)
+		for len(locator) < len(previous)+shift {
+			locator = append(locator, 0)
+		}
+		for i, p := range previous {
+			locator[i+shift] = (locator[i+shift] - coef*p%pdf417Modulus + pdf417Modulus) % pdf417Modulus
+		}
+		if 2*errs <= k {
+			errs, previous, lastDiscrepancy, shift = k+1-errs, saved, d, 1
+		} else {
+			shift++
+		}
+	}
+	if errs > numEC/2 {
+		return errPDF417NotFound
+	}
+
+	// Ω(x) = S(x)Λ(x) mod x^numEC, and Forney's formula gives each error value as -Ω(X⁻¹)/Λ'(X⁻¹)
+	evaluator := make([]int, numEC)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] = (evaluator[i] + syndromes[i-j]*locator[j]) % pdf417Modulus
+		}
+	}
+	derivative := make([]int, len(locator))
+	for i := 1; i < len(locator); i++ {
+		derivative[i-1] = i * locator[i] % pdf417Modulus
+	}
+
+	found := 0
+	n := len(codewords)
+	for j := 0; j < n; j++ {
+		xInv := gfInverse(gfPow(3, n-1-j))
+		if gfEval(locator, xInv) != 0 {
+			continue
+		}
+		denominator := gfEval(derivative, xInv)
+		if denominator == 0 {
+			return errPDF417NotFound
+		}
+		magnitude := (pdf417Modulus - gfEval(evaluator, xInv)*gfInverse(denominator)%pdf417Modulus) % pdf417Modulus
+		codewords[j] = (codewords[j] - magnitude + pdf417Modulus) % pdf417Modulus
+		found++
+	}
+	if found != errs {
+		return errPDF417NotFound
+	}
+	return nil
+}
+
+func gfPow(base, exp int) int {
+	result := 1
+	for base %= pdf417Modulus; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result = result * base % pdf417Modulus
+		}
+		base = base * base % pdf417Modulus
+	}
+	return result
+}
+
+func gfInverse(a int) int {
+	return gfPow(a, pdf417Modulus-2)
+}
+
+// gfEval evaluates a polynomial stored lowest degree first
+func gfEval(poly []int, x int) int {
+	result := 0
+	for i := len(poly) - 1; i >= 0; i-- {
+		result = (result*x + poly[i]) % pdf417Modulus
+	}
+	return result
+}
+
+// Text compaction sub-modes
+const (
+	pdf417Alpha = iota
+	pdf417Lower
+	pdf417Mixed
+	pdf417Punct
+)
+
+// decodePDF417Codewords turns data codewords into text, following the text, byte and numeric compaction latches
+func decodePDF417Codewords(codewords []int) (string, error) {
+	var out strings.Builder
+	mode, sub := 900, pdf417Alpha
+	for i := 0; i < len(codewords); {
+		switch code := codewords[i]; code {
+		case 900, 901, 902, 924:
+			mode = code
+			if code == 900 {
+				sub = pdf417Alpha
+			}
+			i++
+			continue
+		case 913: // shift to byte compaction for a single byte
+			if i+1 < len(codewords) {
+				out.WriteByte(byte(codewords[i+1]))
+			}
+			i += 2
+			continue
+		case 925, 927: // ECI designators with one parameter; boarding passes are plain ASCII
+			i += 2
+			continue
+		case 926:
+			i += 3
+			continue
+		case 922, 923, 928: // macro PDF417 control block; nothing after it is message data
+			return out.String(), nil
+		default:
+			if code >= 900 {
+				return "", errPDF417NotFound
+			}
+		}
+
+		end := i
+		for end < len(codewords) && codewords[end] < 900 {
+			end++
+		}
+		segment := codewords[i:end]
+		i = end
+
+		switch mode {
+		case 900:
+			sub = decodePDF417Text(&out, segment, sub)
+		case 901, 924:
+			decodePDF417Bytes(&out, segment, mode == 924)
+		case 902:
+			if err := decodePDF417Numeric(&out, segment); err != nil {
+				return "", err
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// decodePDF417Text decodes text compaction, two values of base 30 per codeword, and returns the sub-mode it ends in
+func decodePDF417Text(out *strings.Builder, segment []int, sub int) int {
+	shift := -1 // the sub-mode of a single shifted character, if any
+	for _, code := range segment {
+		for _, v := range [2]int{code / 30, code % 30} {
+			mode := sub
+			if shift >= 0 {
+				mode, shift = shift, -1
+			}
+			switch mode {
+			case pdf417Alpha, pdf417Lower:
+				switch {
+				case v < 26 && mode == pdf417Alpha:
+					out.WriteByte(byte('A' + v))
+				case v < 26:
+					out.WriteByte(byte('a' + v))
+				case v == 26:
+					out.WriteByte(' ')
+				case v == 27 && mode == pdf417Alpha:
+					sub = pdf417Lower
+				case v == 27:
+					shift = pdf417Alpha
+				case v == 28:
+					sub = pdf417Mixed
+				case v == 29:
+					shift = pdf417Punct
+				}
+			case pdf417Mixed:
+				switch {
+				case v < 25:
+					out.WriteByte(pdf417MixedChars[v])
+				case v == 25:
+					sub = pdf417Punct
+				case v == 26:
+					out.WriteByte(' ')
+				case v == 27:
+					sub = pdf417Lower
+				case v == 28:
+					sub = pdf417Alpha
+				case v == 29:
+					shift = pdf417Punct
+				}
+			case pdf417Punct:
+				if v < 29 {
+					out.WriteByte(pdf417PunctChars[v])
+				} else {
+					sub = pdf417Alpha
+				}
+			}
+		}
+	}
+	return sub
+}
+
+// decodePDF417Bytes decodes byte compaction: five codewords carry six bytes, and in latch 901 the trailing
+// codewords that do not fill a group are single bytes
+func decodePDF417Bytes(out *strings.Builder, segment []int, whole bool) {
+	groups := len(segment) / 5
+	if !whole && len(segment) > 0 {
+		groups = (len(segment) - 1) / 5
+	}
+	for g := 0; g < groups; g++ {
+		var value uint64
+		for _, code := range segment[5*g : 5*g+5] {
+			value = value*900 + uint64(code)
+		}
+		for shift := 40; shift >= 0; shift -= 8 {
+			out.WriteByte(byte(value >> shift))
+		}
+	}
+	for _, code := range segment[5*groups:] {
+		out.WriteByte(byte(code))
+	}
+}
+
+// decodePDF417Numeric decodes numeric compaction, where up to 15 codewords hold a base-900 number prefixed by 1
+func decodePDF417Numeric(out *strings.Builder, segment []int) error {
+	for start := 0; start < len(segment); start += 15 {
+		end := min(start+15, len(segment))
+		value := new(big.Int)
+		for _, code := range segment[start:end] {
+			value.Mul(value, big.NewInt(900))
+			value.Add(value, big.NewInt(int64(code)))
+		}
+		digits := value.String()
+		if len(digits) < 2 || digits[0] != '1' {
+			return errPDF417NotFound
+		}
+		out.WriteString(digits[1:])
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// Regenerate the fixtures with: go test ./infrastructure -run TestBoardingPassFixtures -update
+var updateFixtures = flag.Bool("update", false, "rewrite the boarding-pass PNGs in testdata")
+
+const (
+	singleLegPass = "M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 226F001A0025 100"
+	multiLegPass  = "M2DESMARAIS/LUC       EABC123 YULFRAAC 0834 226F001A0025 100DEF456 FRAGVALH 3664 227C012C0002 100"
+)
+
+// boardingPassFixtures are rendered the way a scanner or screenshot would show them; want is empty when the
+// reader should report that no barcode was found
+var boardingPassFixtures = []struct {
+	file    string
+	text    string
+	degrees float64
+	ink     uint8 // grey level of the bars on a white background
+	want    string
+}{
+	{file: "boarding_pass.png", text: singleLegPass, ink: 0, want: singleLegPass},
+	{file: "boarding_pass_rotated.png", text: singleLegPass, degrees: 90, ink: 0, want: singleLegPass},
+	{file: "boarding_pass_multi_leg.png", text: multiLegPass, ink: 0, want: multiLegPass},
+	{file: "boarding_pass_skewed.png", text: singleLegPass, degrees: 8, ink: 0},
+	{file: "boarding_pass_low_contrast.png", text: singleLegPass, ink: 240},
+}
+
+func TestBoardingPassFixtures(t *testing.T) {
+	if *updateFixtures {
+		for _, f := range boardingPassFixtures {
+			writeFixture(t, f.file, renderBoardingPass(f.text, f.degrees, f.ink))
+		}
+	}
+
+	reader := NewBarcodeReader()
+	for _, f := range boardingPassFixtures {
+		t.Run(f.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", f.file))
+			if err != nil {
+				t.Fatalf("read fixture: %v", err)
+			}
+			got, err := reader.DecodeBarcode(context.Background(), data)
+			if f.want == "" {
+				if !errors.Is(err, domain.ErrBarcodeNotFound) {
+					t.Fatalf("DecodeBarcode = %q, %v; want %v", got, err, domain.ErrBarcodeNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeBarcode: %v", err)
+			}
+			if got != f.want {
+				t.Fatalf("DecodeBarcode = %q, want %q", got, f.want)
+			}
+		})
+	}
+}
+
+func TestMultiLegBoardingPassImage(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "boarding_pass_multi_leg.png"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	text, err := NewBarcodeReader().DecodeBarcode(context.Background(), data)
+	if err != nil {
+		t.Fatalf("DecodeBarcode: %v", err)
+	}
+	pass, err := domain.ParseBoardingPass(text)
+	if err != nil {
+		t.Fatalf("ParseBoardingPass: %v", err)
+	}
+	if len(pass.Legs) != 2 || pass.Legs[0].FlightNumber != "AC834" || pass.Legs[1].FlightNumber != "LH3664" ||
+		pass.Legs[1].FromAirport != "FRA" || pass.Legs[1].ToAirport != "GVA" {
+		t.Errorf("parsed legs = %+v, want AC834 YUL-FRA then LH3664 FRA-GVA", pass.Legs)
+	}
+}
+
+func TestDecodeBarcodeRejectsNonImages(t *testing.T) {
+	_, err := NewBarcodeReader().DecodeBarcode(context.Background(), []byte(singleLegPass))
+	var derr *domain.Error
+	if !errors.As(err, &derr) || derr.Kind != domain.KindInvalid {
+		t.Fatalf("DecodeBarcode of text = %v, want a validation error", err)
+	}
+}
+
+func writeFixture(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode %s: %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join("testdata", name), buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+// renderBoardingPass draws text as a PDF417 symbol at three pixels per module, turned by degrees
+func renderBoardingPass(text string, degrees float64, ink uint8) image.Image {
+	const scale, rowHeight, quiet = 3, 4, 2
+	modules := encodePDF417([]byte(text), 6, 3)
+
+	width := (len(modules[0]) + 2*quiet) * scale
+	height := len(modules)*rowHeight*scale + 2*quiet*scale
+	upright := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			upright.SetGray(x, y, color.Gray{Y: 255})
+			mx, my := x/scale-quiet, (y-quiet*scale)/(rowHeight*scale)
+			if y >= quiet*scale && mx >= 0 && mx < len(modules[0]) && my < len(modules) && modules[my][mx] {
+				upright.SetGray(x, y, color.Gray{Y: ink})
+			}
+		}
+	}
+	if degrees == 0 {
+		return upright
+	}
+	return rotateGray(upright, degrees)
+}
+
+// rotateGray turns img clockwise about its centre onto a white canvas large enough to hold it
+func rotateGray(img *image.Gray, degrees float64) *image.Gray {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	outW := int(math.Ceil(math.Abs(w*cos) + math.Abs(h*sin)))
+	outH := int(math.Ceil(math.Abs(w*sin) + math.Abs(h*cos)))
+	out := image.NewGray(image.Rect(0, 0, outW, outH))
+	for y := 0; y < outH; y++ {
+		for x := 0; x < outW; x++ {
+			dx, dy := float64(x)-float64(outW)/2+0.5, float64(y)-float64(outH)/2+0.5
+			sx := int(math.Floor(dx*cos + dy*sin + w/2))
+			sy := int(math.Floor(-dx*sin + dy*cos + h/2))
+			shade := uint8(255)
+			if sx >= 0 && sy >= 0 && sx < int(w) && sy < int(h) {
+				shade = img.GrayAt(sx, sy).Y
+			}
+			out.SetGray(x, y, color.Gray{Y: shade})
+		}
+	}
+	return out
+}
+
+// encodePDF417 lays out data in byte compaction with the given number of data columns and error correction
+// level, returning one slice of modules per row with true for a bar
+func encodePDF417(data []byte, cols, level int) [][]bool {
+	var words []int
+	if len(data)%6 == 0 {
+		words = append(words, 924)
+	} else {
+		words = append(words, 901)
+	}
+	whole := len(data) / 6 * 6
+	for i := 0; i < whole; i += 6 {
+		var value uint64
+		for _, b := range data[i : i+6] {
+			value = value<<8 | uint64(b)
+		}
+		group := make([]int, 5)
+		for j := 4; j >= 0; j-- {
+			group[j] = int(value % 900)
+			value /= 900
+		}
+		words = append(words, group...)
+	}
+	for _, b := range data[whole:] {
+		words = append(words, int(b))
+	}
+
+	numEC := 2 << level
+	rows := max(3, (1+len(words)+numEC+cols-1)/cols)
+	codewords := append([]int{0}, words...)
+	for len(codewords) < rows*cols-numEC {
+		codewords = append(codewords, 900)
+	}
+	codewords[0] = len(codewords)
+	codewords = append(codewords, pdf417ErrorCorrection(codewords, numEC)...)
+
+	start := []bool{true, true, true, true, true, true, true, true, false, true, false, true, false, true, false, false, false}
+	stop := []bool{true, true, true, true, true, true, true, false, true, false, false, false, true, false, true, false, false, true}
+	matrix := make([][]bool, rows)
+	for r := 0; r < rows; r++ {
+		cluster := r % 3
+		base := 30 * (r / 3)
+		var left, right int
+		switch cluster {
+		case 0:
+			left, right = base+(rows-1)/3, base+cols-1
+		case 1:
+			left, right = base+level*3+(rows-1)%3, base+(rows-1)/3
+		case 2:
+			left, right = base+cols-1, base+level*3+(rows-1)%3
+		}
+		line := append([]bool(nil), start...)
+		line = appendPattern(line, pdf417Patterns[cluster][left])
+		for c := 0; c < cols; c++ {
+			line = appendPattern(line, pdf417Patterns[cluster][codewords[r*cols+c]])
+		}
+		line = appendPattern(line, pdf417Patterns[cluster][right])
+		matrix[r] = append(line, stop...)
+	}
+	return matrix
+}
+
+func appendPattern(line []bool, pattern uint32) []bool {
+	for bit := pdf417Modules - 1; bit >= 0; bit-- {
+		line = append(line, pattern>>bit&1 == 1)
+	}
+	return line
+}
+
+// pdf417ErrorCorrection returns the numEC codewords that make the data divisible by the generator with
+// roots 3^1..3^numEC, the inverse of correctPDF417
+func pdf417ErrorCorrection(data []int, numEC int) []int {
+	generator := []int{1} // highest degree first
+	for i := 1; i <= numEC; i++ {
+		root := gfPow(3, i)
+		next := make([]int, len(generator)+1)
+		for j, g := range generator {
+			next[j] = (next[j] + g) % pdf417Modulus
+			next[j+1] = (next[j+1] + pdf417Modulus - g*root%pdf417Modulus) % pdf417Modulus
+		}
+		generator = next
+	}
+
+	remainder := append(append([]int(nil), data...), make([]int, numEC)...)
+	for i := 0; i < len(data); i++ {
+		coef := remainder[i]
+		if coef == 0 {
+			continue
+		}
+		for j, g := range generator {
+			remainder[i+j] = (remainder[i+j] + pdf417Modulus - coef*g%pdf417Modulus) % pdf417Modulus
+		}
+	}
+	ec := remainder[len(data):]
+	for i := range ec {
+		ec[i] = (pdf417Modulus - ec[i]) % pdf417Modulus
+	}
+	return ec
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// legacyUpdatedAt is the UpdatedAt of flights stored before versioning, placing them at the start of the feed
+var legacyUpdatedAt = time.Unix(0, 0).UTC()
+
+// writeTime stamps a write. It is truncated to milliseconds, the finest precision every backend keeps, so a
+// cursor built from a returned change compares equal to the stored value
+func writeTime() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// flightChange wraps a stored flight as a change feed entry
+func flightChange(flight domain.Flight) domain.FlightChange {
+	return domain.FlightChange{FlightID: flight.ID, Version: flight.Version, UpdatedAt: flight.UpdatedAt, Flight: &flight}
+}
+
+// mergeChanges orders flights and tombstones into one feed and keeps the first limit entries
+func mergeChanges(changes []domain.FlightChange, limit int) []domain.FlightChange {
+	sort.Slice(changes, func(i, j int) bool {
+		cursor := domain.ChangeCursor{UpdatedAt: changes[i].UpdatedAt, FlightID: changes[i].FlightID}
+		return cursor.After(changes[j])
+	})
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes
+}
@@ -0,0 +1,56 @@
+//go:build mongo
+
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Run with: MONGO_URI=mongodb://localhost:27017 go test -tags mongo ./repositories/
+func init() {
+	backends = append(backends, backend{
+		name: "mongo",
+		open: func(t *testing.T) (domain.UserRepository, domain.FlightRepository) {
+			db := openMongo(t)
+			return repositories.NewUserRepository(db, repositories.DefaultTimeouts),
+				repositories.NewFlightRepository(db, repositories.DefaultTimeouts)
+		},
+		trips: func(t *testing.T) domain.TripRepository {
+			return repositories.NewTripRepository(openMongo(t), repositories.DefaultTimeouts)
+		},
+		households: func(t *testing.T) domain.HouseholdRepository {
+			return repositories.NewHouseholdRepository(openMongo(t), repositories.DefaultTimeouts)
+		},
+	})
+}
+
+// openMongo creates a throwaway database per test, with the indexes the migrations create
+func openMongo(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	db := client.Database("passme_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	if _, err := repositories.RunMigrations(ctx, db); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	return db
+}
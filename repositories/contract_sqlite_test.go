@@ -0,0 +1,77 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+)
+
+func init() {
+	backends = append(backends, backend{
+		name: "sqlite",
+		open: func(t *testing.T) (domain.UserRepository, domain.FlightRepository) {
+			return openSQLite(t)
+		},
+		trips: func(t *testing.T) domain.TripRepository {
+			return repositories.NewSQLTripRepository(openSQLDB(t), repositories.DialectSQLite, repositories.DefaultTimeouts)
+		},
+		households: func(t *testing.T) domain.HouseholdRepository {
+			return repositories.NewSQLHouseholdRepository(openSQLDB(t), repositories.DialectSQLite, repositories.DefaultTimeouts)
+		},
+	})
+}
+
+// openSQLite migrates a private in-memory SQLite database, the STORAGE=sqlite backend with no files
+func openSQLite(t *testing.T) (domain.UserRepository, domain.FlightRepository) {
+	db := openSQLDB(t)
+	return repositories.NewSQLUserRepository(db, repositories.DialectSQLite, repositories.DefaultTimeouts),
+		repositories.NewSQLFlightRepository(db, repositories.DialectSQLite, repositories.DefaultTimeouts)
+}
+
+// openSQLDB opens and migrates the database behind openSQLite
+func openSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := repositories.OpenSQL(ctx, repositories.DialectSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := repositories.RunSQLMigrations(ctx, db, repositories.DialectSQLite); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	return db
+}
+
+// A share link must stop resolving once its flight is gone, even though shares live in their own table
+func TestSQLiteShareFollowsDeletedFlight(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLDB(t)
+	flights := repositories.NewSQLFlightRepository(db, repositories.DialectSQLite, repositories.DefaultTimeouts)
+	shares := repositories.NewSQLShareRepository(db, repositories.DialectSQLite, repositories.DefaultTimeouts)
+
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+	now := time.Now().UTC()
+	share := &domain.FlightShare{FlightID: flight.ID, UserID: "owner", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := shares.CreateShare(ctx, share); err != nil {
+		t.Fatalf("CreateShare: %v", err)
+	}
+	if err := flights.DeleteFlight(ctx, "owner", flight.ID, 0); err != nil {
+		t.Fatalf("DeleteFlight: %v", err)
+	}
+
+	if _, err := shares.GetShareByID(ctx, share.ID); !errors.Is(err, domain.ErrShareNotFound) {
+		t.Errorf("GetShareByID after deleting the flight: got %v, want %v", err, domain.ErrShareNotFound)
+	}
+	if remaining, err := shares.GetSharesByFlightID(ctx, flight.ID); err != nil || len(remaining) != 0 {
+		t.Errorf("GetSharesByFlightID after deleting the flight = %d shares, %v; want none", len(remaining), err)
+	}
+}
@@ -0,0 +1,829 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+)
+
+// backend opens fresh, empty repositories of one storage implementation for a single test
+type backend struct {
+	name       string
+	open       func(t *testing.T) (domain.UserRepository, domain.FlightRepository)
+	trips      func(t *testing.T) domain.TripRepository
+	households func(t *testing.T) domain.HouseholdRepository
+}
+
+// backends lists every implementation the contract runs against; backends that need a running service
+// add themselves from files behind a build tag
+var backends = []backend{
+	{
+		name: "memory",
+		open: func(t *testing.T) (domain.UserRepository, domain.FlightRepository) {
+			return repositories.NewMemoryUserRepository(), repositories.NewMemoryFlightRepository()
+		},
+		trips: func(t *testing.T) domain.TripRepository {
+			return repositories.NewMemoryTripRepository()
+		},
+		households: func(t *testing.T) domain.HouseholdRepository {
+			return repositories.NewMemoryHouseholdRepository()
+		},
+	},
+}
+
+// missingID is well formed for every backend but never issued by one in a fresh store
+const missingID = "5f1d7f3a9c2b4e0011223344"
+
+func TestUserRepositoryContract(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			tests := []struct {
+				name string
+				run  func(t *testing.T, users domain.UserRepository)
+			}{
+				{"create and find", testCreateAndFindUser},
+				{"duplicate keys", testDuplicateUser},
+				{"update", testUpdateUser},
+				{"not found", testUserNotFound},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					users, _ := b.open(t)
+					tt.run(t, users)
+				})
+			}
+		})
+	}
+}
+
+func TestFlightRepositoryContract(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			tests := []struct {
+				name string
+				run  func(t *testing.T, flights domain.FlightRepository)
+			}{
+				{"create and get", testCreateAndGetFlight},
+				{"update", testUpdateFlight},
+				{"delete", testDeleteFlight},
+				{"ownership", testFlightOwnership},
+				{"not found", testFlightNotFound},
+				{"client ref", testFlightClientRef},
+				{"tombstones", testFlightTombstones},
+				{"expiry", testFlightExpiry},
+				{"reseal", testResealFlight},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					_, flights := b.open(t)
+					tt.run(t, flights)
+				})
+			}
+		})
+	}
+}
+
+func TestTripRepositoryContract(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			tests := []struct {
+				name string
+				run  func(t *testing.T, trips domain.TripRepository)
+			}{
+				{"versions", testTripVersions},
+				{"expiry", testTripExpiry},
+				{"reseal", testResealTrip},
+				{"not found", testTripNotFound},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					tt.run(t, b.trips(t))
+				})
+			}
+		})
+	}
+}
+
+func TestHouseholdRepositoryContract(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			tests := []struct {
+				name string
+				run  func(t *testing.T, households domain.HouseholdRepository)
+			}{
+				{"last guardian", testRemoveLastGuardian},
+				{"concurrent removal", testConcurrentGuardianRemoval},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					tt.run(t, b.households(t))
+				})
+			}
+		})
+	}
+}
+
+func testCreateAndFindUser(t *testing.T, users domain.UserRepository) {
+	ctx := context.Background()
+	user := newUser("ada")
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.ID == "" || user.Version != 1 {
+		t.Fatalf("created user has ID %q and version %d, want an ID and version 1", user.ID, user.Version)
+	}
+
+	lookups := map[string]func() (*domain.User, error){
+		"id":       func() (*domain.User, error) { return users.FindUserByID(ctx, user.ID) },
+		"email":    func() (*domain.User, error) { return users.FindUserByEmail(ctx, user.Email) },
+		"username": func() (*domain.User, error) { return users.FindUserByUsername(ctx, user.Username) },
+	}
+	for by, find := range lookups {
+		got, err := find()
+		if err != nil {
+			t.Fatalf("find by %s: %v", by, err)
+		}
+		if got.ID != user.ID || got.Username != user.Username || got.Email != user.Email ||
+			got.Password != user.Password || got.RetentionDays != user.RetentionDays || got.Version != 1 {
+			t.Errorf("find by %s = %+v, want %+v", by, got, user)
+		}
+	}
+}
+
+func testDuplicateUser(t *testing.T, users domain.UserRepository) {
+	ctx := context.Background()
+	if err := users.CreateUser(ctx, newUser("ada")); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	other := newUser("grace")
+	if err := users.CreateUser(ctx, other); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	sameEmail := newUser("ada2")
+	sameEmail.Email = "ada@example.com"
+	if err := users.CreateUser(ctx, sameEmail); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Errorf("duplicate email: got %v, want %v", err, domain.ErrEmailTaken)
+	}
+	sameUsername := newUser("ada")
+	sameUsername.Email = "someone@example.com"
+	if err := users.CreateUser(ctx, sameUsername); !errors.Is(err, domain.ErrUsernameTaken) {
+		t.Errorf("duplicate username: got %v, want %v", err, domain.ErrUsernameTaken)
+	}
+	if _, err := users.UpdateUsername(ctx, other.ID, "ada", 0); !errors.Is(err, domain.ErrUsernameTaken) {
+		t.Errorf("rename to a taken username: got %v, want %v", err, domain.ErrUsernameTaken)
+	}
+}
+
+func testUpdateUser(t *testing.T, users domain.UserRepository) {
+	ctx := context.Background()
+	user := newUser("ada")
+	if err := users.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if version, err := users.UpdateUsername(ctx, user.ID, "lovelace", 1); err != nil || version != 2 {
+		t.Fatalf("UpdateUsername = %d, %v, want version 2", version, err)
+	}
+	if version, err := users.UpdatePassword(ctx, user.ID, "new-hash", 0); err != nil || version != 3 {
+		t.Fatalf("UpdatePassword = %d, %v, want version 3", version, err)
+	}
+	if version, err := users.UpdateRetention(ctx, user.ID, 30, 3); err != nil || version != 4 {
+		t.Fatalf("UpdateRetention = %d, %v, want version 4", version, err)
+	}
+	if _, err := users.UpdateRetention(ctx, user.ID, 60, 3); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("stale version: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+
+	got, err := users.FindUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindUserByID: %v", err)
+	}
+	if got.Username != "lovelace" || got.Password != "new-hash" || got.RetentionDays != 30 || got.Version != 4 {
+		t.Errorf("after updates got %+v, want username lovelace, password new-hash, retention 30, version 4", got)
+	}
+	if _, err := users.FindUserByUsername(ctx, "ada"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("old username still resolves: %v", err)
+	}
+}
+
+func testUserNotFound(t *testing.T, users domain.UserRepository) {
+	ctx := context.Background()
+	if _, err := users.FindUserByID(ctx, missingID); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("FindUserByID: got %v, want %v", err, domain.ErrUserNotFound)
+	}
+	if _, err := users.FindUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("FindUserByEmail: got %v, want %v", err, domain.ErrUserNotFound)
+	}
+	if _, err := users.FindUserByUsername(ctx, "nobody"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("FindUserByUsername: got %v, want %v", err, domain.ErrUserNotFound)
+	}
+	if _, err := users.UpdateUsername(ctx, missingID, "nobody", 0); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("UpdateUsername: got %v, want %v", err, domain.ErrUserNotFound)
+	}
+	if _, err := users.UpdateRetention(ctx, missingID, 30, 1); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("versioned UpdateRetention: got %v, want %v", err, domain.ErrUserNotFound)
+	}
+}
+
+func testCreateAndGetFlight(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+	if flight.ID == "" || flight.Version != 1 {
+		t.Fatalf("created flight has ID %q and version %d, want an ID and version 1", flight.ID, flight.Version)
+	}
+
+	got, err := flights.GetFlightByID(ctx, "owner", flight.ID)
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	assertSameFlight(t, got, flight)
+}
+
+func testUpdateFlight(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+
+	update := *flight
+	update.Title = "Return trip"
+	update.QA = append([]domain.QA(nil), flight.QA...)
+	update.QA[0].Answer = "P7654321"
+	if err := flights.UpdateFlight(ctx, &update); err != nil {
+		t.Fatalf("UpdateFlight: %v", err)
+	}
+	if update.Version != 2 {
+		t.Errorf("updated version = %d, want 2", update.Version)
+	}
+
+	got, err := flights.GetFlightByID(ctx, "owner", flight.ID)
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	assertSameFlight(t, got, &update)
+
+	stale := *flight
+	if err := flights.UpdateFlight(ctx, &stale); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("stale update: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+}
+
+func testDeleteFlight(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+
+	if err := flights.DeleteFlight(ctx, "owner", flight.ID, 2); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("stale delete: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+	if err := flights.DeleteFlight(ctx, "owner", flight.ID, 1); err != nil {
+		t.Fatalf("DeleteFlight: %v", err)
+	}
+	if _, err := flights.GetFlightByID(ctx, "owner", flight.ID); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("get after delete: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+	if err := flights.DeleteFlight(ctx, "owner", flight.ID, 0); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("second delete: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+}
+
+func testFlightOwnership(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	mine, theirs := newFlight("owner"), newFlight("stranger")
+	for _, flight := range []*domain.Flight{mine, theirs} {
+		if err := flights.CreateFlight(ctx, flight); err != nil {
+			t.Fatalf("CreateFlight: %v", err)
+		}
+	}
+
+	listed, err := flights.GetFlightsByUserID(ctx, "owner")
+	if err != nil {
+		t.Fatalf("GetFlightsByUserID: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != mine.ID {
+		t.Errorf("owner lists %d flights, want only their own", len(listed))
+	}
+
+	if _, err := flights.GetFlightByID(ctx, "stranger", mine.ID); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("get another user's flight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+	hijack := *mine
+	hijack.UserID = "stranger"
+	if err := flights.UpdateFlight(ctx, &hijack); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("update another user's flight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+	if err := flights.DeleteFlight(ctx, "stranger", mine.ID, 0); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("delete another user's flight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+
+	got, err := flights.GetFlightByID(ctx, "owner", mine.ID)
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	assertSameFlight(t, got, mine)
+}
+
+func testFlightNotFound(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	if _, err := flights.GetFlightByID(ctx, "owner", missingID); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("GetFlightByID: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+	missing := newFlight("owner")
+	missing.ID = missingID
+	if err := flights.UpdateFlight(ctx, missing); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("UpdateFlight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+	if err := flights.DeleteFlight(ctx, "owner", missingID, 0); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("DeleteFlight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+	listed, err := flights.GetFlightsByUserID(ctx, "nobody")
+	if err != nil || len(listed) != 0 {
+		t.Errorf("GetFlightsByUserID for a user with no flights = %d flights, %v; want none", len(listed), err)
+	}
+}
+
+func testFlightClientRef(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	flight.ClientRef = "device-1/42"
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+	retry := newFlight("owner")
+	retry.ClientRef = flight.ClientRef
+	if err := flights.CreateFlight(ctx, retry); !errors.Is(err, domain.ErrClientRefExists) {
+		t.Errorf("retried create: got %v, want %v", err, domain.ErrClientRefExists)
+	}
+	other := newFlight("stranger")
+	other.ClientRef = flight.ClientRef
+	if err := flights.CreateFlight(ctx, other); err != nil {
+		t.Errorf("another user's create with the same ref: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := flights.CreateFlight(ctx, newFlight("owner")); err != nil {
+			t.Fatalf("CreateFlight without a ref: %v", err)
+		}
+	}
+
+	// Updates never carry the reference, and must not drop it
+	update := *flight
+	update.ClientRef = ""
+	update.Title = "Return trip"
+	if err := flights.UpdateFlight(ctx, &update); err != nil {
+		t.Fatalf("UpdateFlight: %v", err)
+	}
+	got, err := flights.GetFlightByClientRef(ctx, "owner", flight.ClientRef)
+	if err != nil {
+		t.Fatalf("GetFlightByClientRef: %v", err)
+	}
+	update.ClientRef = flight.ClientRef
+	assertSameFlight(t, got, &update)
+
+	if _, err := flights.GetFlightByClientRef(ctx, "owner", "device-1/43"); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("unknown ref: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+}
+
+func testFlightTombstones(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+	if err := flights.DeleteFlight(ctx, "owner", flight.ID, 0); err != nil {
+		t.Fatalf("DeleteFlight: %v", err)
+	}
+	tombstones := func() int {
+		t.Helper()
+		changes, err := flights.GetFlightChanges(ctx, "owner", domain.ChangeCursor{}, 10)
+		if err != nil {
+			t.Fatalf("GetFlightChanges: %v", err)
+		}
+		n := 0
+		for _, change := range changes {
+			if change.Deleted && change.FlightID == flight.ID && change.Version == 2 {
+				n++
+			}
+		}
+		return n
+	}
+	if n := tombstones(); n != 1 {
+		t.Fatalf("feed holds %d tombstones for the deleted flight, want 1", n)
+	}
+
+	if pruned, err := flights.PruneTombstones(ctx, time.Now().Add(-time.Hour)); err != nil || pruned != 0 {
+		t.Errorf("PruneTombstones before the delete = %d, %v; want 0", pruned, err)
+	}
+	if pruned, err := flights.PruneTombstones(ctx, time.Now().Add(time.Minute)); err != nil || pruned != 1 {
+		t.Errorf("PruneTombstones after the delete = %d, %v; want 1", pruned, err)
+	}
+	if n := tombstones(); n != 0 {
+		t.Errorf("feed still holds %d tombstones after pruning", n)
+	}
+
+	// A delete that loses to a concurrent edit must not leave a tombstone for a flight that still exists, and
+	// one that wins must leave exactly one
+	for i := 0; i < 10; i++ {
+		flight := newFlight("owner")
+		if err := flights.CreateFlight(ctx, flight); err != nil {
+			t.Fatalf("CreateFlight: %v", err)
+		}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			update := *flight
+			update.Title = "Edited"
+			flights.UpdateFlight(ctx, &update)
+		}()
+		var deleteErr error
+		go func() {
+			defer wg.Done()
+			deleteErr = flights.DeleteFlight(ctx, "owner", flight.ID, 0)
+		}()
+		wg.Wait()
+		if deleteErr != nil && !errors.Is(deleteErr, domain.ErrVersionConflict) {
+			t.Fatalf("DeleteFlight: %v", deleteErr)
+		}
+
+		_, err := flights.GetFlightByID(ctx, "owner", flight.ID)
+		exists := err == nil
+		changes, err := flights.GetFlightChanges(ctx, "owner", domain.ChangeCursor{}, 100)
+		if err != nil {
+			t.Fatalf("GetFlightChanges: %v", err)
+		}
+		n := 0
+		for _, change := range changes {
+			if change.Deleted && change.FlightID == flight.ID {
+				n++
+			}
+		}
+		if exists == (n != 0) || n > 1 || exists != (deleteErr != nil) {
+			t.Fatalf("after a racing edit and delete (%v) the flight exists: %v with %d tombstones", deleteErr, exists, n)
+		}
+	}
+}
+
+func testFlightExpiry(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+	expiresAt := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	warnedAt := expiresAt.AddDate(0, 0, -7)
+	if err := flights.SetFlightExpiry(ctx, flight.ID, &expiresAt, &warnedAt); err != nil {
+		t.Fatalf("SetFlightExpiry: %v", err)
+	}
+
+	got, err := flights.GetFlightByID(ctx, "owner", flight.ID)
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) || got.ExpiryWarnedAt == nil || !got.ExpiryWarnedAt.Equal(warnedAt) {
+		t.Errorf("flight has expiry %v warned %v, want %v warned %v", got.ExpiresAt, got.ExpiryWarnedAt, expiresAt, warnedAt)
+	}
+	if got.Version != flight.Version || !got.UpdatedAt.Equal(flight.UpdatedAt) {
+		t.Errorf("setting the expiry moved the flight to version %d at %v, want version %d at %v",
+			got.Version, got.UpdatedAt, flight.Version, flight.UpdatedAt)
+	}
+
+	// The owner's copy is still current, so an edit based on it goes through
+	update := *got
+	update.Title = "Renamed"
+	if err := flights.UpdateFlight(ctx, &update); err != nil {
+		t.Errorf("UpdateFlight after SetFlightExpiry: %v", err)
+	}
+	if err := flights.SetFlightExpiry(ctx, missingID, &expiresAt, nil); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("SetFlightExpiry on a missing flight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+}
+
+func testResealFlight(t *testing.T, flights domain.FlightRepository) {
+	ctx := context.Background()
+	flight := newFlight("owner")
+	if err := flights.CreateFlight(ctx, flight); err != nil {
+		t.Fatalf("CreateFlight: %v", err)
+	}
+
+	resealed := *flight
+	resealed.QA = append([]domain.QA(nil), flight.QA...)
+	resealed.QA[0].Answer = "sealed:P1234567"
+	if err := flights.ResealFlight(ctx, &resealed); err != nil {
+		t.Fatalf("ResealFlight: %v", err)
+	}
+	got, err := flights.GetFlightByID(ctx, "owner", flight.ID)
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	assertSameFlight(t, got, &resealed)
+	if got.Version != flight.Version || !got.UpdatedAt.Equal(flight.UpdatedAt) {
+		t.Errorf("resealing moved the flight to version %d at %v, want version %d at %v",
+			got.Version, got.UpdatedAt, flight.Version, flight.UpdatedAt)
+	}
+
+	update := *got
+	update.Title = "Return trip"
+	if err := flights.UpdateFlight(ctx, &update); err != nil {
+		t.Fatalf("UpdateFlight: %v", err)
+	}
+	if err := flights.ResealFlight(ctx, &resealed); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("reseal after an edit: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+	missing := *flight
+	missing.ID = missingID
+	if err := flights.ResealFlight(ctx, &missing); !errors.Is(err, domain.ErrFlightNotFound) {
+		t.Errorf("ResealFlight on a missing flight: got %v, want %v", err, domain.ErrFlightNotFound)
+	}
+}
+
+func testTripVersions(t *testing.T, trips domain.TripRepository) {
+	ctx := context.Background()
+	trip := newTrip("owner")
+	if err := trips.CreateTrip(ctx, trip); err != nil {
+		t.Fatalf("CreateTrip: %v", err)
+	}
+	if trip.ID == "" || trip.Version != 1 {
+		t.Fatalf("created trip has ID %q and version %d, want an ID and version 1", trip.ID, trip.Version)
+	}
+
+	update := *trip
+	update.Title = "Lisbon and back"
+	if err := trips.UpdateTrip(ctx, &update); err != nil {
+		t.Fatalf("UpdateTrip: %v", err)
+	}
+	if update.Version != 2 {
+		t.Errorf("updated version = %d, want 2", update.Version)
+	}
+	stale := *trip
+	if err := trips.UpdateTrip(ctx, &stale); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("stale update: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+
+	if err := trips.DeleteTrip(ctx, "owner", trip.ID, 1); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("stale delete: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+	got, err := trips.GetTripByID(ctx, "owner", trip.ID)
+	if err != nil {
+		t.Fatalf("GetTripByID after a stale delete: %v", err)
+	}
+	if got.Title != update.Title || got.Version != 2 || len(got.Legs) != len(trip.Legs) {
+		t.Errorf("trip after a stale delete = %+v, want the update intact", got)
+	}
+	if err := trips.DeleteTrip(ctx, "stranger", trip.ID, 0); !errors.Is(err, domain.ErrTripNotFound) {
+		t.Errorf("delete another user's trip: got %v, want %v", err, domain.ErrTripNotFound)
+	}
+	if err := trips.DeleteTrip(ctx, "owner", trip.ID, 2); err != nil {
+		t.Fatalf("DeleteTrip: %v", err)
+	}
+	if _, err := trips.GetTripByID(ctx, "owner", trip.ID); !errors.Is(err, domain.ErrTripNotFound) {
+		t.Errorf("get after delete: got %v, want %v", err, domain.ErrTripNotFound)
+	}
+}
+
+func testTripExpiry(t *testing.T, trips domain.TripRepository) {
+	ctx := context.Background()
+	soon, later := newTrip("owner"), newTrip("owner")
+	for _, trip := range []*domain.Trip{soon, later} {
+		if err := trips.CreateTrip(ctx, trip); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+	}
+	expiresAt := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	warnedAt := expiresAt.AddDate(0, 0, -7)
+	if err := trips.SetTripExpiry(ctx, soon.ID, &expiresAt, &warnedAt); err != nil {
+		t.Fatalf("SetTripExpiry: %v", err)
+	}
+	farOff := expiresAt.AddDate(1, 0, 0)
+	if err := trips.SetTripExpiry(ctx, later.ID, &farOff, nil); err != nil {
+		t.Fatalf("SetTripExpiry: %v", err)
+	}
+
+	expiring, err := trips.FindExpiringTrips(ctx, expiresAt)
+	if err != nil {
+		t.Fatalf("FindExpiringTrips: %v", err)
+	}
+	if len(expiring) != 1 || expiring[0].ID != soon.ID {
+		t.Fatalf("FindExpiringTrips returned %d trips, want only the one expiring first", len(expiring))
+	}
+	got := expiring[0]
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) || got.ExpiryWarnedAt == nil || !got.ExpiryWarnedAt.Equal(warnedAt) {
+		t.Errorf("expiring trip has expiry %v warned %v, want %v warned %v", got.ExpiresAt, got.ExpiryWarnedAt, expiresAt, warnedAt)
+	}
+	if got.Version != soon.Version || len(got.Legs) != len(soon.Legs) {
+		t.Errorf("expiring trip has version %d and %d legs, want version %d and %d legs", got.Version, len(got.Legs), soon.Version, len(soon.Legs))
+	}
+
+	if err := trips.SetTripExpiry(ctx, soon.ID, nil, nil); err != nil {
+		t.Fatalf("clear expiry: %v", err)
+	}
+	if expiring, err := trips.FindExpiringTrips(ctx, farOff); err != nil || len(expiring) != 1 || expiring[0].ID != later.ID {
+		t.Errorf("after clearing an expiry FindExpiringTrips = %d trips, %v; want only the other trip", len(expiring), err)
+	}
+	if err := trips.SetTripExpiry(ctx, missingID, &expiresAt, nil); !errors.Is(err, domain.ErrTripNotFound) {
+		t.Errorf("SetTripExpiry on a missing trip: got %v, want %v", err, domain.ErrTripNotFound)
+	}
+}
+
+func testResealTrip(t *testing.T, trips domain.TripRepository) {
+	ctx := context.Background()
+	trip := newTrip("owner")
+	if err := trips.CreateTrip(ctx, trip); err != nil {
+		t.Fatalf("CreateTrip: %v", err)
+	}
+
+	resealed := *trip
+	resealed.Legs = append([]domain.Leg(nil), trip.Legs...)
+	resealed.Legs[1].QA = []domain.QA{{QuestionID: "passport_number", Question: "Passport number?", Answer: "sealed:P1234567"}}
+	if err := trips.ResealTrip(ctx, &resealed); err != nil {
+		t.Fatalf("ResealTrip: %v", err)
+	}
+	got, err := trips.GetTripByID(ctx, "owner", trip.ID)
+	if err != nil {
+		t.Fatalf("GetTripByID: %v", err)
+	}
+	if got.Version != trip.Version {
+		t.Errorf("resealing moved the trip to version %d, want %d", got.Version, trip.Version)
+	}
+	if len(got.Legs) != 2 || len(got.Legs[1].QA) != 1 || got.Legs[1].QA[0].Answer != "sealed:P1234567" || got.Legs[0].QA[0].Answer != "P1234567" {
+		t.Errorf("resealed trip has legs %+v", got.Legs)
+	}
+
+	update := *got
+	update.Title = "Lisbon direct"
+	if err := trips.UpdateTrip(ctx, &update); err != nil {
+		t.Fatalf("UpdateTrip: %v", err)
+	}
+	if err := trips.ResealTrip(ctx, &resealed); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Errorf("reseal after an edit: got %v, want %v", err, domain.ErrVersionConflict)
+	}
+}
+
+func testTripNotFound(t *testing.T, trips domain.TripRepository) {
+	ctx := context.Background()
+	if _, err := trips.GetTripByID(ctx, "owner", missingID); !errors.Is(err, domain.ErrTripNotFound) {
+		t.Errorf("GetTripByID: got %v, want %v", err, domain.ErrTripNotFound)
+	}
+	missing := newTrip("owner")
+	missing.ID = missingID
+	if err := trips.UpdateTrip(ctx, missing); !errors.Is(err, domain.ErrTripNotFound) {
+		t.Errorf("UpdateTrip: got %v, want %v", err, domain.ErrTripNotFound)
+	}
+	if err := trips.DeleteTrip(ctx, "owner", missingID, 0); !errors.Is(err, domain.ErrTripNotFound) {
+		t.Errorf("DeleteTrip: got %v, want %v", err, domain.ErrTripNotFound)
+	}
+}
+
+// testRemoveLastGuardian keeps a dependent's last guardian in place until the dependent is gone, then drops
+// the household with its last member
+func testRemoveLastGuardian(t *testing.T, households domain.HouseholdRepository) {
+	ctx := context.Background()
+	household := newHousehold("guardian", "dependent")
+	household.Members = append(household.Members,
+		domain.HouseholdMember{UserID: "invited", Name: "invited", Role: domain.RoleGuardian, Status: domain.MemberInvited, AddedAt: household.CreatedAt})
+	if err := households.CreateHousehold(ctx, household); err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+
+	// An invitation does not count as a guardian
+	if err := households.RemoveHouseholdMember(ctx, household.ID, "guardian"); !errors.Is(err, domain.ErrLastGuardian) {
+		t.Fatalf("removing the last guardian: got %v, want %v", err, domain.ErrLastGuardian)
+	}
+	if err := households.RemoveHouseholdMember(ctx, household.ID, "stranger"); !errors.Is(err, domain.ErrMemberNotFound) {
+		t.Errorf("removing a non-member: got %v, want %v", err, domain.ErrMemberNotFound)
+	}
+	if err := households.RemoveHouseholdMember(ctx, missingID, "guardian"); !errors.Is(err, domain.ErrHouseholdNotFound) {
+		t.Errorf("removing from a missing household: got %v, want %v", err, domain.ErrHouseholdNotFound)
+	}
+
+	for _, userID := range []string{"dependent", "invited"} {
+		if err := households.RemoveHouseholdMember(ctx, household.ID, userID); err != nil {
+			t.Fatalf("RemoveHouseholdMember(%s): %v", userID, err)
+		}
+	}
+	got, err := households.GetHouseholdByID(ctx, household.ID)
+	if err != nil || len(got.Members) != 1 || !got.HasRole("guardian", domain.RoleGuardian) {
+		t.Fatalf("household after removing the others = %+v, %v; want only the guardian", got, err)
+	}
+	if err := households.RemoveHouseholdMember(ctx, household.ID, "guardian"); err != nil {
+		t.Fatalf("last member leaving: %v", err)
+	}
+	if _, err := households.GetHouseholdByID(ctx, household.ID); !errors.Is(err, domain.ErrHouseholdNotFound) {
+		t.Errorf("household after its last member left: got %v, want %v", err, domain.ErrHouseholdNotFound)
+	}
+}
+
+// testConcurrentGuardianRemoval lets two guardians leave at once; only one may go
+func testConcurrentGuardianRemoval(t *testing.T, households domain.HouseholdRepository) {
+	ctx := context.Background()
+	household := newHousehold("first", "dependent")
+	household.Members = append(household.Members,
+		domain.HouseholdMember{UserID: "second", Name: "second", Role: domain.RoleGuardian, Status: domain.MemberActive, AddedAt: household.CreatedAt})
+	if err := households.CreateHousehold(ctx, household); err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+
+	errs := make(chan error, 2)
+	for _, userID := range []string{"first", "second"} {
+		go func() { errs <- households.RemoveHouseholdMember(ctx, household.ID, userID) }()
+	}
+	removed, blocked := 0, 0
+	for range 2 {
+		switch err := <-errs; {
+		case err == nil:
+			removed++
+		case errors.Is(err, domain.ErrLastGuardian):
+			blocked++
+		default:
+			t.Errorf("RemoveHouseholdMember: %v", err)
+		}
+	}
+	if removed != 1 || blocked != 1 {
+		t.Errorf("%d guardians left and %d were kept, want one of each", removed, blocked)
+	}
+	got, err := households.GetHouseholdByID(ctx, household.ID)
+	if err != nil {
+		t.Fatalf("GetHouseholdByID: %v", err)
+	}
+	if !got.HasRole("first", domain.RoleGuardian) && !got.HasRole("second", domain.RoleGuardian) {
+		t.Errorf("the dependent was left without a guardian: %+v", got.Members)
+	}
+}
+
+func newUser(name string) *domain.User {
+	return &domain.User{
+		Username:      name,
+		Email:         name + "@example.com",
+		Password:      "hash-of-" + name,
+		RetentionDays: 7,
+	}
+}
+
+func newFlight(userID string) *domain.Flight {
+	return &domain.Flight{
+		Title:       "Lisbon",
+		FromCountry: "US",
+		ToCountry:   "PT",
+		Date:        time.Date(2026, 12, 1, 10, 0, 0, 0, time.UTC),
+		UserID:      userID,
+		Language:    "pt",
+		QA: []domain.QA{
+			{QuestionID: "passport_number", Question: "Passport number?", Answer: "P1234567"},
+			{Question: "Purpose of visit?", Answer: "Tourism"},
+		},
+	}
+}
+
+func newTrip(userID string) *domain.Trip {
+	qa := []domain.QA{{QuestionID: "passport_number", Question: "Passport number?", Answer: "P1234567"}}
+	return &domain.Trip{
+		Title:  "Lisbon via Madrid",
+		UserID: userID,
+		Legs: []domain.Leg{
+			{FromCountry: "US", ToCountry: "ES", Date: time.Date(2026, 12, 1, 10, 0, 0, 0, time.UTC), Language: "es", QA: qa},
+			{FromCountry: "ES", ToCountry: "PT", Date: time.Date(2026, 12, 2, 9, 0, 0, 0, time.UTC), Language: "pt", QA: qa},
+		},
+	}
+}
+
+func newHousehold(guardianID, dependentID string) *domain.Household {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &domain.Household{
+		Name:      "Family",
+		CreatedAt: now,
+		Members: []domain.HouseholdMember{
+			{UserID: guardianID, Name: guardianID, Role: domain.RoleGuardian, Status: domain.MemberActive, AddedAt: now},
+			{UserID: dependentID, Name: dependentID, Role: domain.RoleDependent, Status: domain.MemberActive, AddedAt: now},
+		},
+	}
+}
+
+// assertSameFlight compares the fields a client writes; versions and timestamps are checked separately
+func assertSameFlight(t *testing.T, got, want *domain.Flight) {
+	t.Helper()
+	if got.ID != want.ID || got.UserID != want.UserID || got.Title != want.Title ||
+		got.FromCountry != want.FromCountry || got.ToCountry != want.ToCountry ||
+		got.Language != want.Language || !got.Date.Equal(want.Date) || got.Version != want.Version {
+		t.Errorf("got flight %+v, want %+v", got, want)
+	}
+	if len(got.QA) != len(want.QA) {
+		t.Fatalf("got %d QA pairs, want %d", len(got.QA), len(want.QA))
+	}
+	for i := range want.QA {
+		if got.QA[i] != want.QA[i] {
+			t.Errorf("QA[%d] = %+v, want %+v", i, got.QA[i], want.QA[i])
+		}
+	}
+}
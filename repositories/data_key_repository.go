@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// dataKeyRepository is the MongoDB implementation of the DataKeyRepository interface
+type dataKeyRepository struct {
+	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+// NewDataKeyRepository initializes a data key repository on the data_keys collection
+func NewDataKeyRepository(db *mongo.Database, timeouts Timeouts) domain.DataKeyRepository {
+	return &dataKeyRepository{
+		collection: db.Collection("data_keys"),
+		timeouts:   timeouts,
+	}
+}
+
+// GetDataKeys returns every version of the user's data key, oldest first
+func (r *dataKeyRepository) GetDataKeys(ctx context.Context, userID string) ([]domain.DataKey, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+// CreateDataKey stores a new data key version; the unique index rejects concurrent duplicates
+func (r *dataKeyRepository) CreateDataKey(ctx context.Context, key *domain.DataKey) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if _, err := r.collection.InsertOne(ctx, key); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrDataKeyExists
+		}
+		return fmt.Errorf("error creating data key: %w", err)
+	}
+	return nil
+}
+
+// UpdateDataKey stores a re-wrapped data key
+func (r *dataKeyRepository) UpdateDataKey(ctx context.Context, key *domain.DataKey) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"user_id": key.UserID, "version": key.Version},
+		bson.M{"$set": bson.M{"master_key_id": key.MasterKeyID, "wrapped_key": key.WrappedKey}},
+	)
+	if err != nil {
+		return fmt.Errorf("error updating data key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrDataKeyNotFound
+	}
+	return nil
+}
+
+// ListDataKeys returns every stored data key
+func (r *dataKeyRepository) ListDataKeys(ctx context.Context) ([]domain.DataKey, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *dataKeyRepository) find(ctx context.Context, filter bson.M) ([]domain.DataKey, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding data keys: %w", err)
+	}
+	var keys []domain.DataKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("error finding data keys: %w", err)
+	}
+	return keys, nil
+}
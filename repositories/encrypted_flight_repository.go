@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// encryptedFlightRepository encrypts QA answers before they reach another FlightRepository and decrypts them on the way back
+type encryptedFlightRepository struct {
+	next   domain.FlightRepository
+	cipher domain.FieldCipher
+}
+
+// NewEncryptedFlightRepository wraps repo so QA answers are only ever stored as ciphertext.
+// Answers written before encryption was enabled are read back unchanged and encrypted on their next update.
+func NewEncryptedFlightRepository(repo domain.FlightRepository, cipher domain.FieldCipher) domain.FlightRepository {
+	return &encryptedFlightRepository{next: repo, cipher: cipher}
+}
+
+func (r *encryptedFlightRepository) CreateFlight(ctx context.Context, flight *domain.Flight) error {
+	return r.write(ctx, flight, r.next.CreateFlight)
+}
+
+func (r *encryptedFlightRepository) UpdateFlight(ctx context.Context, flight *domain.Flight) error {
+	return r.write(ctx, flight, r.next.UpdateFlight)
+}
+
+func (r *encryptedFlightRepository) ResealFlight(ctx context.Context, flight *domain.Flight) error {
+	return r.write(ctx, flight, r.next.ResealFlight)
+}
+
+func (r *encryptedFlightRepository) GetFlightByID(ctx context.Context, userID, id string) (*domain.Flight, error) {
+	flight, err := r.next.GetFlightByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decrypt(ctx, flight); err != nil {
+		return nil, err
+	}
+	return flight, nil
+}
+
+func (r *encryptedFlightRepository) DeleteFlight(ctx context.Context, userID, id string, version int64) error {
+	return r.next.DeleteFlight(ctx, userID, id, version)
+}
+
+func (r *encryptedFlightRepository) GetFlightsByUserID(ctx context.Context, userID string) ([]domain.Flight, error) {
+	flights, err := r.next.GetFlightsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return flights, r.decryptAll(ctx, flights)
+}
+
+func (r *encryptedFlightRepository) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) ([]domain.FlightChange, error) {
+	changes, err := r.next.GetFlightChanges(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.Flight == nil {
+			continue
+		}
+		if err := r.decrypt(ctx, change.Flight); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+func (r *encryptedFlightRepository) GetFlightByClientRef(ctx context.Context, userID, clientRef string) (*domain.Flight, error) {
+	flight, err := r.next.GetFlightByClientRef(ctx, userID, clientRef)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decrypt(ctx, flight); err != nil {
+		return nil, err
+	}
+	return flight, nil
+}
+
+func (r *encryptedFlightRepository) PruneTombstones(ctx context.Context, before time.Time) (int, error) {
+	return r.next.PruneTombstones(ctx, before)
+}
+
+func (r *encryptedFlightRepository) ListFlights(ctx context.Context, afterID string, limit int) ([]domain.Flight, error) {
+	flights, err := r.next.ListFlights(ctx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return flights, r.decryptAll(ctx, flights)
+}
+
+func (r *encryptedFlightRepository) SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	return r.next.SetFlightExpiry(ctx, id, expiresAt, warnedAt)
+}
+
+func (r *encryptedFlightRepository) FindExpiringFlights(ctx context.Context, before time.Time) ([]domain.Flight, error) {
+	flights, err := r.next.FindExpiringFlights(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	return flights, r.decryptAll(ctx, flights)
+}
+
+// write stores an encrypted copy so the caller's flight keeps its plaintext answers but picks up generated fields
+func (r *encryptedFlightRepository) write(ctx context.Context, flight *domain.Flight, store func(context.Context, *domain.Flight) error) error {
+	stored := *flight
+	stored.QA = make([]domain.QA, len(flight.QA))
+	for i, qa := range flight.QA {
+		answer, err := r.cipher.Encrypt(ctx, flight.UserID, qa.Answer)
+		if err != nil {
+			return err
+		}
+		qa.Answer = answer
+		stored.QA[i] = qa
+	}
+	if err := store(ctx, &stored); err != nil {
+		return err
+	}
+	qa := flight.QA
+	*flight = stored
+	flight.QA = qa
+	return nil
+}
+
+func (r *encryptedFlightRepository) decrypt(ctx context.Context, flight *domain.Flight) error {
+	for i := range flight.QA {
+		answer, err := r.cipher.Decrypt(ctx, flight.UserID, flight.QA[i].Answer)
+		if err != nil {
+			return err
+		}
+		flight.QA[i].Answer = answer
+	}
+	return nil
+}
+
+func (r *encryptedFlightRepository) decryptAll(ctx context.Context, flights []domain.Flight) error {
+	for i := range flights {
+		if err := r.decrypt(ctx, &flights[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
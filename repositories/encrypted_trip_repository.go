@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// encryptedTripRepository encrypts the QA answers of every leg before they reach another TripRepository
+type encryptedTripRepository struct {
+	next   domain.TripRepository
+	cipher domain.FieldCipher
+}
+
+// NewEncryptedTripRepository wraps repo so leg answers are only ever stored as ciphertext
+func NewEncryptedTripRepository(repo domain.TripRepository, cipher domain.FieldCipher) domain.TripRepository {
+	return &encryptedTripRepository{next: repo, cipher: cipher}
+}
+
+func (r *encryptedTripRepository) CreateTrip(ctx context.Context, trip *domain.Trip) error {
+	return r.write(ctx, trip, r.next.CreateTrip)
+}
+
+func (r *encryptedTripRepository) UpdateTrip(ctx context.Context, trip *domain.Trip) error {
+	return r.write(ctx, trip, r.next.UpdateTrip)
+}
+
+func (r *encryptedTripRepository) ResealTrip(ctx context.Context, trip *domain.Trip) error {
+	return r.write(ctx, trip, r.next.ResealTrip)
+}
+
+func (r *encryptedTripRepository) GetTripByID(ctx context.Context, userID, id string) (*domain.Trip, error) {
+	trip, err := r.next.GetTripByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decrypt(ctx, trip); err != nil {
+		return nil, err
+	}
+	return trip, nil
+}
+
+func (r *encryptedTripRepository) GetTripsByUserID(ctx context.Context, userID string) ([]domain.Trip, error) {
+	trips, err := r.next.GetTripsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return trips, r.decryptAll(ctx, trips)
+}
+
+func (r *encryptedTripRepository) DeleteTrip(ctx context.Context, userID, id string, version int64) error {
+	return r.next.DeleteTrip(ctx, userID, id, version)
+}
+
+func (r *encryptedTripRepository) SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	return r.next.SetTripExpiry(ctx, id, expiresAt, warnedAt)
+}
+
+func (r *encryptedTripRepository) FindExpiringTrips(ctx context.Context, before time.Time) ([]domain.Trip, error) {
+	trips, err := r.next.FindExpiringTrips(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	return trips, r.decryptAll(ctx, trips)
+}
+
+func (r *encryptedTripRepository) ListTrips(ctx context.Context, afterID string, limit int) ([]domain.Trip, error) {
+	trips, err := r.next.ListTrips(ctx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return trips, r.decryptAll(ctx, trips)
+}
+
+// write stores an encrypted copy so the caller's trip keeps its plaintext answers but picks up generated fields
+func (r *encryptedTripRepository) write(ctx context.Context, trip *domain.Trip, store func(context.Context, *domain.Trip) error) error {
+	stored := *trip
+	stored.Legs = make([]domain.Leg, len(trip.Legs))
+	for i, leg := range trip.Legs {
+		sealed := make([]domain.QA, len(leg.QA))
+		for j, qa := range leg.QA {
+			answer, err := r.cipher.Encrypt(ctx, trip.UserID, qa.Answer)
+			if err != nil {
+				return err
+			}
+			qa.Answer = answer
+			sealed[j] = qa
+		}
+		leg.QA = sealed
+		stored.Legs[i] = leg
+	}
+	if err := store(ctx, &stored); err != nil {
+		return err
+	}
+	legs := trip.Legs
+	*trip = stored
+	for i := range legs {
+		legs[i].Date = stored.Legs[i].Date
+		legs[i].Language = stored.Legs[i].Language
+	}
+	trip.Legs = legs
+	return nil
+}
+
+func (r *encryptedTripRepository) decrypt(ctx context.Context, trip *domain.Trip) error {
+	for i := range trip.Legs {
+		for j := range trip.Legs[i].QA {
+			answer, err := r.cipher.Decrypt(ctx, trip.UserID, trip.Legs[i].QA[j].Answer)
+			if err != nil {
+				return err
+			}
+			trip.Legs[i].QA[j].Answer = answer
+		}
+	}
+	return nil
+}
+
+func (r *encryptedTripRepository) decryptAll(ctx context.Context, trips []domain.Trip) error {
+	for i := range trips {
+		if err := r.decrypt(ctx, &trips[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// encryptedUserRepository encrypts saved profile answers before they reach another UserRepository and decrypts them on the way back
+type encryptedUserRepository struct {
+	next   domain.UserRepository
+	cipher domain.FieldCipher
+}
+
+// NewEncryptedUserRepository wraps repo so profile answers are only ever stored as ciphertext, under the same
+// per-user data key as the user's flights. Account fields pass through unchanged.
+func NewEncryptedUserRepository(repo domain.UserRepository, cipher domain.FieldCipher) domain.UserRepository {
+	return &encryptedUserRepository{next: repo, cipher: cipher}
+}
+
+func (r *encryptedUserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *encryptedUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.next.FindUserByEmail(ctx, email)
+}
+
+func (r *encryptedUserRepository) FindUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.next.FindUserByUsername(ctx, username)
+}
+
+func (r *encryptedUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.next.FindUserByID(ctx, id)
+}
+
+func (r *encryptedUserRepository) UpdateUsername(ctx context.Context, id, newUsername string, version int64) (int64, error) {
+	return r.next.UpdateUsername(ctx, id, newUsername, version)
+}
+
+func (r *encryptedUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (int64, error) {
+	return r.next.UpdatePassword(ctx, id, hashedPassword, version)
+}
+
+func (r *encryptedUserRepository) UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (int64, error) {
+	return r.next.UpdateRetention(ctx, id, retentionDays, version)
+}
+
+func (r *encryptedUserRepository) UpdateCalendarToken(ctx context.Context, id, tokenHash string) error {
+	return r.next.UpdateCalendarToken(ctx, id, tokenHash)
+}
+
+func (r *encryptedUserRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (*domain.User, error) {
+	return r.next.FindUserByCalendarToken(ctx, tokenHash)
+}
+
+func (r *encryptedUserRepository) DeleteProfileAnswer(ctx context.Context, userID, questionID string) error {
+	return r.next.DeleteProfileAnswer(ctx, userID, questionID)
+}
+
+func (r *encryptedUserRepository) GetAnswerProfile(ctx context.Context, userID string) (*domain.AnswerProfile, error) {
+	profile, err := r.next.GetAnswerProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decrypt(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (r *encryptedUserRepository) SaveProfileAnswers(ctx context.Context, userID string, answers []domain.ProfileAnswer) error {
+	sealed := make([]domain.ProfileAnswer, len(answers))
+	for i, answer := range answers {
+		ciphertext, err := r.cipher.Encrypt(ctx, userID, answer.Answer)
+		if err != nil {
+			return err
+		}
+		answer.Answer = ciphertext
+		sealed[i] = answer
+	}
+	return r.next.SaveProfileAnswers(ctx, userID, sealed)
+}
+
+func (r *encryptedUserRepository) ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) ([]domain.AnswerProfile, error) {
+	profiles, err := r.next.ListAnswerProfiles(ctx, afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles {
+		if err := r.decrypt(ctx, &profiles[i]); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+func (r *encryptedUserRepository) decrypt(ctx context.Context, profile *domain.AnswerProfile) error {
+	for i := range profile.Answers {
+		answer, err := r.cipher.Decrypt(ctx, profile.UserID, profile.Answers[i].Answer)
+		if err != nil {
+			return err
+		}
+		profile.Answers[i].Answer = answer
+	}
+	return nil
+}
@@ -3,10 +3,13 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
 )
@@ -14,29 +17,49 @@ import (
 // flightRepository is the implementation of the FlightRepository interface
 type flightRepository struct {
 	collection *mongo.Collection
+	tombstones *mongo.Collection
+	timeouts   Timeouts
+}
+
+// tombstoneDocument marks a deleted flight in the change feed
+type tombstoneDocument struct {
+	FlightID  string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	Version   int64     `bson:"version"`
+	DeletedAt time.Time `bson:"deleted_at"`
 }
 
 // NewFlightRepository initializes a new flight repository
-func NewFlightRepository(db *mongo.Database) domain.FlightRepository {
+func NewFlightRepository(db *mongo.Database, timeouts Timeouts) domain.FlightRepository {
 	return &flightRepository{
 		collection: db.Collection("flights"),
+		tombstones: db.Collection("flight_tombstones"),
+		timeouts:   timeouts,
 	}
 }
 
 // CreateFlight stores a new flight into the MongoDB database
-func (r *flightRepository) CreateFlight(flight *domain.Flight) error {
+func (r *flightRepository) CreateFlight(ctx context.Context, flight *domain.Flight) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
 	flight.Date = flight.Date.UTC() // Ensure consistency
 
 	if flight.ID == "" {
-		flight.ID = primitive.NewObjectID().Hex()
+		flight.ID = newID()
 	}
 
 	// Make sure the 'Language' field is set (can be validated earlier in the controller if needed)
 	if flight.Language == "" {
 		flight.Language = "English" // Set a default language if not provided
 	}
+	flight.Version = 1
+	flight.UpdatedAt = writeTime()
 
-	result, err := r.collection.InsertOne(context.Background(), flight)
+	result, err := r.collection.InsertOne(ctx, flight)
+	if mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), flightClientRefIndex) {
+		return domain.ErrClientRefExists
+	}
 	if err != nil {
 		return err
 	}
@@ -48,41 +71,100 @@ func (r *flightRepository) CreateFlight(flight *domain.Flight) error {
 	return nil
 }
 
-// GetFlightByID retrieves a flight by its ID from MongoDB
-func (r *flightRepository) GetFlightByID(id string) (*domain.Flight, error) {
+// GetFlightByID retrieves one of the user's flights by its ID from MongoDB
+func (r *flightRepository) GetFlightByID(ctx context.Context, userID, id string) (*domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
 	var flight domain.Flight
-	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&flight)
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&flight)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("flight not found")
+			return nil, domain.ErrFlightNotFound
 		}
-		return nil, fmt.Errorf("error finding flight: %v", err)
+		return nil, fmt.Errorf("error finding flight: %w", err)
 	}
 	return &flight, nil
 }
 
-// DeleteFlight removes a flight from MongoDB by its ID
-func (r *flightRepository) DeleteFlight(id string) error {
-	result, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": id})
+// DeleteFlight records the tombstone of one of the user's flights first and then removes the flight, so a
+// failure in between can never lose a delete from the change feed. A tombstone left behind by a delete that
+// did not go through is taken back, and GetFlightChanges hides any whose flight still exists
+func (r *flightRepository) DeleteFlight(ctx context.Context, userID, id string, version int64) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	state, err := r.storedState(ctx, userID, id, version)
 	if err != nil {
 		return err
 	}
+	stored := state.Version
+	tombstone := tombstoneDocument{FlightID: id, UserID: userID, Version: stored + 1, DeletedAt: writeTime()}
+	// Replacing rather than inserting overwrites a tombstone left by an earlier attempt that failed midway
+	_, err = r.tombstones.ReplaceOne(ctx, bson.M{"_id": id}, tombstone, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error recording flight tombstone: %w", err)
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID, "version": stored})
+	if err != nil {
+		r.dropTombstone(ctx, tombstone)
+		return fmt.Errorf("error deleting flight: %w", err)
+	}
+	// A concurrent writer moved the version between the read and the delete
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("flight not found")
+		r.dropTombstone(ctx, tombstone)
+		return domain.ErrVersionConflict
 	}
 	return nil
 }
 
-// GetFlightsByUserID retrieves all flights for a specific user from MongoDB
-func (r *flightRepository) GetFlightsByUserID(userID string) ([]domain.Flight, error) {
-	cursor, err := r.collection.Find(context.Background(), bson.M{"user_id": userID})
+// dropTombstone takes back the tombstone of a delete that did not go through. It is kept when the flight is
+// gone after all, which means a concurrent delete of the same version won and shares the tombstone
+func (r *flightRepository) dropTombstone(ctx context.Context, tombstone tombstoneDocument) {
+	if count, err := r.collection.CountDocuments(ctx, bson.M{"_id": tombstone.FlightID}); err != nil || count == 0 {
+		return
+	}
+	r.tombstones.DeleteOne(ctx, bson.M{"_id": tombstone.FlightID, "version": tombstone.Version})
+}
+
+// flightState is the part of a stored flight that a write checks or carries over
+type flightState struct {
+	Version   int64  `bson:"version"`
+	ClientRef string `bson:"client_ref"`
+}
+
+// storedState reads the version and client reference of one of the user's flights and checks the version
+// against an expected non-zero version
+func (r *flightRepository) storedState(ctx context.Context, userID, id string, expected int64) (flightState, error) {
+	var doc flightState
+	opts := options.FindOne().SetProjection(bson.M{"version": 1, "client_ref": 1})
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}, opts).Decode(&doc)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return doc, domain.ErrFlightNotFound
+	case err != nil:
+		return doc, fmt.Errorf("error finding flight: %w", err)
+	case expected != 0 && expected != doc.Version:
+		return doc, domain.ErrVersionConflict
+	}
+	return doc, nil
+}
+
+// GetFlightsByUserID retrieves all flights for a specific user from MongoDB ordered by date
+func (r *flightRepository) GetFlightsByUserID(ctx context.Context, userID string) ([]domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	var flights []domain.Flight
-	for cursor.Next(context.Background()) {
+	for cursor.Next(ctx) {
 		var flight domain.Flight
 		if err := cursor.Decode(&flight); err != nil {
 			return nil, err
@@ -91,3 +173,237 @@ func (r *flightRepository) GetFlightsByUserID(userID string) ([]domain.Flight, e
 	}
 	return flights, nil
 }
+
+// UpdateFlight replaces a stored flight with the given one, matching on the version it read so a
+// concurrent write is not lost
+func (r *flightRepository) UpdateFlight(ctx context.Context, flight *domain.Flight) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	flight.Date = flight.Date.UTC()
+
+	state, err := r.storedState(ctx, flight.UserID, flight.ID, flight.Version)
+	if err != nil {
+		return err
+	}
+	stored := state.Version
+	updated := *flight
+	updated.Version = stored + 1
+	updated.UpdatedAt = writeTime()
+	updated.ClientRef = state.ClientRef
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": flight.ID, "user_id": flight.UserID, "version": stored}, updated)
+	if err != nil {
+		return fmt.Errorf("error updating flight: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrVersionConflict
+	}
+	flight.Version = updated.Version
+	flight.UpdatedAt = updated.UpdatedAt
+	flight.ClientRef = updated.ClientRef
+	return nil
+}
+
+// ResealFlight sets the QA pairs of a stored flight, matching on the version it read, and leaves the version
+// and updated_at alone
+func (r *flightRepository) ResealFlight(ctx context.Context, flight *domain.Flight) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	state, err := r.storedState(ctx, flight.UserID, flight.ID, flight.Version)
+	if err != nil {
+		return err
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": flight.ID, "user_id": flight.UserID, "version": state.Version},
+		bson.M{"$set": bson.M{"qa": flight.QA}})
+	if err != nil {
+		return fmt.Errorf("error resealing flight: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+// GetFlightChanges reads the user's flights and tombstones after the cursor and merges them into feed order
+func (r *flightRepository) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) ([]domain.FlightChange, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	since := after.UpdatedAt.UTC()
+	afterCursor := func(field string) bson.M {
+		return bson.M{"user_id": userID, "$or": bson.A{
+			bson.M{field: bson.M{"$gt": since}},
+			bson.M{field: since, "_id": bson.M{"$gt": after.FlightID}},
+		}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, afterCursor("updated_at"), opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding flight changes: %w", err)
+	}
+	var flights []domain.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, fmt.Errorf("error finding flight changes: %w", err)
+	}
+
+	opts = options.Find().SetSort(bson.D{{Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err = r.tombstones.Find(ctx, afterCursor("deleted_at"), opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding flight tombstones: %w", err)
+	}
+	var tombstones []tombstoneDocument
+	if err := cursor.All(ctx, &tombstones); err != nil {
+		return nil, fmt.Errorf("error finding flight tombstones: %w", err)
+	}
+
+	live, err := r.liveFlights(ctx, tombstones)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]domain.FlightChange, 0, len(flights)+len(tombstones))
+	for _, flight := range flights {
+		changes = append(changes, flightChange(flight))
+	}
+	for _, tombstone := range tombstones {
+		// The delete is still in progress or never went through
+		if live[tombstone.FlightID] {
+			continue
+		}
+		changes = append(changes, domain.FlightChange{
+			FlightID:  tombstone.FlightID,
+			Version:   tombstone.Version,
+			UpdatedAt: tombstone.DeletedAt.UTC(),
+			Deleted:   true,
+		})
+	}
+	return mergeChanges(changes, limit), nil
+}
+
+// liveFlights returns the IDs among the tombstoned flights that are still stored
+func (r *flightRepository) liveFlights(ctx context.Context, tombstones []tombstoneDocument) (map[string]bool, error) {
+	if len(tombstones) == 0 {
+		return nil, nil
+	}
+	ids := make(bson.A, len(tombstones))
+	for i, tombstone := range tombstones {
+		ids[i] = tombstone.FlightID
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding flight changes: %w", err)
+	}
+	var docs []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("error finding flight changes: %w", err)
+	}
+	live := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		live[doc.ID] = true
+	}
+	return live, nil
+}
+
+// GetFlightByClientRef retrieves the user's flight created with the given client reference from MongoDB
+func (r *flightRepository) GetFlightByClientRef(ctx context.Context, userID, clientRef string) (*domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var flight domain.Flight
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "client_ref": clientRef}).Decode(&flight)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrFlightNotFound
+		}
+		return nil, fmt.Errorf("error finding flight: %w", err)
+	}
+	return &flight, nil
+}
+
+// PruneTombstones deletes the tombstones of flights deleted before the given time
+func (r *flightRepository) PruneTombstones(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.tombstones.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before.UTC()}})
+	if err != nil {
+		return 0, fmt.Errorf("error pruning flight tombstones: %w", err)
+	}
+	return int(result.DeletedCount), nil
+}
+
+// ListFlights pages through every flight in MongoDB ordered by ID
+func (r *flightRepository) ListFlights(ctx context.Context, afterID string, limit int) ([]domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	filter := bson.M{}
+	if afterID != "" {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing flights: %w", err)
+	}
+
+	var flights []domain.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, fmt.Errorf("error listing flights: %w", err)
+	}
+	return flights, nil
+}
+
+// SetFlightExpiry sets or clears the expiry fields of a flight
+func (r *flightRepository) SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	set, unset := bson.M{}, bson.M{}
+	for field, value := range map[string]*time.Time{"expires_at": expiresAt, "expiry_warned_at": warnedAt} {
+		if value == nil {
+			unset[field] = ""
+		} else {
+			set[field] = value.UTC()
+		}
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("error updating flight expiry: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrFlightNotFound
+	}
+	return nil
+}
+
+// FindExpiringFlights returns flights whose expiry is at or before the given time, soonest first
+func (r *flightRepository) FindExpiringFlights(ctx context.Context, before time.Time) ([]domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"expires_at": bson.M{"$lte": before.UTC()}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expiring flights: %w", err)
+	}
+
+	var flights []domain.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, fmt.Errorf("error finding expiring flights: %w", err)
+	}
+	return flights, nil
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// householdRepository is the MongoDB implementation of the HouseholdRepository interface. Members are embedded
+// in the household document so membership changes are single-document updates
+type householdRepository struct {
+	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+// NewHouseholdRepository initializes a new household repository
+func NewHouseholdRepository(db *mongo.Database, timeouts Timeouts) domain.HouseholdRepository {
+	return &householdRepository{
+		collection: db.Collection("households"),
+		timeouts:   timeouts,
+	}
+}
+
+// CreateHousehold stores a new household with its initial members
+func (r *householdRepository) CreateHousehold(ctx context.Context, household *domain.Household) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if household.ID == "" {
+		household.ID = newID()
+	}
+	if household.Members == nil {
+		household.Members = []domain.HouseholdMember{}
+	}
+	if _, err := r.collection.InsertOne(ctx, household); err != nil {
+		return fmt.Errorf("error creating household: %w", err)
+	}
+	return nil
+}
+
+// GetHouseholdByID retrieves a household by its ID
+func (r *householdRepository) GetHouseholdByID(ctx context.Context, id string) (*domain.Household, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var household domain.Household
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&household)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrHouseholdNotFound
+		}
+		return nil, fmt.Errorf("error finding household: %w", err)
+	}
+	return &household, nil
+}
+
+func (r *householdRepository) GetHouseholdsByUserID(ctx context.Context, userID string) ([]domain.Household, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"members.user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding households: %w", err)
+	}
+
+	var households []domain.Household
+	if err := cursor.All(ctx, &households); err != nil {
+		return nil, fmt.Errorf("error finding households: %w", err)
+	}
+	return households, nil
+}
+
+func (r *householdRepository) DeleteHousehold(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("error deleting household: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrHouseholdNotFound
+	}
+	return nil
+}
+
+// AddHouseholdMember pushes the member only if the user is not already listed, so concurrent invitations of
+// the same user cannot both land
+func (r *householdRepository) AddHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) error {
+	return r.updateMember(ctx, householdID,
+		bson.M{"members.user_id": bson.M{"$ne": member.UserID}},
+		bson.M{"$push": bson.M{"members": member}},
+		domain.ErrMemberExists)
+}
+
+func (r *householdRepository) UpdateHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) error {
+	return r.updateMember(ctx, householdID,
+		bson.M{"members.user_id": member.UserID},
+		bson.M{"$set": bson.M{"members.$.role": member.Role, "members.$.status": member.Status}},
+		domain.ErrMemberNotFound)
+}
+
+// RemoveHouseholdMember pulls the member only while the same document still has another active guardian,
+// the member is not an active guardian, or no one else is listed. A household left empty is then deleted
+func (r *householdRepository) RemoveHouseholdMember(ctx context.Context, householdID, userID string) error {
+	otherGuardian := bson.M{"user_id": bson.M{"$ne": userID}, "role": domain.RoleGuardian, "status": domain.MemberActive}
+	memberGuardian := bson.M{"user_id": userID, "role": domain.RoleGuardian, "status": domain.MemberActive}
+
+	err := r.updateMember(ctx, householdID,
+		bson.M{"members.user_id": userID, "$or": bson.A{
+			bson.M{"members": bson.M{"$elemMatch": otherGuardian}},
+			bson.M{"members": bson.M{"$not": bson.M{"$elemMatch": memberGuardian}}},
+			bson.M{"members": bson.M{"$size": 1}},
+		}},
+		bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}},
+		errMemberNotRemoved)
+	if errors.Is(err, errMemberNotRemoved) {
+		return r.removalError(ctx, householdID, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": householdID, "members": bson.M{"$size": 0}}); err != nil {
+		return fmt.Errorf("error deleting household: %w", err)
+	}
+	return nil
+}
+
+// errMemberNotRemoved is reported by updateMember when the guarded pull matched nothing, before the cause is known
+var errMemberNotRemoved = errors.New("household member not removed")
+
+// removalError tells a missing member from the last active guardian after a guarded pull matched nothing
+func (r *householdRepository) removalError(ctx context.Context, householdID, userID string) error {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": householdID, "members.user_id": userID})
+	if err != nil {
+		return fmt.Errorf("error finding household: %w", err)
+	}
+	if count == 0 {
+		return domain.ErrMemberNotFound
+	}
+	return domain.ErrLastGuardian
+}
+
+// updateMember applies update to the household when it also matches memberFilter. When nothing matches it
+// reports ErrHouseholdNotFound if the household is missing and notMatched otherwise
+func (r *householdRepository) updateMember(ctx context.Context, householdID string, memberFilter, update bson.M, notMatched error) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	memberFilter["_id"] = householdID
+	result, err := r.collection.UpdateOne(ctx, memberFilter, update)
+	if err != nil {
+		return fmt.Errorf("error updating household: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": householdID})
+	if err != nil {
+		return fmt.Errorf("error finding household: %w", err)
+	}
+	if count == 0 {
+		return domain.ErrHouseholdNotFound
+	}
+	return notMatched
+}
@@ -0,0 +1,8 @@
+package repositories
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// newID returns a fresh identifier in the same hex ObjectID format on every backend
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	Infrastructure "github.com/shaloms4/Pass-Me-Core-Functionality/infrastructure"
+)
+
+// instrumentedFlightRepository records Prometheus metrics around another FlightRepository
+type instrumentedFlightRepository struct {
+	next    domain.FlightRepository
+	backend string
+}
+
+// NewInstrumentedFlightRepository wraps repo so every call is timed under the given backend label
+func NewInstrumentedFlightRepository(repo domain.FlightRepository, backend string) domain.FlightRepository {
+	return &instrumentedFlightRepository{next: repo, backend: backend}
+}
+
+func (r *instrumentedFlightRepository) CreateFlight(ctx context.Context, flight *domain.Flight) (err error) {
+	defer r.observe("CreateFlight", time.Now(), &err)
+	if err = r.next.CreateFlight(ctx, flight); err == nil {
+		Infrastructure.RecordFlightCreated(flight.ToCountry)
+	}
+	return err
+}
+
+func (r *instrumentedFlightRepository) GetFlightByID(ctx context.Context, userID, id string) (flight *domain.Flight, err error) {
+	defer r.observe("GetFlightByID", time.Now(), &err)
+	return r.next.GetFlightByID(ctx, userID, id)
+}
+
+func (r *instrumentedFlightRepository) DeleteFlight(ctx context.Context, userID, id string, version int64) (err error) {
+	defer r.observe("DeleteFlight", time.Now(), &err)
+	return r.next.DeleteFlight(ctx, userID, id, version)
+}
+
+func (r *instrumentedFlightRepository) GetFlightsByUserID(ctx context.Context, userID string) (flights []domain.Flight, err error) {
+	defer r.observe("GetFlightsByUserID", time.Now(), &err)
+	return r.next.GetFlightsByUserID(ctx, userID)
+}
+
+func (r *instrumentedFlightRepository) UpdateFlight(ctx context.Context, flight *domain.Flight) (err error) {
+	defer r.observe("UpdateFlight", time.Now(), &err)
+	return r.next.UpdateFlight(ctx, flight)
+}
+
+func (r *instrumentedFlightRepository) ResealFlight(ctx context.Context, flight *domain.Flight) (err error) {
+	defer r.observe("ResealFlight", time.Now(), &err)
+	return r.next.ResealFlight(ctx, flight)
+}
+
+func (r *instrumentedFlightRepository) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) (changes []domain.FlightChange, err error) {
+	defer r.observe("GetFlightChanges", time.Now(), &err)
+	return r.next.GetFlightChanges(ctx, userID, after, limit)
+}
+
+func (r *instrumentedFlightRepository) GetFlightByClientRef(ctx context.Context, userID, clientRef string) (flight *domain.Flight, err error) {
+	defer r.observe("GetFlightByClientRef", time.Now(), &err)
+	return r.next.GetFlightByClientRef(ctx, userID, clientRef)
+}
+
+func (r *instrumentedFlightRepository) PruneTombstones(ctx context.Context, before time.Time) (pruned int, err error) {
+	defer r.observe("PruneTombstones", time.Now(), &err)
+	return r.next.PruneTombstones(ctx, before)
+}
+
+func (r *instrumentedFlightRepository) ListFlights(ctx context.Context, afterID string, limit int) (flights []domain.Flight, err error) {
+	defer r.observe("ListFlights", time.Now(), &err)
+	return r.next.ListFlights(ctx, afterID, limit)
+}
+
+func (r *instrumentedFlightRepository) SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) (err error) {
+	defer r.observe("SetFlightExpiry", time.Now(), &err)
+	return r.next.SetFlightExpiry(ctx, id, expiresAt, warnedAt)
+}
+
+func (r *instrumentedFlightRepository) FindExpiringFlights(ctx context.Context, before time.Time) (flights []domain.Flight, err error) {
+	defer r.observe("FindExpiringFlights", time.Now(), &err)
+	return r.next.FindExpiringFlights(ctx, before)
+}
+
+func (r *instrumentedFlightRepository) observe(operation string, start time.Time, err *error) {
+	Infrastructure.ObserveRepositoryOperation(r.backend, "flights", operation, start, *err)
+}
+
+// instrumentedUserRepository records Prometheus metrics around another UserRepository
+type instrumentedUserRepository struct {
+	next    domain.UserRepository
+	backend string
+}
+
+// NewInstrumentedUserRepository wraps repo so every call is timed under the given backend label
+func NewInstrumentedUserRepository(repo domain.UserRepository, backend string) domain.UserRepository {
+	return &instrumentedUserRepository{next: repo, backend: backend}
+}
+
+func (r *instrumentedUserRepository) CreateUser(ctx context.Context, user *domain.User) (err error) {
+	defer r.observe("CreateUser", time.Now(), &err)
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *instrumentedUserRepository) FindUserByEmail(ctx context.Context, email string) (user *domain.User, err error) {
+	defer r.observe("FindUserByEmail", time.Now(), &err)
+	return r.next.FindUserByEmail(ctx, email)
+}
+
+func (r *instrumentedUserRepository) FindUserByUsername(ctx context.Context, username string) (user *domain.User, err error) {
+	defer r.observe("FindUserByUsername", time.Now(), &err)
+	return r.next.FindUserByUsername(ctx, username)
+}
+
+func (r *instrumentedUserRepository) FindUserByID(ctx context.Context, id string) (user *domain.User, err error) {
+	defer r.observe("FindUserByID", time.Now(), &err)
+	return r.next.FindUserByID(ctx, id)
+}
+
+func (r *instrumentedUserRepository) UpdateUsername(ctx context.Context, id, newUsername string, version int64) (updated int64, err error) {
+	defer r.observe("UpdateUsername", time.Now(), &err)
+	return r.next.UpdateUsername(ctx, id, newUsername, version)
+}
+
+func (r *instrumentedUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (updated int64, err error) {
+	defer r.observe("UpdatePassword", time.Now(), &err)
+	return r.next.UpdatePassword(ctx, id, hashedPassword, version)
+}
+
+func (r *instrumentedUserRepository) UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (updated int64, err error) {
+	defer r.observe("UpdateRetention", time.Now(), &err)
+	return r.next.UpdateRetention(ctx, id, retentionDays, version)
+}
+
+func (r *instrumentedUserRepository) UpdateCalendarToken(ctx context.Context, id, tokenHash string) (err error) {
+	defer r.observe("UpdateCalendarToken", time.Now(), &err)
+	return r.next.UpdateCalendarToken(ctx, id, tokenHash)
+}
+
+func (r *instrumentedUserRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (user *domain.User, err error) {
+	defer r.observe("FindUserByCalendarToken", time.Now(), &err)
+	return r.next.FindUserByCalendarToken(ctx, tokenHash)
+}
+
+func (r *instrumentedUserRepository) GetAnswerProfile(ctx context.Context, userID string) (profile *domain.AnswerProfile, err error) {
+	defer r.observe("GetAnswerProfile", time.Now(), &err)
+	return r.next.GetAnswerProfile(ctx, userID)
+}
+
+func (r *instrumentedUserRepository) SaveProfileAnswers(ctx context.Context, userID string, answers []domain.ProfileAnswer) (err error) {
+	defer r.observe("SaveProfileAnswers", time.Now(), &err)
+	return r.next.SaveProfileAnswers(ctx, userID, answers)
+}
+
+func (r *instrumentedUserRepository) DeleteProfileAnswer(ctx context.Context, userID, questionID string) (err error) {
+	defer r.observe("DeleteProfileAnswer", time.Now(), &err)
+	return r.next.DeleteProfileAnswer(ctx, userID, questionID)
+}
+
+func (r *instrumentedUserRepository) ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) (profiles []domain.AnswerProfile, err error) {
+	defer r.observe("ListAnswerProfiles", time.Now(), &err)
+	return r.next.ListAnswerProfiles(ctx, afterUserID, limit)
+}
+
+func (r *instrumentedUserRepository) observe(operation string, start time.Time, err *error) {
+	Infrastructure.ObserveRepositoryOperation(r.backend, "users", operation, start, *err)
+}
+
+// instrumentedTripRepository records Prometheus metrics around another TripRepository
+type instrumentedTripRepository struct {
+	next    domain.TripRepository
+	backend string
+}
+
+// NewInstrumentedTripRepository wraps repo so every call is timed under the given backend label
+func NewInstrumentedTripRepository(repo domain.TripRepository, backend string) domain.TripRepository {
+	return &instrumentedTripRepository{next: repo, backend: backend}
+}
+
+func (r *instrumentedTripRepository) CreateTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	defer r.observe("CreateTrip", time.Now(), &err)
+	return r.next.CreateTrip(ctx, trip)
+}
+
+func (r *instrumentedTripRepository) GetTripByID(ctx context.Context, userID, id string) (trip *domain.Trip, err error) {
+	defer r.observe("GetTripByID", time.Now(), &err)
+	return r.next.GetTripByID(ctx, userID, id)
+}
+
+func (r *instrumentedTripRepository) GetTripsByUserID(ctx context.Context, userID string) (trips []domain.Trip, err error) {
+	defer r.observe("GetTripsByUserID", time.Now(), &err)
+	return r.next.GetTripsByUserID(ctx, userID)
+}
+
+func (r *instrumentedTripRepository) UpdateTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	defer r.observe("UpdateTrip", time.Now(), &err)
+	return r.next.UpdateTrip(ctx, trip)
+}
+
+func (r *instrumentedTripRepository) ResealTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	defer r.observe("ResealTrip", time.Now(), &err)
+	return r.next.ResealTrip(ctx, trip)
+}
+
+func (r *instrumentedTripRepository) DeleteTrip(ctx context.Context, userID, id string, version int64) (err error) {
+	defer r.observe("DeleteTrip", time.Now(), &err)
+	return r.next.DeleteTrip(ctx, userID, id, version)
+}
+
+func (r *instrumentedTripRepository) ListTrips(ctx context.Context, afterID string, limit int) (trips []domain.Trip, err error) {
+	defer r.observe("ListTrips", time.Now(), &err)
+	return r.next.ListTrips(ctx, afterID, limit)
+}
+
+func (r *instrumentedTripRepository) SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) (err error) {
+	defer r.observe("SetTripExpiry", time.Now(), &err)
+	return r.next.SetTripExpiry(ctx, id, expiresAt, warnedAt)
+}
+
+func (r *instrumentedTripRepository) FindExpiringTrips(ctx context.Context, before time.Time) (trips []domain.Trip, err error) {
+	defer r.observe("FindExpiringTrips", time.Now(), &err)
+	return r.next.FindExpiringTrips(ctx, before)
+}
+
+func (r *instrumentedTripRepository) observe(operation string, start time.Time, err *error) {
+	Infrastructure.ObserveRepositoryOperation(r.backend, "trips", operation, start, *err)
+}
+
+// instrumentedShareRepository records Prometheus metrics around another ShareRepository
+type instrumentedShareRepository struct {
+	next    domain.ShareRepository
+	backend string
+}
+
+// NewInstrumentedShareRepository wraps repo so every call is timed under the given backend label
+func NewInstrumentedShareRepository(repo domain.ShareRepository, backend string) domain.ShareRepository {
+	return &instrumentedShareRepository{next: repo, backend: backend}
+}
+
+func (r *instrumentedShareRepository) CreateShare(ctx context.Context, share *domain.FlightShare) (err error) {
+	defer r.observe("CreateShare", time.Now(), &err)
+	return r.next.CreateShare(ctx, share)
+}
+
+func (r *instrumentedShareRepository) GetShareByID(ctx context.Context, id string) (share *domain.FlightShare, err error) {
+	defer r.observe("GetShareByID", time.Now(), &err)
+	return r.next.GetShareByID(ctx, id)
+}
+
+func (r *instrumentedShareRepository) GetSharesByFlightID(ctx context.Context, flightID string) (shares []domain.FlightShare, err error) {
+	defer r.observe("GetSharesByFlightID", time.Now(), &err)
+	return r.next.GetSharesByFlightID(ctx, flightID)
+}
+
+func (r *instrumentedShareRepository) RevokeShare(ctx context.Context, id string, revokedAt time.Time) (err error) {
+	defer r.observe("RevokeShare", time.Now(), &err)
+	return r.next.RevokeShare(ctx, id, revokedAt)
+}
+
+func (r *instrumentedShareRepository) RecordShareAccess(ctx context.Context, id string, accessedAt time.Time) (err error) {
+	defer r.observe("RecordShareAccess", time.Now(), &err)
+	return r.next.RecordShareAccess(ctx, id, accessedAt)
+}
+
+func (r *instrumentedShareRepository) observe(operation string, start time.Time, err *error) {
+	Infrastructure.ObserveRepositoryOperation(r.backend, "flight_shares", operation, start, *err)
+}
+
+// instrumentedHouseholdRepository records Prometheus metrics around another HouseholdRepository
+type instrumentedHouseholdRepository struct {
+	next    domain.HouseholdRepository
+	backend string
+}
+
+// NewInstrumentedHouseholdRepository wraps repo so every call is timed under the given backend label
+func NewInstrumentedHouseholdRepository(repo domain.HouseholdRepository, backend string) domain.HouseholdRepository {
+	return &instrumentedHouseholdRepository{next: repo, backend: backend}
+}
+
+func (r *instrumentedHouseholdRepository) CreateHousehold(ctx context.Context, household *domain.Household) (err error) {
+	defer r.observe("CreateHousehold", time.Now(), &err)
+	return r.next.CreateHousehold(ctx, household)
+}
+
+func (r *instrumentedHouseholdRepository) GetHouseholdByID(ctx context.Context, id string) (household *domain.Household, err error) {
+	defer r.observe("GetHouseholdByID", time.Now(), &err)
+	return r.next.GetHouseholdByID(ctx, id)
+}
+
+func (r *instrumentedHouseholdRepository) GetHouseholdsByUserID(ctx context.Context, userID string) (households []domain.Household, err error) {
+	defer r.observe("GetHouseholdsByUserID", time.Now(), &err)
+	return r.next.GetHouseholdsByUserID(ctx, userID)
+}
+
+func (r *instrumentedHouseholdRepository) DeleteHousehold(ctx context.Context, id string) (err error) {
+	defer r.observe("DeleteHousehold", time.Now(), &err)
+	return r.next.DeleteHousehold(ctx, id)
+}
+
+func (r *instrumentedHouseholdRepository) AddHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) (err error) {
+	defer r.observe("AddHouseholdMember", time.Now(), &err)
+	return r.next.AddHouseholdMember(ctx, householdID, member)
+}
+
+func (r *instrumentedHouseholdRepository) UpdateHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) (err error) {
+	defer r.observe("UpdateHouseholdMember", time.Now(), &err)
+	return r.next.UpdateHouseholdMember(ctx, householdID, member)
+}
+
+func (r *instrumentedHouseholdRepository) RemoveHouseholdMember(ctx context.Context, householdID, userID string) (err error) {
+	defer r.observe("RemoveHouseholdMember", time.Now(), &err)
+	return r.next.RemoveHouseholdMember(ctx, householdID, userID)
+}
+
+func (r *instrumentedHouseholdRepository) observe(operation string, start time.Time, err *error) {
+	Infrastructure.ObserveRepositoryOperation(r.backend, "households", operation, start, *err)
+}
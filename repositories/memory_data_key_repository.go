@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memoryDataKeyRepository is an in-memory implementation of the DataKeyRepository interface
+type memoryDataKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string][]domain.DataKey
+}
+
+// NewMemoryDataKeyRepository initializes an empty in-memory data key repository
+func NewMemoryDataKeyRepository() domain.DataKeyRepository {
+	return &memoryDataKeyRepository{
+		keys: make(map[string][]domain.DataKey),
+	}
+}
+
+// GetDataKeys returns every version of the user's data key, oldest first
+func (r *memoryDataKeyRepository) GetDataKeys(ctx context.Context, userID string) ([]domain.DataKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.DataKey(nil), r.keys[userID]...), nil
+}
+
+// CreateDataKey stores a new data key version unless that version already exists
+func (r *memoryDataKeyRepository) CreateDataKey(ctx context.Context, key *domain.DataKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys[key.UserID] {
+		if existing.Version == key.Version {
+			return domain.ErrDataKeyExists
+		}
+	}
+	keys := append(r.keys[key.UserID], *key)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Version < keys[j].Version })
+	r.keys[key.UserID] = keys
+	return nil
+}
+
+// UpdateDataKey stores a re-wrapped data key
+func (r *memoryDataKeyRepository) UpdateDataKey(ctx context.Context, key *domain.DataKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.keys[key.UserID] {
+		if existing.Version == key.Version {
+			r.keys[key.UserID][i].MasterKeyID = key.MasterKeyID
+			r.keys[key.UserID][i].WrappedKey = key.WrappedKey
+			return nil
+		}
+	}
+	return domain.ErrDataKeyNotFound
+}
+
+// ListDataKeys returns every stored data key
+func (r *memoryDataKeyRepository) ListDataKeys(ctx context.Context) ([]domain.DataKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []domain.DataKey
+	for _, userKeys := range r.keys {
+		keys = append(keys, userKeys...)
+	}
+	return keys, nil
+}
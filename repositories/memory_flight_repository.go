@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memoryFlightRepository is an in-memory implementation of the FlightRepository interface
+type memoryFlightRepository struct {
+	mu      sync.RWMutex
+	flights map[string]domain.Flight
+	// tombstones holds the change feed entries of deleted flights by flight ID
+	tombstones map[string]memoryTombstone
+}
+
+type memoryTombstone struct {
+	userID string
+	change domain.FlightChange
+}
+
+// NewMemoryFlightRepository initializes an empty in-memory flight repository
+func NewMemoryFlightRepository() domain.FlightRepository {
+	return &memoryFlightRepository{
+		flights:    make(map[string]domain.Flight),
+		tombstones: make(map[string]memoryTombstone),
+	}
+}
+
+// CreateFlight stores a copy of the flight in memory
+func (r *memoryFlightRepository) CreateFlight(ctx context.Context, flight *domain.Flight) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	flight.Date = flight.Date.UTC() // Ensure consistency with the Mongo repository
+
+	if flight.ID == "" {
+		flight.ID = newID()
+	}
+	if flight.Language == "" {
+		flight.Language = "English"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.flights[flight.ID]; exists {
+		return fmt.Errorf("error creating flight: duplicate id %s", flight.ID)
+	}
+	if flight.ClientRef != "" && r.findByClientRef(flight.UserID, flight.ClientRef) != nil {
+		return domain.ErrClientRefExists
+	}
+	flight.Version = 1
+	flight.UpdatedAt = writeTime()
+	r.flights[flight.ID] = copyFlight(*flight)
+	return nil
+}
+
+// GetFlightByID retrieves a copy of one of the user's flights by its ID
+func (r *memoryFlightRepository) GetFlightByID(ctx context.Context, userID, id string) (*domain.Flight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	flight, ok := r.flights[id]
+	if !ok || flight.UserID != userID {
+		return nil, domain.ErrFlightNotFound
+	}
+	flight = copyFlight(flight)
+	return &flight, nil
+}
+
+// DeleteFlight removes one of the user's flights by its ID and leaves a tombstone in its place
+func (r *memoryFlightRepository) DeleteFlight(ctx context.Context, userID, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	flight, ok := r.flights[id]
+	if !ok || flight.UserID != userID {
+		return domain.ErrFlightNotFound
+	}
+	if version != 0 && version != flight.Version {
+		return domain.ErrVersionConflict
+	}
+	delete(r.flights, id)
+	r.tombstones[id] = memoryTombstone{userID: userID, change: domain.FlightChange{
+		FlightID:  id,
+		Version:   flight.Version + 1,
+		UpdatedAt: writeTime(),
+		Deleted:   true,
+	}}
+	return nil
+}
+
+// GetFlightsByUserID retrieves all flights for a specific user ordered by date
+func (r *memoryFlightRepository) GetFlightsByUserID(ctx context.Context, userID string) ([]domain.Flight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var flights []domain.Flight
+	for _, flight := range r.flights {
+		if flight.UserID == userID {
+			flights = append(flights, copyFlight(flight))
+		}
+	}
+	sortFlights(flights)
+	return flights, nil
+}
+
+// copyFlight returns a flight that shares no mutable state with the original
+func copyFlight(flight domain.Flight) domain.Flight {
+	if flight.QA != nil {
+		flight.QA = append([]domain.QA(nil), flight.QA...)
+	}
+	flight.ExpiresAt = copyTime(flight.ExpiresAt)
+	flight.ExpiryWarnedAt = copyTime(flight.ExpiryWarnedAt)
+	flight.DepartureTime = copyTime(flight.DepartureTime)
+	flight.ArrivalTime = copyTime(flight.ArrivalTime)
+	return flight
+}
+
+// sortFlights orders flights by date, breaking ties by ID, matching the database backends
+func sortFlights(flights []domain.Flight) {
+	sort.Slice(flights, func(i, j int) bool {
+		if !flights[i].Date.Equal(flights[j].Date) {
+			return flights[i].Date.Before(flights[j].Date)
+		}
+		return flights[i].ID < flights[j].ID
+	})
+}
+
+// UpdateFlight replaces a stored flight with a copy of the given one
+func (r *memoryFlightRepository) UpdateFlight(ctx context.Context, flight *domain.Flight) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	flight.Date = flight.Date.UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.flights[flight.ID]
+	if !ok || stored.UserID != flight.UserID {
+		return domain.ErrFlightNotFound
+	}
+	if flight.Version != 0 && flight.Version != stored.Version {
+		return domain.ErrVersionConflict
+	}
+	flight.Version = stored.Version + 1
+	flight.UpdatedAt = writeTime()
+	flight.ClientRef = stored.ClientRef
+	r.flights[flight.ID] = copyFlight(*flight)
+	return nil
+}
+
+// ResealFlight swaps the stored QA pairs for a copy of the given ones, leaving the version alone
+func (r *memoryFlightRepository) ResealFlight(ctx context.Context, flight *domain.Flight) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.flights[flight.ID]
+	if !ok || stored.UserID != flight.UserID {
+		return domain.ErrFlightNotFound
+	}
+	if flight.Version != 0 && flight.Version != stored.Version {
+		return domain.ErrVersionConflict
+	}
+	stored.QA = append([]domain.QA(nil), flight.QA...)
+	r.flights[flight.ID] = stored
+	return nil
+}
+
+// GetFlightChanges collects the user's flights and tombstones after the cursor
+func (r *memoryFlightRepository) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) ([]domain.FlightChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []domain.FlightChange
+	for _, flight := range r.flights {
+		if change := flightChange(copyFlight(flight)); flight.UserID == userID && after.After(change) {
+			changes = append(changes, change)
+		}
+	}
+	for _, tombstone := range r.tombstones {
+		if tombstone.userID == userID && after.After(tombstone.change) {
+			changes = append(changes, tombstone.change)
+		}
+	}
+	return mergeChanges(changes, limit), nil
+}
+
+// GetFlightByClientRef retrieves a copy of the user's flight created with the given client reference
+func (r *memoryFlightRepository) GetFlightByClientRef(ctx context.Context, userID, clientRef string) (*domain.Flight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	flight := r.findByClientRef(userID, clientRef)
+	if flight == nil {
+		return nil, domain.ErrFlightNotFound
+	}
+	found := copyFlight(*flight)
+	return &found, nil
+}
+
+// findByClientRef scans for the user's flight with a client reference; callers must hold the lock
+func (r *memoryFlightRepository) findByClientRef(userID, clientRef string) *domain.Flight {
+	for _, flight := range r.flights {
+		if clientRef != "" && flight.UserID == userID && flight.ClientRef == clientRef {
+			return &flight
+		}
+	}
+	return nil
+}
+
+// PruneTombstones forgets the flights deleted before the given time
+func (r *memoryFlightRepository) PruneTombstones(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pruned := 0
+	for id, tombstone := range r.tombstones {
+		if tombstone.change.UpdatedAt.Before(before) {
+			delete(r.tombstones, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// ListFlights pages through every flight ordered by ID
+func (r *memoryFlightRepository) ListFlights(ctx context.Context, afterID string, limit int) ([]domain.Flight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.flights))
+	for id := range r.flights {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	var flights []domain.Flight
+	for _, id := range ids {
+		flights = append(flights, copyFlight(r.flights[id]))
+	}
+	return flights, nil
+}
+
+// SetFlightExpiry sets or clears the expiry fields of a flight
+func (r *memoryFlightRepository) SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	flight, ok := r.flights[id]
+	if !ok {
+		return domain.ErrFlightNotFound
+	}
+	flight.ExpiresAt = copyTime(expiresAt)
+	flight.ExpiryWarnedAt = copyTime(warnedAt)
+	r.flights[id] = flight
+	return nil
+}
+
+// FindExpiringFlights returns flights whose expiry is at or before the given time, soonest first
+func (r *memoryFlightRepository) FindExpiringFlights(ctx context.Context, before time.Time) ([]domain.Flight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var flights []domain.Flight
+	for _, flight := range r.flights {
+		if flight.ExpiresAt != nil && !flight.ExpiresAt.After(before) {
+			flights = append(flights, copyFlight(flight))
+		}
+	}
+	sort.Slice(flights, func(i, j int) bool {
+		if !flights[i].ExpiresAt.Equal(*flights[j].ExpiresAt) {
+			return flights[i].ExpiresAt.Before(*flights[j].ExpiresAt)
+		}
+		return flights[i].ID < flights[j].ID
+	})
+	return flights, nil
+}
+
+// copyTime returns a UTC copy of t so stored flights never alias the caller's values
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := t.UTC()
+	return &c
+}
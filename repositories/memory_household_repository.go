@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memoryHouseholdRepository is an in-memory implementation of the HouseholdRepository interface
+type memoryHouseholdRepository struct {
+	mu         sync.RWMutex
+	households map[string]domain.Household
+}
+
+// NewMemoryHouseholdRepository initializes an empty in-memory household repository
+func NewMemoryHouseholdRepository() domain.HouseholdRepository {
+	return &memoryHouseholdRepository{
+		households: make(map[string]domain.Household),
+	}
+}
+
+func (r *memoryHouseholdRepository) CreateHousehold(ctx context.Context, household *domain.Household) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if household.ID == "" {
+		household.ID = newID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.households[household.ID]; exists {
+		return fmt.Errorf("error creating household: duplicate id %s", household.ID)
+	}
+	r.households[household.ID] = cloneHousehold(*household)
+	return nil
+}
+
+func (r *memoryHouseholdRepository) GetHouseholdByID(ctx context.Context, id string) (*domain.Household, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	household, ok := r.households[id]
+	if !ok {
+		return nil, domain.ErrHouseholdNotFound
+	}
+	household = cloneHousehold(household)
+	return &household, nil
+}
+
+func (r *memoryHouseholdRepository) GetHouseholdsByUserID(ctx context.Context, userID string) ([]domain.Household, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var households []domain.Household
+	for _, household := range r.households {
+		if _, ok := household.Member(userID); ok {
+			households = append(households, cloneHousehold(household))
+		}
+	}
+	sort.Slice(households, func(i, j int) bool {
+		if !households[i].CreatedAt.Equal(households[j].CreatedAt) {
+			return households[i].CreatedAt.Before(households[j].CreatedAt)
+		}
+		return households[i].ID < households[j].ID
+	})
+	return households, nil
+}
+
+func (r *memoryHouseholdRepository) DeleteHousehold(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.households[id]; !ok {
+		return domain.ErrHouseholdNotFound
+	}
+	delete(r.households, id)
+	return nil
+}
+
+func (r *memoryHouseholdRepository) AddHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) error {
+	return r.update(ctx, householdID, func(household *domain.Household) error {
+		if _, ok := household.Member(member.UserID); ok {
+			return domain.ErrMemberExists
+		}
+		household.Members = append(household.Members, member)
+		return nil
+	})
+}
+
+func (r *memoryHouseholdRepository) UpdateHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) error {
+	return r.update(ctx, householdID, func(household *domain.Household) error {
+		for i := range household.Members {
+			if household.Members[i].UserID == member.UserID {
+				household.Members[i].Role = member.Role
+				household.Members[i].Status = member.Status
+				return nil
+			}
+		}
+		return domain.ErrMemberNotFound
+	})
+}
+
+func (r *memoryHouseholdRepository) RemoveHouseholdMember(ctx context.Context, householdID, userID string) error {
+	err := r.update(ctx, householdID, func(household *domain.Household) error {
+		if !household.CanRemove(userID) {
+			return domain.ErrLastGuardian
+		}
+		for i := range household.Members {
+			if household.Members[i].UserID == userID {
+				household.Members = append(household.Members[:i], household.Members[i+1:]...)
+				return nil
+			}
+		}
+		return domain.ErrMemberNotFound
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if household, ok := r.households[householdID]; ok && len(household.Members) == 0 {
+		delete(r.households, householdID)
+	}
+	return nil
+}
+
+// update applies a change to a copy of the household and stores it only if the change succeeds
+func (r *memoryHouseholdRepository) update(ctx context.Context, id string, apply func(*domain.Household) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	household, ok := r.households[id]
+	if !ok {
+		return domain.ErrHouseholdNotFound
+	}
+	household = cloneHousehold(household)
+	if err := apply(&household); err != nil {
+		return err
+	}
+	r.households[id] = household
+	return nil
+}
+
+// cloneHousehold copies the member slice so callers cannot modify the stored household
+func cloneHousehold(household domain.Household) domain.Household {
+	household.Members = append([]domain.HouseholdMember{}, household.Members...)
+	return household
+}
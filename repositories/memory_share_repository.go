@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memoryShareRepository is an in-memory implementation of the ShareRepository interface
+type memoryShareRepository struct {
+	mu     sync.RWMutex
+	shares map[string]domain.FlightShare
+}
+
+// NewMemoryShareRepository initializes an empty in-memory share repository
+func NewMemoryShareRepository() domain.ShareRepository {
+	return &memoryShareRepository{
+		shares: make(map[string]domain.FlightShare),
+	}
+}
+
+func (r *memoryShareRepository) CreateShare(ctx context.Context, share *domain.FlightShare) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if share.ID == "" {
+		share.ID = newID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.shares[share.ID]; exists {
+		return fmt.Errorf("error creating share: duplicate id %s", share.ID)
+	}
+	r.shares[share.ID] = *share
+	return nil
+}
+
+func (r *memoryShareRepository) GetShareByID(ctx context.Context, id string) (*domain.FlightShare, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	share, ok := r.shares[id]
+	if !ok {
+		return nil, domain.ErrShareNotFound
+	}
+	return &share, nil
+}
+
+func (r *memoryShareRepository) GetSharesByFlightID(ctx context.Context, flightID string) ([]domain.FlightShare, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var shares []domain.FlightShare
+	for _, share := range r.shares {
+		if share.FlightID == flightID {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.After(shares[j].CreatedAt)
+		}
+		return shares[i].ID > shares[j].ID
+	})
+	return shares, nil
+}
+
+func (r *memoryShareRepository) RevokeShare(ctx context.Context, id string, revokedAt time.Time) error {
+	return r.update(ctx, id, func(share *domain.FlightShare) {
+		share.RevokedAt = &revokedAt
+	})
+}
+
+func (r *memoryShareRepository) RecordShareAccess(ctx context.Context, id string, accessedAt time.Time) error {
+	return r.update(ctx, id, func(share *domain.FlightShare) {
+		share.AccessCount++
+		share.LastAccessedAt = &accessedAt
+	})
+}
+
+func (r *memoryShareRepository) update(ctx context.Context, id string, apply func(*domain.FlightShare)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	share, ok := r.shares[id]
+	if !ok {
+		return domain.ErrShareNotFound
+	}
+	apply(&share)
+	r.shares[id] = share
+	return nil
+}
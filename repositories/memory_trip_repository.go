@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memoryTripRepository is an in-memory implementation of the TripRepository interface
+type memoryTripRepository struct {
+	mu    sync.RWMutex
+	trips map[string]domain.Trip
+}
+
+// NewMemoryTripRepository initializes an empty in-memory trip repository
+func NewMemoryTripRepository() domain.TripRepository {
+	return &memoryTripRepository{
+		trips: make(map[string]domain.Trip),
+	}
+}
+
+// CreateTrip stores a copy of the trip in memory at version 1
+func (r *memoryTripRepository) CreateTrip(ctx context.Context, trip *domain.Trip) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	normalizeTrip(trip)
+	if trip.ID == "" {
+		trip.ID = newID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.trips[trip.ID]; exists {
+		return fmt.Errorf("error creating trip: duplicate id %s", trip.ID)
+	}
+	trip.Version = 1
+	r.trips[trip.ID] = copyTrip(*trip)
+	return nil
+}
+
+// GetTripByID retrieves a copy of one of the user's trips by its ID
+func (r *memoryTripRepository) GetTripByID(ctx context.Context, userID, id string) (*domain.Trip, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trip, ok := r.trips[id]
+	if !ok || trip.UserID != userID {
+		return nil, domain.ErrTripNotFound
+	}
+	trip = copyTrip(trip)
+	return &trip, nil
+}
+
+// GetTripsByUserID retrieves all trips of a user ordered by the date of their first leg
+func (r *memoryTripRepository) GetTripsByUserID(ctx context.Context, userID string) ([]domain.Trip, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var trips []domain.Trip
+	for _, trip := range r.trips {
+		if trip.UserID == userID {
+			trips = append(trips, copyTrip(trip))
+		}
+	}
+	sortTrips(trips)
+	return trips, nil
+}
+
+// UpdateTrip replaces a stored trip with a copy of the given one if its version still matches
+func (r *memoryTripRepository) UpdateTrip(ctx context.Context, trip *domain.Trip) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	normalizeTrip(trip)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.trips[trip.ID]
+	if !ok || stored.UserID != trip.UserID {
+		return domain.ErrTripNotFound
+	}
+	if trip.Version != 0 && trip.Version != stored.Version {
+		return domain.ErrVersionConflict
+	}
+	trip.Version = stored.Version + 1
+	r.trips[trip.ID] = copyTrip(*trip)
+	return nil
+}
+
+// ResealTrip swaps the stored legs for a copy of the given ones, leaving the version alone
+func (r *memoryTripRepository) ResealTrip(ctx context.Context, trip *domain.Trip) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.trips[trip.ID]
+	if !ok || stored.UserID != trip.UserID {
+		return domain.ErrTripNotFound
+	}
+	if trip.Version != 0 && trip.Version != stored.Version {
+		return domain.ErrVersionConflict
+	}
+	stored.Legs = copyTrip(*trip).Legs
+	r.trips[trip.ID] = stored
+	return nil
+}
+
+// DeleteTrip removes one of the user's trips by its ID if its version still matches
+func (r *memoryTripRepository) DeleteTrip(ctx context.Context, userID, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[id]
+	if !ok || trip.UserID != userID {
+		return domain.ErrTripNotFound
+	}
+	if version != 0 && version != trip.Version {
+		return domain.ErrVersionConflict
+	}
+	delete(r.trips, id)
+	return nil
+}
+
+// ListTrips pages through every trip ordered by ID
+func (r *memoryTripRepository) ListTrips(ctx context.Context, afterID string, limit int) ([]domain.Trip, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.trips))
+	for id := range r.trips {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	var trips []domain.Trip
+	for _, id := range ids {
+		trips = append(trips, copyTrip(r.trips[id]))
+	}
+	return trips, nil
+}
+
+// SetTripExpiry sets or clears the expiry fields of a trip
+func (r *memoryTripRepository) SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[id]
+	if !ok {
+		return domain.ErrTripNotFound
+	}
+	trip.ExpiresAt = copyTime(expiresAt)
+	trip.ExpiryWarnedAt = copyTime(warnedAt)
+	r.trips[id] = trip
+	return nil
+}
+
+// FindExpiringTrips returns trips whose expiry is at or before the given time, soonest first
+func (r *memoryTripRepository) FindExpiringTrips(ctx context.Context, before time.Time) ([]domain.Trip, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var trips []domain.Trip
+	for _, trip := range r.trips {
+		if trip.ExpiresAt != nil && !trip.ExpiresAt.After(before) {
+			trips = append(trips, copyTrip(trip))
+		}
+	}
+	sort.Slice(trips, func(i, j int) bool {
+		if !trips[i].ExpiresAt.Equal(*trips[j].ExpiresAt) {
+			return trips[i].ExpiresAt.Before(*trips[j].ExpiresAt)
+		}
+		return trips[i].ID < trips[j].ID
+	})
+	return trips, nil
+}
+
+// copyTrip returns a trip that shares no mutable state with the original
+func copyTrip(trip domain.Trip) domain.Trip {
+	legs := make([]domain.Leg, len(trip.Legs))
+	for i, leg := range trip.Legs {
+		if leg.QA != nil {
+			leg.QA = append([]domain.QA(nil), leg.QA...)
+		}
+		legs[i] = leg
+	}
+	trip.Legs = legs
+	trip.ExpiresAt = copyTime(trip.ExpiresAt)
+	trip.ExpiryWarnedAt = copyTime(trip.ExpiryWarnedAt)
+	return trip
+}
+
+// sortTrips orders trips by the date of their first leg, breaking ties by ID, matching the database backends
+func sortTrips(trips []domain.Trip) {
+	sort.Slice(trips, func(i, j int) bool {
+		if a, b := trips[i].StartDate(), trips[j].StartDate(); !a.Equal(b) {
+			return a.Before(b)
+		}
+		return trips[i].ID < trips[j].ID
+	})
+}
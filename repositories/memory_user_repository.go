@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memoryUserRepository is an in-memory implementation of the UserRepository interface
+type memoryUserRepository struct {
+	mu         sync.RWMutex
+	users      map[string]domain.User
+	byEmail    map[string]string
+	byUsername map[string]string
+	byCalendar map[string]string
+	answers    map[string]map[string]domain.ProfileAnswer
+}
+
+// NewMemoryUserRepository initializes an empty in-memory user repository
+func NewMemoryUserRepository() domain.UserRepository {
+	return &memoryUserRepository{
+		users:      make(map[string]domain.User),
+		byEmail:    make(map[string]string),
+		byUsername: make(map[string]string),
+		byCalendar: make(map[string]string),
+		answers:    make(map[string]map[string]domain.ProfileAnswer),
+	}
+}
+
+// CreateUser stores a new user, enforcing the same unique constraints as the Mongo indexes
+func (r *memoryUserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, taken := r.byEmail[user.Email]; taken {
+		return domain.ErrEmailTaken
+	}
+	if _, taken := r.byUsername[user.Username]; taken {
+		return domain.ErrUsernameTaken
+	}
+
+	if user.ID == "" {
+		user.ID = newID()
+	}
+	user.Version = 1
+	r.users[user.ID] = *user
+	r.byEmail[user.Email] = user.ID
+	r.byUsername[user.Username] = user.ID
+	return nil
+}
+
+// FindUserByEmail retrieves a user by their email
+func (r *memoryUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookup(r.byEmail, email)
+}
+
+// FindUserByUsername retrieves a user by their username
+func (r *memoryUserRepository) FindUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookup(r.byUsername, username)
+}
+
+// FindUserByID retrieves a user by their ID
+func (r *memoryUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) UpdateUsername(ctx context.Context, id, newUsername string, version int64) (int64, error) {
+	return r.update(ctx, id, version, func(user *domain.User) error {
+		if owner, taken := r.byUsername[newUsername]; taken && owner != user.ID {
+			return domain.ErrUsernameTaken
+		}
+		delete(r.byUsername, user.Username)
+		r.byUsername[newUsername] = user.ID
+		user.Username = newUsername
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (int64, error) {
+	return r.update(ctx, id, version, func(user *domain.User) error {
+		user.Password = hashedPassword
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (int64, error) {
+	return r.update(ctx, id, version, func(user *domain.User) error {
+		user.RetentionDays = retentionDays
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateCalendarToken(ctx context.Context, id, tokenHash string) error {
+	_, err := r.update(ctx, id, 0, func(user *domain.User) error {
+		delete(r.byCalendar, user.CalendarTokenHash)
+		if tokenHash != "" {
+			r.byCalendar[tokenHash] = user.ID
+		}
+		user.CalendarTokenHash = tokenHash
+		return nil
+	})
+	return err
+}
+
+// FindUserByCalendarToken retrieves the user whose calendar feed token has the given hash
+func (r *memoryUserRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookup(r.byCalendar, tokenHash)
+}
+
+// GetAnswerProfile returns a copy of the user's saved answers
+func (r *memoryUserRepository) GetAnswerProfile(ctx context.Context, userID string) (*domain.AnswerProfile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.users[userID]; !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return r.profile(userID), nil
+}
+
+func (r *memoryUserRepository) SaveProfileAnswers(ctx context.Context, userID string, answers []domain.ProfileAnswer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[userID]; !ok {
+		return domain.ErrUserNotFound
+	}
+	saved := r.answers[userID]
+	if saved == nil {
+		saved = make(map[string]domain.ProfileAnswer)
+		r.answers[userID] = saved
+	}
+	for _, answer := range answers {
+		saved[answer.QuestionID] = answer
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) DeleteProfileAnswer(ctx context.Context, userID, questionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.answers[userID][questionID]; !ok {
+		return domain.ErrProfileAnswerNotFound
+	}
+	delete(r.answers[userID], questionID)
+	return nil
+}
+
+func (r *memoryUserRepository) ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) ([]domain.AnswerProfile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.answers))
+	for id, saved := range r.answers {
+		if id > afterUserID && len(saved) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	profiles := make([]domain.AnswerProfile, 0, len(ids))
+	for _, id := range ids {
+		profiles = append(profiles, *r.profile(id))
+	}
+	return profiles, nil
+}
+
+// profile copies a user's answers into a sorted profile; callers hold the lock
+func (r *memoryUserRepository) profile(userID string) *domain.AnswerProfile {
+	profile := &domain.AnswerProfile{UserID: userID, Answers: make([]domain.ProfileAnswer, 0, len(r.answers[userID]))}
+	for _, answer := range r.answers[userID] {
+		profile.Answers = append(profile.Answers, answer)
+	}
+	sort.Slice(profile.Answers, func(i, j int) bool { return profile.Answers[i].QuestionID < profile.Answers[j].QuestionID })
+	return profile
+}
+
+// lookup resolves a user through one of the unique indexes; callers must hold the lock
+func (r *memoryUserRepository) lookup(index map[string]string, key string) (*domain.User, error) {
+	id, ok := index[key]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	user := r.users[id]
+	return &user, nil
+}
+
+// update applies fn to the stored user under the write lock and bumps its version; a non-zero version must
+// match the stored one
+func (r *memoryUserRepository) update(ctx context.Context, id string, version int64, fn func(user *domain.User) error) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return 0, domain.ErrUserNotFound
+	}
+	if version != 0 && version != user.Version {
+		return 0, domain.ErrVersionConflict
+	}
+	if err := fn(&user); err != nil {
+		return 0, err
+	}
+	user.Version++
+	r.users[id] = user
+	return user.Version, nil
+}
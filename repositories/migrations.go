@@ -0,0 +1,315 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index names are fixed so that duplicate-key errors can be traced back to the field that caused them
+const (
+	userEmailIndex       = "users_email_unique"
+	userUsernameIndex    = "users_username_unique"
+	flightUserIndex      = "flights_user_id_date"
+	dataKeyIndex         = "data_keys_user_id_version_unique"
+	flightExpiryIndex    = "flights_expires_at"
+	tripUserIndex        = "trips_user_id_start_date"
+	userCalendarIndex    = "users_calendar_token_hash_unique"
+	shareFlightIndex     = "flight_shares_flight_id_created_at"
+	householdMemberIndex = "households_members_user_id"
+	flightChangesIndex   = "flights_user_id_updated_at"
+	tombstoneUserIndex   = "flight_tombstones_user_id_deleted_at"
+	tripExpiryIndex      = "trips_expires_at"
+	flightClientRefIndex = "flights_user_id_client_ref_unique"
+	tombstoneAgeIndex    = "flight_tombstones_deleted_at"
+)
+
+// Migration is a single versioned change to the database schema
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// appliedMigration is the record kept in the schema_migrations collection
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// migrations lists every schema change in the order it must be applied
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "unique indexes on users.email and users.username",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Report every clash up front instead of the first one the index build trips over
+			if err := checkDuplicates(ctx, db.Collection("users"), "email", "username"); err != nil {
+				return err
+			}
+			_, err := db.Collection("users").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "email", Value: 1}},
+					Options: options.Index().SetName(userEmailIndex).SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "username", Value: 1}},
+					Options: options.Index().SetName(userUsernameIndex).SetUnique(true),
+				},
+			})
+			return err
+		},
+	},
+	{
+		Version:     2,
+		Description: "compound index on flights(user_id, date)",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("flights").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}},
+				Options: options.Index().SetName(flightUserIndex),
+			})
+			return err
+		},
+	},
+	{
+		Version:     3,
+		Description: "unique index on data_keys(user_id, version)",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("data_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: 1}},
+				Options: options.Index().SetName(dataKeyIndex).SetUnique(true),
+			})
+			return err
+		},
+	},
+	{
+		Version:     4,
+		Description: "sparse index on flights.expires_at for the retention sweep",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("flights").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName(flightExpiryIndex).SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		Version:     5,
+		Description: "compound index on trips(user_id, legs.0.date)",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("trips").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "legs.0.date", Value: 1}},
+				Options: options.Index().SetName(tripUserIndex),
+			})
+			return err
+		},
+	},
+	{
+		Version:     6,
+		Description: "sparse unique index on users.calendar_token_hash",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "calendar_token_hash", Value: 1}},
+				Options: options.Index().SetName(userCalendarIndex).SetUnique(true).SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		Version:     7,
+		Description: "compound index on flight_shares(flight_id, created_at)",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("flight_shares").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "flight_id", Value: 1}, {Key: "created_at", Value: -1}},
+				Options: options.Index().SetName(shareFlightIndex),
+			})
+			return err
+		},
+	},
+	{
+		Version:     8,
+		Description: "index on households.members.user_id",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("households").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "members.user_id", Value: 1}},
+				Options: options.Index().SetName(householdMemberIndex),
+			})
+			return err
+		},
+	},
+	{
+		Version:     9,
+		Description: "flight versions and the flight_tombstones change feed",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			flights := db.Collection("flights")
+			_, err := flights.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": 1, "updated_at": legacyUpdatedAt}})
+			if err != nil {
+				return err
+			}
+			_, err = flights.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName(flightChangesIndex),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("flight_tombstones").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName(tombstoneUserIndex),
+			})
+			return err
+		},
+	},
+	{
+		Version:     10,
+		Description: "user versions",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("users").UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": 1}})
+			return err
+		},
+	},
+	{
+		Version:     11,
+		Description: "trip versions and a sparse index on trips.expires_at for the retention sweep",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			trips := db.Collection("trips")
+			_, err := trips.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": 1}})
+			if err != nil {
+				return err
+			}
+			_, err = trips.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName(tripExpiryIndex).SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		Version:     12,
+		Description: "unique flight client_ref per user and an index on flight_tombstones.deleted_at for pruning",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("flights").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "client_ref", Value: 1}},
+				Options: options.Index().SetName(flightClientRefIndex).SetUnique(true).
+					SetPartialFilterExpression(bson.M{"client_ref": bson.M{"$exists": true}}),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("flight_tombstones").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+				Options: options.Index().SetName(tombstoneAgeIndex),
+			})
+			return err
+		},
+	},
+}
+
+// maxReportedDuplicates bounds how many clashing values a DuplicateKeysError lists per field
+const maxReportedDuplicates = 20
+
+// DuplicateKeysError means existing documents share values that a new unique index forbids. The index
+// can only be built once an operator merges or removes the duplicates
+type DuplicateKeysError struct {
+	Collection string
+	// Values maps each field to the values held by more than one document
+	Values map[string][]string
+}
+
+func (e *DuplicateKeysError) Error() string {
+	fields := make([]string, 0, len(e.Values))
+	for field := range e.Values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s.%s: %s", e.Collection, field, strings.Join(e.Values[field], ", ")))
+	}
+	return "duplicate values block a unique index; merge or remove these documents and restart: " + strings.Join(parts, "; ")
+}
+
+// checkDuplicates returns a DuplicateKeysError when any of the fields holds the same value in more than
+// one document of the collection
+func checkDuplicates(ctx context.Context, collection *mongo.Collection, fields ...string) error {
+	found := map[string][]string{}
+	for _, field := range fields {
+		cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+			{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+			{{Key: "$sort", Value: bson.M{"_id": 1}}},
+			{{Key: "$limit", Value: maxReportedDuplicates}},
+		})
+		if err != nil {
+			return fmt.Errorf("error looking for duplicate %s.%s: %w", collection.Name(), field, err)
+		}
+		var groups []struct {
+			Value any `bson:"_id"`
+			Count int `bson:"count"`
+		}
+		if err := cursor.All(ctx, &groups); err != nil {
+			return fmt.Errorf("error looking for duplicate %s.%s: %w", collection.Name(), field, err)
+		}
+		for _, group := range groups {
+			found[field] = append(found[field], fmt.Sprintf("%v (%d documents)", group.Value, group.Count))
+		}
+	}
+	if len(found) > 0 {
+		return &DuplicateKeysError{Collection: collection.Name(), Values: found}
+	}
+	return nil
+}
+
+// RunMigrations applies every migration not yet recorded in schema_migrations and returns the ones it applied
+func RunMigrations(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	history := db.Collection("schema_migrations")
+
+	cursor, err := history.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	var records []appliedMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+
+	done := make(map[int]bool, len(records))
+	for _, record := range records {
+		done[record.Version] = true
+	}
+
+	pending := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := m.Up(ctx, db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		_, err := history.InsertOne(ctx, appliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC(),
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return applied, fmt.Errorf("error recording migration %d: %w", m.Version, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
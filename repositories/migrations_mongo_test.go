@@ -0,0 +1,61 @@
+//go:build mongo
+
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestRunMigrationsReportsDuplicateUsers seeds users the way a deployment without the unique indexes could
+// have stored them and checks the first migration names every clash instead of failing on the index build
+func TestRunMigrationsReportsDuplicateUsers(t *testing.T) {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	db := client.Database("passme_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+
+	_, err = db.Collection("users").InsertMany(ctx, []any{
+		bson.M{"username": "ada", "email": "ada@example.com"},
+		bson.M{"username": "ada", "email": "ada@example.org"},
+		bson.M{"username": "grace", "email": "grace@example.com"},
+		bson.M{"username": "hopper", "email": "grace@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("seed users: %v", err)
+	}
+
+	applied, err := repositories.RunMigrations(ctx, db)
+	var duplicates *repositories.DuplicateKeysError
+	if !errors.As(err, &duplicates) {
+		t.Fatalf("RunMigrations = %v, want a DuplicateKeysError", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("applied %d migrations before the failing one, want none", len(applied))
+	}
+	if got := duplicates.Values["username"]; len(got) != 1 || !strings.HasPrefix(got[0], "ada ") {
+		t.Errorf("duplicate usernames = %v, want ada", got)
+	}
+	if got := duplicates.Values["email"]; len(got) != 1 || !strings.HasPrefix(got[0], "grace@example.com ") {
+		t.Errorf("duplicate emails = %v, want grace@example.com", got)
+	}
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// shareRepository is the MongoDB implementation of the ShareRepository interface
+type shareRepository struct {
+	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+// NewShareRepository initializes a new flight share repository
+func NewShareRepository(db *mongo.Database, timeouts Timeouts) domain.ShareRepository {
+	return &shareRepository{
+		collection: db.Collection("flight_shares"),
+		timeouts:   timeouts,
+	}
+}
+
+// CreateShare stores a new share
+func (r *shareRepository) CreateShare(ctx context.Context, share *domain.FlightShare) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if share.ID == "" {
+		share.ID = newID()
+	}
+	if _, err := r.collection.InsertOne(ctx, share); err != nil {
+		return fmt.Errorf("error creating share: %w", err)
+	}
+	return nil
+}
+
+// GetShareByID retrieves a share by its ID
+func (r *shareRepository) GetShareByID(ctx context.Context, id string) (*domain.FlightShare, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var share domain.FlightShare
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&share)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrShareNotFound
+		}
+		return nil, fmt.Errorf("error finding share: %w", err)
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) GetSharesByFlightID(ctx context.Context, flightID string) ([]domain.FlightShare, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"flight_id": flightID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shares: %w", err)
+	}
+
+	var shares []domain.FlightShare
+	if err := cursor.All(ctx, &shares); err != nil {
+		return nil, fmt.Errorf("error finding shares: %w", err)
+	}
+	return shares, nil
+}
+
+func (r *shareRepository) RevokeShare(ctx context.Context, id string, revokedAt time.Time) error {
+	return r.update(ctx, id, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+}
+
+// RecordShareAccess increments the counter in place so concurrent openings are all counted
+func (r *shareRepository) RecordShareAccess(ctx context.Context, id string, accessedAt time.Time) error {
+	return r.update(ctx, id, bson.M{
+		"$inc": bson.M{"access_count": 1},
+		"$set": bson.M{"last_accessed_at": accessedAt},
+	})
+}
+
+func (r *shareRepository) update(ctx context.Context, id string, update bson.M) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("error updating share: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrShareNotFound
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// sqlDataKeyRepository is the database/sql implementation of the DataKeyRepository interface
+type sqlDataKeyRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	timeouts Timeouts
+}
+
+// NewSQLDataKeyRepository initializes a data key repository backed by PostgreSQL or SQLite
+func NewSQLDataKeyRepository(db *sql.DB, dialect Dialect, timeouts Timeouts) domain.DataKeyRepository {
+	return &sqlDataKeyRepository{
+		db:       db,
+		dialect:  dialect,
+		timeouts: timeouts,
+	}
+}
+
+// GetDataKeys returns every version of the user's data key, oldest first
+func (r *sqlDataKeyRepository) GetDataKeys(ctx context.Context, userID string) ([]domain.DataKey, error) {
+	return r.find(ctx, `WHERE user_id = ?`, userID)
+}
+
+// CreateDataKey inserts a new data key version; the primary key rejects concurrent duplicates
+func (r *sqlDataKeyRepository) CreateDataKey(ctx context.Context, key *domain.DataKey) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`INSERT INTO data_keys (user_id, version, master_key_id, wrapped_key, created_at) VALUES (?, ?, ?, ?, ?)`),
+		key.UserID, key.Version, key.MasterKeyID, key.WrappedKey, key.CreatedAt.UTC())
+	if err != nil {
+		if msg := strings.ToLower(err.Error()); strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate key") {
+			return domain.ErrDataKeyExists
+		}
+		return fmt.Errorf("error creating data key: %w", err)
+	}
+	return nil
+}
+
+// UpdateDataKey stores a re-wrapped data key
+func (r *sqlDataKeyRepository) UpdateDataKey(ctx context.Context, key *domain.DataKey) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`UPDATE data_keys SET master_key_id = ?, wrapped_key = ? WHERE user_id = ? AND version = ?`),
+		key.MasterKeyID, key.WrappedKey, key.UserID, key.Version)
+	if err != nil {
+		return fmt.Errorf("error updating data key: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrDataKeyNotFound
+	}
+	return nil
+}
+
+// ListDataKeys returns every stored data key
+func (r *sqlDataKeyRepository) ListDataKeys(ctx context.Context) ([]domain.DataKey, error) {
+	return r.find(ctx, ``)
+}
+
+func (r *sqlDataKeyRepository) find(ctx context.Context, where string, args ...any) ([]domain.DataKey, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT user_id, version, master_key_id, wrapped_key, created_at FROM data_keys `+where+` ORDER BY user_id, version`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding data keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.DataKey
+	for rows.Next() {
+		var key domain.DataKey
+		if err := rows.Scan(&key.UserID, &key.Version, &key.MasterKeyID, &key.WrappedKey, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error reading data key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading data keys: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,547 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// flightColumns lists the flights columns in the order scanFlight and flightValues use
+const flightColumns = `id, title, from_country, to_country, date, user_id, language, expires_at, expiry_warned_at, ` +
+	`flight_number, airline_code, origin_airport, destination_airport, departure_time, departure_timezone, arrival_time, arrival_timezone, ` +
+	`version, updated_at`
+
+// flightAssignments is the SET clause that rewrites every flights column except id
+var flightAssignments = strings.Join(strings.Split(flightColumns, ", ")[1:], " = ?, ") + " = ?"
+
+// flightPlaceholders holds one placeholder per flights column
+var flightPlaceholders = strings.TrimSuffix(strings.Repeat("?, ", len(strings.Split(flightColumns, ", "))), ", ")
+
+// sqlFlightRepository is the database/sql implementation of the FlightRepository interface
+type sqlFlightRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	timeouts Timeouts
+}
+
+// NewSQLFlightRepository initializes a flight repository backed by PostgreSQL or SQLite
+func NewSQLFlightRepository(db *sql.DB, dialect Dialect, timeouts Timeouts) domain.FlightRepository {
+	return &sqlFlightRepository{
+		db:       db,
+		dialect:  dialect,
+		timeouts: timeouts,
+	}
+}
+
+// CreateFlight inserts the flight and its QA pairs in a single transaction. The client reference is only
+// written here, so UpdateFlight keeps it
+func (r *sqlFlightRepository) CreateFlight(ctx context.Context, flight *domain.Flight) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	flight.Date = flight.Date.UTC() // Ensure consistency
+
+	if flight.ID == "" {
+		flight.ID = newID()
+	}
+	if flight.Language == "" {
+		flight.Language = "English"
+	}
+	flight.Version = 1
+	flight.UpdatedAt = writeTime()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`INSERT INTO flights (`+flightColumns+`, client_ref) VALUES (`+flightPlaceholders+`, ?)`),
+			append(flightValues(flight), nullString(flight.ClientRef))...)
+		if err != nil {
+			return err
+		}
+		return r.insertQA(ctx, tx, flight)
+	})
+	if err != nil {
+		// The unique index on (user_id, client_ref) rejected a concurrent create with the same reference
+		if flight.ClientRef != "" {
+			if _, lookupErr := r.GetFlightByClientRef(ctx, flight.UserID, flight.ClientRef); lookupErr == nil {
+				return domain.ErrClientRefExists
+			}
+		}
+		return fmt.Errorf("error creating flight: %w", err)
+	}
+	return nil
+}
+
+// GetFlightByID retrieves one of the user's flights and its QA pairs by ID
+func (r *sqlFlightRepository) GetFlightByID(ctx context.Context, userID, id string) (*domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	flight, err := scanFlight(r.db.QueryRowContext(ctx,
+		r.dialect.rebind(`SELECT `+flightColumns+` FROM flights WHERE id = ? AND user_id = ?`), id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrFlightNotFound
+		}
+		return nil, fmt.Errorf("error finding flight: %w", err)
+	}
+
+	qa, err := r.loadQA(ctx, `WHERE flight_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	flight.QA = qa[id]
+	return flight, nil
+}
+
+// DeleteFlight removes one of the user's flights with its QA pairs and share links and records a tombstone,
+// all in a single transaction
+func (r *sqlFlightRepository) DeleteFlight(ctx context.Context, userID, id string, version int64) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stored, err := r.storedVersion(ctx, tx, userID, id, version)
+		if err != nil {
+			return err
+		}
+		// Cascades may be off on a database opened without foreign keys, so children are removed explicitly
+		for _, query := range []string{`DELETE FROM flight_qa WHERE flight_id = ?`, `DELETE FROM flight_shares WHERE flight_id = ?`} {
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(query), id); err != nil {
+				return err
+			}
+		}
+		result, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`DELETE FROM flights WHERE id = ? AND user_id = ? AND version = ?`), id, userID, stored)
+		if err != nil {
+			return err
+		}
+		// A concurrent writer moved the version between the read and the write
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrVersionConflict
+		}
+		_, err = tx.ExecContext(ctx,
+			r.dialect.rebind(`INSERT INTO flight_tombstones (flight_id, user_id, version, deleted_at) VALUES (?, ?, ?, ?)`),
+			id, userID, stored+1, writeTime())
+		return err
+	})
+	if errors.Is(err, domain.ErrFlightNotFound) || errors.Is(err, domain.ErrVersionConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting flight: %w", err)
+	}
+	return nil
+}
+
+// storedVersion reads the version of one of the user's flights and checks it against an expected non-zero
+// version
+func (r *sqlFlightRepository) storedVersion(ctx context.Context, tx *sql.Tx, userID, id string, expected int64) (int64, error) {
+	var stored int64
+	err := tx.QueryRowContext(ctx,
+		r.dialect.rebind(`SELECT version FROM flights WHERE id = ? AND user_id = ?`), id, userID).Scan(&stored)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, domain.ErrFlightNotFound
+	case err != nil:
+		return 0, err
+	case expected != 0 && expected != stored:
+		return 0, domain.ErrVersionConflict
+	}
+	return stored, nil
+}
+
+// GetFlightsByUserID retrieves all flights for a specific user ordered by date
+func (r *sqlFlightRepository) GetFlightsByUserID(ctx context.Context, userID string) ([]domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+flightColumns+` FROM flights WHERE user_id = ? ORDER BY date, id`), userID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding flights: %w", err)
+	}
+
+	flights, err := scanFlights(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	qa, err := r.loadQA(ctx, `WHERE flight_id IN (SELECT id FROM flights WHERE user_id = ?)`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range flights {
+		flights[i].QA = qa[flights[i].ID]
+	}
+	return flights, nil
+}
+
+// loadQA returns the QA pairs matching the where clause, grouped by flight ID in their original order
+func (r *sqlFlightRepository) loadQA(ctx context.Context, where string, args ...any) (map[string][]domain.QA, error) {
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT flight_id, question_id, question, answer FROM flight_qa `+where+` ORDER BY flight_id, position`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding flight QA: %w", err)
+	}
+	defer rows.Close()
+
+	qa := map[string][]domain.QA{}
+	for rows.Next() {
+		var flightID string
+		var pair domain.QA
+		if err := rows.Scan(&flightID, &pair.QuestionID, &pair.Question, &pair.Answer); err != nil {
+			return nil, fmt.Errorf("error reading flight QA: %w", err)
+		}
+		qa[flightID] = append(qa[flightID], pair)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading flight QA: %w", err)
+	}
+	return qa, nil
+}
+
+// UpdateFlight rewrites the flight row and replaces its QA pairs in a single transaction
+func (r *sqlFlightRepository) UpdateFlight(ctx context.Context, flight *domain.Flight) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	flight.Date = flight.Date.UTC()
+
+	updated := *flight
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stored, err := r.storedVersion(ctx, tx, flight.UserID, flight.ID, flight.Version)
+		if err != nil {
+			return err
+		}
+		updated.Version = stored + 1
+		updated.UpdatedAt = writeTime()
+		result, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`UPDATE flights SET `+flightAssignments+` WHERE id = ? AND user_id = ? AND version = ?`),
+			append(flightValues(&updated)[1:], flight.ID, flight.UserID, stored)...)
+		if err != nil {
+			return err
+		}
+		// A concurrent writer moved the version between the read and the write
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrVersionConflict
+		}
+		return r.replaceQA(ctx, tx, flight)
+	})
+	if errors.Is(err, domain.ErrFlightNotFound) || errors.Is(err, domain.ErrVersionConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error updating flight: %w", err)
+	}
+	flight.Version = updated.Version
+	flight.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+// ResealFlight replaces the QA pairs of a flight in a single transaction without touching its row, which is
+// locked by a no-op update so a concurrent edit cannot slip in between the version check and the rewrite
+func (r *sqlFlightRepository) ResealFlight(ctx context.Context, flight *domain.Flight) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stored, err := r.storedVersion(ctx, tx, flight.UserID, flight.ID, flight.Version)
+		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`UPDATE flights SET version = version WHERE id = ? AND user_id = ? AND version = ?`),
+			flight.ID, flight.UserID, stored)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrVersionConflict
+		}
+		return r.replaceQA(ctx, tx, flight)
+	})
+	if errors.Is(err, domain.ErrFlightNotFound) || errors.Is(err, domain.ErrVersionConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error resealing flight: %w", err)
+	}
+	return nil
+}
+
+// GetFlightChanges reads the user's flights and tombstones after the cursor and merges them into feed order
+func (r *sqlFlightRepository) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) ([]domain.FlightChange, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	since := after.UpdatedAt.UTC()
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+flightColumns+` FROM flights WHERE user_id = ? AND (updated_at > ? OR (updated_at = ? AND id > ?)) `+
+			`ORDER BY updated_at, id LIMIT ?`),
+		userID, since, since, after.FlightID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error finding flight changes: %w", err)
+	}
+	flights, err := scanFlights(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]domain.FlightChange, 0, len(flights))
+	if len(flights) > 0 {
+		ids := make([]any, len(flights))
+		for i, flight := range flights {
+			ids[i] = flight.ID
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+		qa, err := r.loadQA(ctx, `WHERE flight_id IN (`+placeholders+`)`, ids...)
+		if err != nil {
+			return nil, err
+		}
+		for _, flight := range flights {
+			flight.QA = qa[flight.ID]
+			changes = append(changes, flightChange(flight))
+		}
+	}
+
+	rows, err = r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT flight_id, version, deleted_at FROM flight_tombstones `+
+			`WHERE user_id = ? AND (deleted_at > ? OR (deleted_at = ? AND flight_id > ?)) ORDER BY deleted_at, flight_id LIMIT ?`),
+		userID, since, since, after.FlightID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error finding flight tombstones: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		change := domain.FlightChange{Deleted: true}
+		if err := rows.Scan(&change.FlightID, &change.Version, &change.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error reading flight tombstone: %w", err)
+		}
+		change.UpdatedAt = change.UpdatedAt.UTC()
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading flight tombstones: %w", err)
+	}
+	return mergeChanges(changes, limit), nil
+}
+
+// GetFlightByClientRef retrieves the user's flight created with the given client reference and its QA pairs
+func (r *sqlFlightRepository) GetFlightByClientRef(ctx context.Context, userID, clientRef string) (*domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	flight, err := scanFlight(r.db.QueryRowContext(ctx,
+		r.dialect.rebind(`SELECT `+flightColumns+` FROM flights WHERE user_id = ? AND client_ref = ?`), userID, clientRef))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrFlightNotFound
+		}
+		return nil, fmt.Errorf("error finding flight: %w", err)
+	}
+	flight.ClientRef = clientRef
+
+	qa, err := r.loadQA(ctx, `WHERE flight_id = ?`, flight.ID)
+	if err != nil {
+		return nil, err
+	}
+	flight.QA = qa[flight.ID]
+	return flight, nil
+}
+
+// PruneTombstones deletes the tombstones of flights deleted before the given time
+func (r *sqlFlightRepository) PruneTombstones(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM flight_tombstones WHERE deleted_at < ?`), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning flight tombstones: %w", err)
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error pruning flight tombstones: %w", err)
+	}
+	return int(pruned), nil
+}
+
+// ListFlights pages through every flight ordered by ID
+func (r *sqlFlightRepository) ListFlights(ctx context.Context, afterID string, limit int) ([]domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+flightColumns+` FROM flights WHERE id > ? ORDER BY id LIMIT ?`), afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing flights: %w", err)
+	}
+
+	flights, err := scanFlights(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(flights) == 0 {
+		return nil, nil
+	}
+
+	qa, err := r.loadQA(ctx, `WHERE flight_id >= ? AND flight_id <= ?`, flights[0].ID, flights[len(flights)-1].ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range flights {
+		flights[i].QA = qa[flights[i].ID]
+	}
+	return flights, nil
+}
+
+// replaceQA swaps the stored QA pairs of a flight for the ones it currently holds
+func (r *sqlFlightRepository) replaceQA(ctx context.Context, tx *sql.Tx, flight *domain.Flight) error {
+	if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM flight_qa WHERE flight_id = ?`), flight.ID); err != nil {
+		return err
+	}
+	return r.insertQA(ctx, tx, flight)
+}
+
+// insertQA stores the flight's QA pairs in order
+func (r *sqlFlightRepository) insertQA(ctx context.Context, tx *sql.Tx, flight *domain.Flight) error {
+	for i, qa := range flight.QA {
+		_, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`INSERT INTO flight_qa (flight_id, position, question_id, question, answer) VALUES (?, ?, ?, ?, ?)`),
+			flight.ID, i, qa.QuestionID, qa.Question, qa.Answer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFlightExpiry sets or clears the expiry columns of a flight
+func (r *sqlFlightRepository) SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`UPDATE flights SET expires_at = ?, expiry_warned_at = ? WHERE id = ?`),
+		utcTime(expiresAt), utcTime(warnedAt), id)
+	if err != nil {
+		return fmt.Errorf("error updating flight expiry: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrFlightNotFound
+	}
+	return nil
+}
+
+// FindExpiringFlights returns flights whose expiry is at or before the given time, soonest first
+func (r *sqlFlightRepository) FindExpiringFlights(ctx context.Context, before time.Time) ([]domain.Flight, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+flightColumns+` FROM flights WHERE expires_at <= ? ORDER BY expires_at, id`), before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error finding expiring flights: %w", err)
+	}
+	flights, err := scanFlights(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	qa, err := r.loadQA(ctx, `WHERE flight_id IN (SELECT id FROM flights WHERE expires_at <= ?)`, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	for i := range flights {
+		flights[i].QA = qa[flights[i].ID]
+	}
+	return flights, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFlight reads one row selected with flightColumns
+func scanFlight(row rowScanner) (*domain.Flight, error) {
+	var flight domain.Flight
+	var expiresAt, warnedAt, departure, arrival sql.NullTime
+	err := row.Scan(&flight.ID, &flight.Title, &flight.FromCountry, &flight.ToCountry, &flight.Date, &flight.UserID, &flight.Language,
+		&expiresAt, &warnedAt,
+		&flight.FlightNumber, &flight.AirlineCode, &flight.OriginAirport, &flight.DestinationAirport,
+		&departure, &flight.DepartureTimezone, &arrival, &flight.ArrivalTimezone,
+		&flight.Version, &flight.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	flight.Date = flight.Date.UTC()
+	flight.UpdatedAt = flight.UpdatedAt.UTC()
+	flight.ExpiresAt = nullTime(expiresAt)
+	flight.ExpiryWarnedAt = nullTime(warnedAt)
+	flight.DepartureTime = nullTime(departure)
+	flight.ArrivalTime = nullTime(arrival)
+	return &flight, nil
+}
+
+// scanFlights reads and closes rows selected with flightColumns
+func scanFlights(rows *sql.Rows) ([]domain.Flight, error) {
+	defer rows.Close()
+
+	var flights []domain.Flight
+	for rows.Next() {
+		flight, err := scanFlight(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error reading flight: %w", err)
+		}
+		flights = append(flights, *flight)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading flights: %w", err)
+	}
+	return flights, nil
+}
+
+// flightValues returns the flight's fields in flightColumns order
+func flightValues(flight *domain.Flight) []any {
+	return []any{flight.ID, flight.Title, flight.FromCountry, flight.ToCountry, flight.Date, flight.UserID, flight.Language,
+		utcTime(flight.ExpiresAt), utcTime(flight.ExpiryWarnedAt),
+		flight.FlightNumber, flight.AirlineCode, flight.OriginAirport, flight.DestinationAirport,
+		utcTime(flight.DepartureTime), flight.DepartureTimezone, utcTime(flight.ArrivalTime), flight.ArrivalTimezone,
+		flight.Version, flight.UpdatedAt.UTC()}
+}
+
+// utcTime converts an optional time into a UTC value or SQL NULL
+func utcTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// nullString stores an empty string as SQL NULL, which unique indexes do not compare
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullTime converts a nullable column into an optional UTC time
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
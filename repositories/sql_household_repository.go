@@ -0,0 +1,288 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// memberColumns lists the household_members columns in the order scanMember reads them
+const memberColumns = `household_id, user_id, name, role, status, added_at`
+
+// sqlHouseholdRepository is the database/sql implementation of the HouseholdRepository interface
+type sqlHouseholdRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	timeouts Timeouts
+}
+
+// NewSQLHouseholdRepository initializes a household repository backed by PostgreSQL or SQLite
+func NewSQLHouseholdRepository(db *sql.DB, dialect Dialect, timeouts Timeouts) domain.HouseholdRepository {
+	return &sqlHouseholdRepository{
+		db:       db,
+		dialect:  dialect,
+		timeouts: timeouts,
+	}
+}
+
+// CreateHousehold inserts the household and its initial members in one transaction
+func (r *sqlHouseholdRepository) CreateHousehold(ctx context.Context, household *domain.Household) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if household.ID == "" {
+		household.ID = newID()
+	}
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`INSERT INTO households (id, name, created_at) VALUES (?, ?, ?)`),
+			household.ID, household.Name, household.CreatedAt.UTC())
+		if err != nil {
+			return err
+		}
+		for _, member := range household.Members {
+			if err := r.insertMember(ctx, tx, household.ID, member); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error creating household: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlHouseholdRepository) GetHouseholdByID(ctx context.Context, id string) (*domain.Household, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	households, err := r.find(ctx, `WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(households) == 0 {
+		return nil, domain.ErrHouseholdNotFound
+	}
+	return &households[0], nil
+}
+
+func (r *sqlHouseholdRepository) GetHouseholdsByUserID(ctx context.Context, userID string) ([]domain.Household, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	return r.find(ctx, `WHERE id IN (SELECT household_id FROM household_members WHERE user_id = ?)`, userID)
+}
+
+// DeleteHousehold removes a household and its members
+func (r *sqlHouseholdRepository) DeleteHousehold(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	var deleted int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM household_members WHERE household_id = ?`), id); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM households WHERE id = ?`), id)
+		if err != nil {
+			return err
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting household: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrHouseholdNotFound
+	}
+	return nil
+}
+
+// AddHouseholdMember relies on the primary key to reject a user who is already listed
+func (r *sqlHouseholdRepository) AddHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if err := r.exists(ctx, householdID); err != nil {
+		return err
+	}
+	if err := r.insertMember(ctx, r.db, householdID, member); err != nil {
+		if msg := strings.ToLower(err.Error()); strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate key") {
+			return domain.ErrMemberExists
+		}
+		return fmt.Errorf("error adding household member: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlHouseholdRepository) UpdateHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) error {
+	return r.execMember(ctx, householdID,
+		`UPDATE household_members SET role = ?, status = ? WHERE household_id = ? AND user_id = ?`,
+		string(member.Role), string(member.Status), householdID, member.UserID)
+}
+
+// RemoveHouseholdMember locks the household row with a no-op update, so concurrent removals queue up and each
+// checks the guardians left by the one before it
+func (r *sqlHouseholdRepository) RemoveHouseholdMember(ctx context.Context, householdID, userID string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE households SET id = id WHERE id = ?`), householdID)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrHouseholdNotFound
+		}
+
+		household := domain.Household{ID: householdID}
+		rows, err := tx.QueryContext(ctx,
+			r.dialect.rebind(`SELECT user_id, role, status FROM household_members WHERE household_id = ?`), householdID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var member domain.HouseholdMember
+			var role, status string
+			if err := rows.Scan(&member.UserID, &role, &status); err != nil {
+				rows.Close()
+				return err
+			}
+			member.Role, member.Status = domain.HouseholdRole(role), domain.MemberStatus(status)
+			household.Members = append(household.Members, member)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if _, ok := household.Member(userID); !ok {
+			return domain.ErrMemberNotFound
+		}
+		if !household.CanRemove(userID) {
+			return domain.ErrLastGuardian
+		}
+		if _, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`DELETE FROM household_members WHERE household_id = ? AND user_id = ?`), householdID, userID); err != nil {
+			return err
+		}
+		if len(household.Members) == 1 {
+			_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM households WHERE id = ?`), householdID)
+		}
+		return err
+	})
+	if errors.Is(err, domain.ErrHouseholdNotFound) || errors.Is(err, domain.ErrMemberNotFound) || errors.Is(err, domain.ErrLastGuardian) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error removing household member: %w", err)
+	}
+	return nil
+}
+
+// execMember runs a change to a single member, telling a missing household from a missing member when no
+// row matches
+func (r *sqlHouseholdRepository) execMember(ctx context.Context, householdID, query string, args ...any) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("error updating household member: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		if err := r.exists(ctx, householdID); err != nil {
+			return err
+		}
+		return domain.ErrMemberNotFound
+	}
+	return nil
+}
+
+// exists reports ErrHouseholdNotFound when there is no household with the given ID
+func (r *sqlHouseholdRepository) exists(ctx context.Context, id string) error {
+	var found string
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT id FROM households WHERE id = ?`), id).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrHouseholdNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error finding household: %w", err)
+	}
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (r *sqlHouseholdRepository) insertMember(ctx context.Context, db execer, householdID string, member domain.HouseholdMember) error {
+	_, err := db.ExecContext(ctx,
+		r.dialect.rebind(`INSERT INTO household_members (`+memberColumns+`) VALUES (?, ?, ?, ?, ?, ?)`),
+		householdID, member.UserID, member.Name, string(member.Role), string(member.Status), member.AddedAt.UTC())
+	return err
+}
+
+// find loads the households matching where, oldest first, then attaches their members in one query
+func (r *sqlHouseholdRepository) find(ctx context.Context, where string, args ...any) ([]domain.Household, error) {
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT id, name, created_at FROM households `+where+` ORDER BY created_at, id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding households: %w", err)
+	}
+	var households []domain.Household
+	index := map[string]int{}
+	for rows.Next() {
+		var household domain.Household
+		if err := rows.Scan(&household.ID, &household.Name, &household.CreatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading household: %w", err)
+		}
+		household.CreatedAt = household.CreatedAt.UTC()
+		household.Members = []domain.HouseholdMember{}
+		index[household.ID] = len(households)
+		households = append(households, household)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading households: %w", err)
+	}
+	if len(households) == 0 {
+		return households, nil
+	}
+
+	rows, err = r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+memberColumns+` FROM household_members WHERE household_id IN (SELECT id FROM households `+where+`) ORDER BY added_at, user_id`),
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding household members: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var householdID, role, status string
+		var member domain.HouseholdMember
+		if err := rows.Scan(&householdID, &member.UserID, &member.Name, &role, &status, &member.AddedAt); err != nil {
+			return nil, fmt.Errorf("error reading household member: %w", err)
+		}
+		member.Role = domain.HouseholdRole(role)
+		member.Status = domain.MemberStatus(status)
+		member.AddedAt = member.AddedAt.UTC()
+		if i, ok := index[householdID]; ok {
+			households[i].Members = append(households[i].Members, member)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading household members: %w", err)
+	}
+	return households, nil
+}
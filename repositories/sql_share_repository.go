@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// shareColumns lists the flight_shares columns in the order scanShare reads them
+const shareColumns = `id, flight_id, user_id, label, created_at, expires_at, revoked_at, access_count, last_accessed_at`
+
+// sqlShareRepository is the database/sql implementation of the ShareRepository interface
+type sqlShareRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	timeouts Timeouts
+}
+
+// NewSQLShareRepository initializes a share repository backed by PostgreSQL or SQLite
+func NewSQLShareRepository(db *sql.DB, dialect Dialect, timeouts Timeouts) domain.ShareRepository {
+	return &sqlShareRepository{
+		db:       db,
+		dialect:  dialect,
+		timeouts: timeouts,
+	}
+}
+
+func (r *sqlShareRepository) CreateShare(ctx context.Context, share *domain.FlightShare) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	if share.ID == "" {
+		share.ID = newID()
+	}
+	_, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`INSERT INTO flight_shares (`+shareColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		share.ID, share.FlightID, share.UserID, share.Label, share.CreatedAt.UTC(), share.ExpiresAt.UTC(),
+		utcTime(share.RevokedAt), share.AccessCount, utcTime(share.LastAccessedAt))
+	if err != nil {
+		return fmt.Errorf("error creating share: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlShareRepository) GetShareByID(ctx context.Context, id string) (*domain.FlightShare, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	share, err := scanShare(r.db.QueryRowContext(ctx,
+		r.dialect.rebind(`SELECT `+shareColumns+` FROM flight_shares WHERE id = ?`), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding share: %w", err)
+	}
+	return share, nil
+}
+
+func (r *sqlShareRepository) GetSharesByFlightID(ctx context.Context, flightID string) ([]domain.FlightShare, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT `+shareColumns+` FROM flight_shares WHERE flight_id = ? ORDER BY created_at DESC, id DESC`), flightID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []domain.FlightShare
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error reading share: %w", err)
+		}
+		shares = append(shares, *share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading shares: %w", err)
+	}
+	return shares, nil
+}
+
+func (r *sqlShareRepository) RevokeShare(ctx context.Context, id string, revokedAt time.Time) error {
+	return r.exec(ctx, `UPDATE flight_shares SET revoked_at = ? WHERE id = ?`, revokedAt.UTC(), id)
+}
+
+// RecordShareAccess increments the counter in the database so concurrent openings are all counted
+func (r *sqlShareRepository) RecordShareAccess(ctx context.Context, id string, accessedAt time.Time) error {
+	return r.exec(ctx, `UPDATE flight_shares SET access_count = access_count + 1, last_accessed_at = ? WHERE id = ?`,
+		accessedAt.UTC(), id)
+}
+
+// exec runs an update of a single share, reporting ErrShareNotFound when no row matches
+func (r *sqlShareRepository) exec(ctx context.Context, query string, args ...any) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("error updating share: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrShareNotFound
+	}
+	return nil
+}
+
+// scanShare reads one row selected with shareColumns
+func scanShare(row rowScanner) (*domain.FlightShare, error) {
+	var share domain.FlightShare
+	var revokedAt, accessedAt sql.NullTime
+	err := row.Scan(&share.ID, &share.FlightID, &share.UserID, &share.Label, &share.CreatedAt, &share.ExpiresAt,
+		&revokedAt, &share.AccessCount, &accessedAt)
+	if err != nil {
+		return nil, err
+	}
+	share.CreatedAt = share.CreatedAt.UTC()
+	share.ExpiresAt = share.ExpiresAt.UTC()
+	share.RevokedAt = nullTime(revokedAt)
+	share.LastAccessedAt = nullTime(accessedAt)
+	return &share, nil
+}
@@ -0,0 +1,365 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" driver
+)
+
+// Dialect identifies the SQL database behind a *sql.DB
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// OpenSQL opens and pings a database for the given dialect
+func OpenSQL(ctx context.Context, dialect Dialect, dsn string) (*sql.DB, error) {
+	var driver string
+	switch dialect {
+	case DialectSQLite:
+		driver = "sqlite"
+		dsn = sqliteDSN(dsn)
+	case DialectPostgres:
+		driver = "pgx"
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		// SQLite allows a single writer; serialising connections avoids "database is locked" errors
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteDSN turns on foreign key enforcement, which SQLite leaves off on every new connection, unless the
+// DSN already sets it
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "foreign_keys") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&_pragma=foreign_keys(1)"
+	}
+	return dsn + "?_pragma=foreign_keys(1)"
+}
+
+// rebind rewrites ? placeholders into the dialect's native form
+func (d Dialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLMigration is a single versioned change to the SQL schema
+type SQLMigration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// sqlMigrations lists every SQL schema change in the order it must be applied
+var sqlMigrations = []SQLMigration{
+	{
+		Version:     1,
+		Description: "users table with unique email and username",
+		Statements: []string{
+			`CREATE TABLE users (
+				id TEXT PRIMARY KEY,
+				username TEXT NOT NULL,
+				password TEXT NOT NULL,
+				email TEXT NOT NULL,
+				CONSTRAINT users_email_unique UNIQUE (email),
+				CONSTRAINT users_username_unique UNIQUE (username)
+			)`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "flights table with QA pairs in a child table",
+		Statements: []string{
+			`CREATE TABLE flights (
+				id TEXT PRIMARY KEY,
+				title TEXT NOT NULL,
+				from_country TEXT NOT NULL,
+				to_country TEXT NOT NULL,
+				date TIMESTAMP NOT NULL,
+				user_id TEXT NOT NULL,
+				language TEXT NOT NULL
+			)`,
+			`CREATE INDEX flights_user_id_date ON flights (user_id, date)`,
+			`CREATE TABLE flight_qa (
+				flight_id TEXT NOT NULL REFERENCES flights (id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				question TEXT NOT NULL,
+				answer TEXT NOT NULL,
+				PRIMARY KEY (flight_id, position)
+			)`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "data_keys table for per-user wrapped encryption keys",
+		Statements: []string{
+			`CREATE TABLE data_keys (
+				user_id TEXT NOT NULL,
+				version INTEGER NOT NULL,
+				master_key_id TEXT NOT NULL,
+				wrapped_key BYTEA NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (user_id, version)
+			)`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "per-user retention and flight expiry columns",
+		Statements: []string{
+			`ALTER TABLE users ADD COLUMN retention_days INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE flights ADD COLUMN expires_at TIMESTAMP`,
+			`ALTER TABLE flights ADD COLUMN expiry_warned_at TIMESTAMP`,
+			`CREATE INDEX flights_expires_at ON flights (expires_at)`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "trips with ordered legs and per-leg QA pairs",
+		Statements: []string{
+			`CREATE TABLE trips (
+				id TEXT PRIMARY KEY,
+				title TEXT NOT NULL,
+				user_id TEXT NOT NULL,
+				start_date TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX trips_user_id_start_date ON trips (user_id, start_date)`,
+			`CREATE TABLE trip_legs (
+				trip_id TEXT NOT NULL REFERENCES trips (id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				from_country TEXT NOT NULL,
+				to_country TEXT NOT NULL,
+				date TIMESTAMP NOT NULL,
+				language TEXT NOT NULL,
+				PRIMARY KEY (trip_id, position)
+			)`,
+			`CREATE TABLE trip_leg_qa (
+				trip_id TEXT NOT NULL REFERENCES trips (id) ON DELETE CASCADE,
+				leg INTEGER NOT NULL,
+				position INTEGER NOT NULL,
+				question TEXT NOT NULL,
+				answer TEXT NOT NULL,
+				PRIMARY KEY (trip_id, leg, position)
+			)`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "flight number, airline, airports and zoned departure and arrival times",
+		Statements: []string{
+			`ALTER TABLE flights ADD COLUMN flight_number TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE flights ADD COLUMN airline_code TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE flights ADD COLUMN origin_airport TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE flights ADD COLUMN destination_airport TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE flights ADD COLUMN departure_time TIMESTAMP`,
+			`ALTER TABLE flights ADD COLUMN departure_timezone TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE flights ADD COLUMN arrival_time TIMESTAMP`,
+			`ALTER TABLE flights ADD COLUMN arrival_timezone TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "calendar feed token hash on users",
+		Statements: []string{
+			`ALTER TABLE users ADD COLUMN calendar_token_hash TEXT`,
+			`CREATE UNIQUE INDEX ` + userCalendarIndex + ` ON users (calendar_token_hash)`,
+		},
+	},
+	{
+		Version:     8,
+		Description: "answer profiles and template question IDs on QA pairs",
+		Statements: []string{
+			`CREATE TABLE user_answers (
+				user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+				question_id TEXT NOT NULL,
+				answer TEXT NOT NULL,
+				updated_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (user_id, question_id)
+			)`,
+			`ALTER TABLE flight_qa ADD COLUMN question_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE trip_leg_qa ADD COLUMN question_id TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		Version:     9,
+		Description: "flight_shares table for read-only share links",
+		Statements: []string{
+			`CREATE TABLE flight_shares (
+				id TEXT PRIMARY KEY,
+				flight_id TEXT NOT NULL REFERENCES flights (id) ON DELETE CASCADE,
+				user_id TEXT NOT NULL,
+				label TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				expires_at TIMESTAMP NOT NULL,
+				revoked_at TIMESTAMP,
+				access_count INTEGER NOT NULL DEFAULT 0,
+				last_accessed_at TIMESTAMP
+			)`,
+			`CREATE INDEX ` + shareFlightIndex + ` ON flight_shares (flight_id, created_at)`,
+		},
+	},
+	{
+		Version:     10,
+		Description: "households and household_members tables",
+		Statements: []string{
+			`CREATE TABLE households (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE household_members (
+				household_id TEXT NOT NULL REFERENCES households (id) ON DELETE CASCADE,
+				user_id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				role TEXT NOT NULL,
+				status TEXT NOT NULL,
+				added_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (household_id, user_id)
+			)`,
+			`CREATE INDEX ` + householdMemberIndex + ` ON household_members (user_id)`,
+		},
+	},
+	{
+		Version:     11,
+		Description: "flight versions and the flight_tombstones change feed",
+		Statements: []string{
+			`ALTER TABLE flights ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+			`ALTER TABLE flights ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00'`,
+			`CREATE INDEX ` + flightChangesIndex + ` ON flights (user_id, updated_at, id)`,
+			`CREATE TABLE flight_tombstones (
+				flight_id TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL,
+				version INTEGER NOT NULL,
+				deleted_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX ` + tombstoneUserIndex + ` ON flight_tombstones (user_id, deleted_at, flight_id)`,
+		},
+	},
+	{
+		Version:     12,
+		Description: "user versions",
+		Statements: []string{
+			`ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		Version:     13,
+		Description: "trip versions and expiry columns",
+		Statements: []string{
+			`ALTER TABLE trips ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+			`ALTER TABLE trips ADD COLUMN expires_at TIMESTAMP`,
+			`ALTER TABLE trips ADD COLUMN expiry_warned_at TIMESTAMP`,
+			`CREATE INDEX ` + tripExpiryIndex + ` ON trips (expires_at)`,
+		},
+	},
+	{
+		Version:     14,
+		Description: "flight client_ref for sync retries and an index on flight_tombstones.deleted_at for pruning",
+		Statements: []string{
+			`ALTER TABLE flights ADD COLUMN client_ref TEXT`,
+			`CREATE UNIQUE INDEX ` + flightClientRefIndex + ` ON flights (user_id, client_ref)`,
+			`CREATE INDEX ` + tombstoneAgeIndex + ` ON flight_tombstones (deleted_at)`,
+		},
+	},
+}
+
+// RunSQLMigrations applies every SQL migration not yet recorded in schema_migrations and returns the ones it applied
+func RunSQLMigrations(ctx context.Context, db *sql.DB, dialect Dialect) ([]SQLMigration, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	done := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+		}
+		done[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+
+	var applied []SQLMigration
+	for _, m := range sqlMigrations {
+		if done[m.Version] {
+			continue
+		}
+		err := inTx(ctx, db, func(tx *sql.Tx) error {
+			for _, stmt := range m.Statements {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx,
+				dialect.rebind(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`),
+				m.Version, m.Description, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// inTx runs fn inside a transaction, committing on success and rolling back otherwise
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
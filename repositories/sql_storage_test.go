@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenSQLEnforcesForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	for _, dsn := range []string{":memory:", filepath.Join(t.TempDir(), "passme.db") + "?_pragma=busy_timeout(5000)"} {
+		db, err := OpenSQL(ctx, DialectSQLite, dsn)
+		if err != nil {
+			t.Fatalf("open sqlite %q: %v", dsn, err)
+		}
+		defer db.Close()
+
+		var enabled int
+		if err := db.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&enabled); err != nil {
+			t.Fatalf("read foreign_keys pragma: %v", err)
+		}
+		if enabled != 1 {
+			t.Errorf("%q: foreign_keys = %d, want 1", dsn, enabled)
+		}
+		if _, err := RunSQLMigrations(ctx, db, DialectSQLite); err != nil {
+			t.Fatalf("RunSQLMigrations: %v", err)
+		}
+		_, err = db.ExecContext(ctx, `INSERT INTO flight_shares (id, flight_id, user_id, label, created_at, expires_at) VALUES ('s1', 'missing', 'u1', '', ?, ?)`,
+			time.Now().UTC(), time.Now().UTC())
+		if err == nil {
+			t.Errorf("%q: a share of a missing flight was stored", dsn)
+		}
+	}
+}
+
+func TestRunSQLMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQL(ctx, DialectSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	applied, err := RunSQLMigrations(ctx, db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("RunSQLMigrations: %v", err)
+	}
+	if len(applied) != len(sqlMigrations) {
+		t.Fatalf("applied %d migrations on a fresh database, want all %d", len(applied), len(sqlMigrations))
+	}
+	for i, m := range applied {
+		if m.Version != i+1 {
+			t.Fatalf("migration %d has version %d; versions must run 1, 2, 3... in order", i, m.Version)
+		}
+	}
+	if latest := applied[len(applied)-1].Version; latest < 12 {
+		t.Errorf("latest migration is %d, want at least 12", latest)
+	}
+
+	var recorded int
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&recorded); err != nil {
+		t.Fatalf("read schema_migrations: %v", err)
+	}
+	if recorded != len(sqlMigrations) {
+		t.Errorf("schema_migrations records version %d, want %d", recorded, len(sqlMigrations))
+	}
+
+	again, err := RunSQLMigrations(ctx, db, DialectSQLite)
+	if err != nil || len(again) != 0 {
+		t.Errorf("second run applied %d migrations (%v), want none", len(again), err)
+	}
+}
+
+// TestRunSQLMigrationsUpgrade stops at the original schema, writes rows the way the first release did, and
+// checks that every later migration backfills them so the repositories read them like new rows
+func TestRunSQLMigrationsUpgrade(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQL(ctx, DialectSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	all := sqlMigrations
+	sqlMigrations = all[:2]
+	_, err = RunSQLMigrations(ctx, db, DialectSQLite)
+	sqlMigrations = all
+	if err != nil {
+		t.Fatalf("RunSQLMigrations up to 2: %v", err)
+	}
+
+	legacy := []string{
+		`INSERT INTO users (id, username, password, email) VALUES ('u1', 'ada', 'hash', 'ada@example.com')`,
+		`INSERT INTO flights (id, title, from_country, to_country, date, user_id, language)
+			VALUES ('f1', 'Lisbon', 'US', 'PT', '2026-12-01 10:00:00+00:00', 'u1', 'pt')`,
+		`INSERT INTO flight_qa (flight_id, position, question, answer) VALUES ('f1', 0, 'Passport number?', 'P1234567')`,
+	}
+	for _, stmt := range legacy {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("seed legacy rows: %v", err)
+		}
+	}
+
+	applied, err := RunSQLMigrations(ctx, db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("RunSQLMigrations: %v", err)
+	}
+	if len(applied) != len(all)-2 || applied[0].Version != 3 {
+		t.Fatalf("upgrade applied %d migrations starting at %d, want %d starting at 3", len(applied), applied[0].Version, len(all)-2)
+	}
+
+	user, err := NewSQLUserRepository(db, DialectSQLite, DefaultTimeouts).FindUserByID(ctx, "u1")
+	if err != nil {
+		t.Fatalf("FindUserByID: %v", err)
+	}
+	if user.Version != 1 || user.RetentionDays != 0 || user.CalendarTokenHash != "" {
+		t.Errorf("legacy user = %+v, want version 1, no retention and no calendar feed", user)
+	}
+
+	flight, err := NewSQLFlightRepository(db, DialectSQLite, DefaultTimeouts).GetFlightByID(ctx, "u1", "f1")
+	if err != nil {
+		t.Fatalf("GetFlightByID: %v", err)
+	}
+	if flight.Version != 1 || !flight.UpdatedAt.Equal(time.Unix(0, 0)) || flight.ExpiresAt != nil {
+		t.Errorf("legacy flight has version %d, updated_at %v and expiry %v; want 1, the epoch and none",
+			flight.Version, flight.UpdatedAt, flight.ExpiresAt)
+	}
+	if len(flight.QA) != 1 || flight.QA[0].Answer != "P1234567" {
+		t.Errorf("legacy flight QA = %+v", flight.QA)
+	}
+}
@@ -0,0 +1,393 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// tripColumns lists the trip columns in the order scanTrip reads them
+const tripColumns = `id, title, user_id, expires_at, expiry_warned_at, version`
+
+// sqlTripRepository is the database/sql implementation of the TripRepository interface
+type sqlTripRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	timeouts Timeouts
+}
+
+// NewSQLTripRepository initializes a trip repository backed by PostgreSQL or SQLite
+func NewSQLTripRepository(db *sql.DB, dialect Dialect, timeouts Timeouts) domain.TripRepository {
+	return &sqlTripRepository{
+		db:       db,
+		dialect:  dialect,
+		timeouts: timeouts,
+	}
+}
+
+// CreateTrip inserts the trip at version 1 with its legs and their QA pairs in a single transaction
+func (r *sqlTripRepository) CreateTrip(ctx context.Context, trip *domain.Trip) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	normalizeTrip(trip)
+	if trip.ID == "" {
+		trip.ID = newID()
+	}
+	trip.Version = 1
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`INSERT INTO trips (id, title, user_id, start_date, expires_at, expiry_warned_at, version) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+			trip.ID, trip.Title, trip.UserID, trip.StartDate(), utcTime(trip.ExpiresAt), utcTime(trip.ExpiryWarnedAt), trip.Version)
+		if err != nil {
+			return err
+		}
+		return r.insertLegs(ctx, tx, trip)
+	})
+	if err != nil {
+		return fmt.Errorf("error creating trip: %w", err)
+	}
+	return nil
+}
+
+// GetTripByID retrieves one of the user's trips with its legs by ID
+func (r *sqlTripRepository) GetTripByID(ctx context.Context, userID, id string) (*domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	trip, err := scanTrip(r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+tripColumns+` FROM trips WHERE id = ? AND user_id = ?`), id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrTripNotFound
+		}
+		return nil, fmt.Errorf("error finding trip: %w", err)
+	}
+
+	legs, err := r.loadLegs(ctx, `WHERE trip_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	trip.Legs = legs[id]
+	return trip, nil
+}
+
+// GetTripsByUserID retrieves all trips of a user ordered by the date of their first leg
+func (r *sqlTripRepository) GetTripsByUserID(ctx context.Context, userID string) ([]domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	trips, err := r.queryTrips(ctx, `WHERE user_id = ? ORDER BY start_date, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	legs, err := r.loadLegs(ctx, `WHERE trip_id IN (SELECT id FROM trips WHERE user_id = ?)`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trips {
+		trips[i].Legs = legs[trips[i].ID]
+	}
+	return trips, nil
+}
+
+// UpdateTrip rewrites the trip row and replaces its legs in a single transaction, matching on the version it
+// read so a concurrent write is not lost
+func (r *sqlTripRepository) UpdateTrip(ctx context.Context, trip *domain.Trip) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	normalizeTrip(trip)
+
+	var version int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stored, err := r.storedVersion(ctx, tx, trip.UserID, trip.ID, trip.Version)
+		if err != nil {
+			return err
+		}
+		version = stored + 1
+		result, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`UPDATE trips SET title = ?, start_date = ?, expires_at = ?, expiry_warned_at = ?, version = ? `+
+				`WHERE id = ? AND user_id = ? AND version = ?`),
+			trip.Title, trip.StartDate(), utcTime(trip.ExpiresAt), utcTime(trip.ExpiryWarnedAt), version, trip.ID, trip.UserID, stored)
+		if err != nil {
+			return err
+		}
+		// A concurrent writer moved the version between the read and the write
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrVersionConflict
+		}
+		if err := r.deleteLegs(ctx, tx, trip.ID); err != nil {
+			return err
+		}
+		return r.insertLegs(ctx, tx, trip)
+	})
+	if errors.Is(err, domain.ErrTripNotFound) || errors.Is(err, domain.ErrVersionConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error updating trip: %w", err)
+	}
+	trip.Version = version
+	return nil
+}
+
+// ResealTrip replaces the legs of a trip in a single transaction without touching its row, which is locked by
+// a no-op update so a concurrent edit cannot slip in between the version check and the rewrite
+func (r *sqlTripRepository) ResealTrip(ctx context.Context, trip *domain.Trip) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		stored, err := r.storedVersion(ctx, tx, trip.UserID, trip.ID, trip.Version)
+		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`UPDATE trips SET version = version WHERE id = ? AND user_id = ? AND version = ?`),
+			trip.ID, trip.UserID, stored)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrVersionConflict
+		}
+		if err := r.deleteLegs(ctx, tx, trip.ID); err != nil {
+			return err
+		}
+		return r.insertLegs(ctx, tx, trip)
+	})
+	if errors.Is(err, domain.ErrTripNotFound) || errors.Is(err, domain.ErrVersionConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error resealing trip: %w", err)
+	}
+	return nil
+}
+
+// DeleteTrip removes one of the user's trips with its legs and their QA pairs if its version still matches
+func (r *sqlTripRepository) DeleteTrip(ctx context.Context, userID, id string, version int64) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Check ownership first so another user's legs are never touched
+		stored, err := r.storedVersion(ctx, tx, userID, id, version)
+		if err != nil {
+			return err
+		}
+		if err := r.deleteLegs(ctx, tx, id); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM trips WHERE id = ? AND user_id = ? AND version = ?`), id, userID, stored)
+		if err != nil {
+			return err
+		}
+		// A concurrent writer moved the version between the read and the delete; the rollback restores the legs
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return domain.ErrVersionConflict
+		}
+		return nil
+	})
+	if errors.Is(err, domain.ErrTripNotFound) || errors.Is(err, domain.ErrVersionConflict) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting trip: %w", err)
+	}
+	return nil
+}
+
+// storedVersion reads the version of one of the user's trips inside tx and checks it against an expected
+// non-zero version
+func (r *sqlTripRepository) storedVersion(ctx context.Context, tx *sql.Tx, userID, id string, expected int64) (int64, error) {
+	var stored int64
+	err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT version FROM trips WHERE id = ? AND user_id = ?`), id, userID).Scan(&stored)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, domain.ErrTripNotFound
+	case err != nil:
+		return 0, err
+	case expected != 0 && expected != stored:
+		return 0, domain.ErrVersionConflict
+	}
+	return stored, nil
+}
+
+// ListTrips pages through every trip ordered by ID
+func (r *sqlTripRepository) ListTrips(ctx context.Context, afterID string, limit int) ([]domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	trips, err := r.queryTrips(ctx, `WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit)
+	if err != nil || len(trips) == 0 {
+		return nil, err
+	}
+	legs, err := r.loadLegs(ctx, `WHERE trip_id >= ? AND trip_id <= ?`, trips[0].ID, trips[len(trips)-1].ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trips {
+		trips[i].Legs = legs[trips[i].ID]
+	}
+	return trips, nil
+}
+
+// SetTripExpiry sets or clears the expiry columns of a trip
+func (r *sqlTripRepository) SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`UPDATE trips SET expires_at = ?, expiry_warned_at = ? WHERE id = ?`),
+		utcTime(expiresAt), utcTime(warnedAt), id)
+	if err != nil {
+		return fmt.Errorf("error updating trip expiry: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrTripNotFound
+	}
+	return nil
+}
+
+// FindExpiringTrips returns trips whose expiry is at or before the given time, soonest first
+func (r *sqlTripRepository) FindExpiringTrips(ctx context.Context, before time.Time) ([]domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	trips, err := r.queryTrips(ctx, `WHERE expires_at <= ? ORDER BY expires_at, id`, before.UTC())
+	if err != nil || len(trips) == 0 {
+		return nil, err
+	}
+	legs, err := r.loadLegs(ctx, `WHERE trip_id IN (SELECT id FROM trips WHERE expires_at <= ?)`, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	for i := range trips {
+		trips[i].Legs = legs[trips[i].ID]
+	}
+	return trips, nil
+}
+
+// queryTrips loads trip rows without their legs
+func (r *sqlTripRepository) queryTrips(ctx context.Context, where string, args ...any) ([]domain.Trip, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`SELECT `+tripColumns+` FROM trips `+where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []domain.Trip
+	for rows.Next() {
+		trip, err := scanTrip(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error reading trip: %w", err)
+		}
+		trips = append(trips, *trip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trips: %w", err)
+	}
+	return trips, nil
+}
+
+// scanTrip reads one row selected with tripColumns, without its legs
+func scanTrip(row rowScanner) (*domain.Trip, error) {
+	var trip domain.Trip
+	var expiresAt, warnedAt sql.NullTime
+	if err := row.Scan(&trip.ID, &trip.Title, &trip.UserID, &expiresAt, &warnedAt, &trip.Version); err != nil {
+		return nil, err
+	}
+	trip.ExpiresAt = nullTime(expiresAt)
+	trip.ExpiryWarnedAt = nullTime(warnedAt)
+	return &trip, nil
+}
+
+// loadLegs returns the legs, with their QA pairs, of the trips matching the where clause, grouped by trip ID in order
+func (r *sqlTripRepository) loadLegs(ctx context.Context, where string, args ...any) (map[string][]domain.Leg, error) {
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT trip_id, from_country, to_country, date, language FROM trip_legs `+where+` ORDER BY trip_id, position`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding trip legs: %w", err)
+	}
+	defer rows.Close()
+
+	legs := map[string][]domain.Leg{}
+	for rows.Next() {
+		var tripID string
+		var leg domain.Leg
+		if err := rows.Scan(&tripID, &leg.FromCountry, &leg.ToCountry, &leg.Date, &leg.Language); err != nil {
+			return nil, fmt.Errorf("error reading trip leg: %w", err)
+		}
+		leg.Date = leg.Date.UTC()
+		legs[tripID] = append(legs[tripID], leg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trip legs: %w", err)
+	}
+	rows.Close()
+
+	qaRows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT trip_id, leg, question_id, question, answer FROM trip_leg_qa `+where+` ORDER BY trip_id, leg, position`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding trip QA: %w", err)
+	}
+	defer qaRows.Close()
+
+	for qaRows.Next() {
+		var tripID string
+		var leg int
+		var pair domain.QA
+		if err := qaRows.Scan(&tripID, &leg, &pair.QuestionID, &pair.Question, &pair.Answer); err != nil {
+			return nil, fmt.Errorf("error reading trip QA: %w", err)
+		}
+		if leg < len(legs[tripID]) {
+			legs[tripID][leg].QA = append(legs[tripID][leg].QA, pair)
+		}
+	}
+	if err := qaRows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trip QA: %w", err)
+	}
+	return legs, nil
+}
+
+// insertLegs stores the trip's legs and their QA pairs in order
+func (r *sqlTripRepository) insertLegs(ctx context.Context, tx *sql.Tx, trip *domain.Trip) error {
+	for i, leg := range trip.Legs {
+		_, err := tx.ExecContext(ctx,
+			r.dialect.rebind(`INSERT INTO trip_legs (trip_id, position, from_country, to_country, date, language) VALUES (?, ?, ?, ?, ?, ?)`),
+			trip.ID, i, leg.FromCountry, leg.ToCountry, leg.Date, leg.Language)
+		if err != nil {
+			return err
+		}
+		for j, qa := range leg.QA {
+			_, err := tx.ExecContext(ctx,
+				r.dialect.rebind(`INSERT INTO trip_leg_qa (trip_id, leg, position, question_id, question, answer) VALUES (?, ?, ?, ?, ?, ?)`),
+				trip.ID, i, j, qa.QuestionID, qa.Question, qa.Answer)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteLegs removes every leg of a trip together with their QA pairs
+func (r *sqlTripRepository) deleteLegs(ctx context.Context, tx *sql.Tx, tripID string) error {
+	if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM trip_leg_qa WHERE trip_id = ?`), tripID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM trip_legs WHERE trip_id = ?`), tripID)
+	return err
+}
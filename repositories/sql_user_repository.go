@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// sqlUserRepository is the database/sql implementation of the UserRepository interface
+type sqlUserRepository struct {
+	db       *sql.DB
+	dialect  Dialect
+	timeouts Timeouts
+}
+
+// NewSQLUserRepository initializes a user repository backed by PostgreSQL or SQLite
+func NewSQLUserRepository(db *sql.DB, dialect Dialect, timeouts Timeouts) domain.UserRepository {
+	return &sqlUserRepository{
+		db:       db,
+		dialect:  dialect,
+		timeouts: timeouts,
+	}
+}
+
+// CreateUser inserts a new user row
+func (r *sqlUserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	id := user.ID
+	if id == "" {
+		id = newID()
+	}
+	_, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`INSERT INTO users (id, username, password, email, retention_days) VALUES (?, ?, ?, ?, ?)`),
+		id, user.Username, user.Password, user.Email, user.RetentionDays)
+	if err != nil {
+		return mapUniqueViolation(err)
+	}
+	user.ID = id
+	user.Version = 1
+	return nil
+}
+
+// FindUserByEmail retrieves a user by their email
+func (r *sqlUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, "email", email)
+}
+
+// FindUserByUsername retrieves a user by their username
+func (r *sqlUserRepository) FindUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.findOne(ctx, "username", username)
+}
+
+// FindUserByID retrieves a user by their ID
+func (r *sqlUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.findOne(ctx, "id", id)
+}
+
+func (r *sqlUserRepository) UpdateUsername(ctx context.Context, id, newUsername string, version int64) (int64, error) {
+	return r.set(ctx, id, "username", newUsername, version)
+}
+
+func (r *sqlUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (int64, error) {
+	return r.set(ctx, id, "password", hashedPassword, version)
+}
+
+func (r *sqlUserRepository) UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (int64, error) {
+	return r.set(ctx, id, "retention_days", retentionDays, version)
+}
+
+// UpdateCalendarToken stores the feed token hash, or NULL to revoke it so the unique index ignores the row
+func (r *sqlUserRepository) UpdateCalendarToken(ctx context.Context, id, tokenHash string) error {
+	var value any
+	if tokenHash != "" {
+		value = tokenHash
+	}
+	_, err := r.set(ctx, id, "calendar_token_hash", value, 0)
+	return err
+}
+
+// FindUserByCalendarToken retrieves the user whose calendar feed token has the given hash
+func (r *sqlUserRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (*domain.User, error) {
+	if tokenHash == "" {
+		return nil, domain.ErrUserNotFound
+	}
+	return r.findOne(ctx, "calendar_token_hash", tokenHash)
+}
+
+// GetAnswerProfile loads the user's rows from user_answers
+func (r *sqlUserRepository) GetAnswerProfile(ctx context.Context, userID string) (*domain.AnswerProfile, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var exists int
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM users WHERE id = ?`), userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading answer profile: %w", err)
+	}
+
+	profiles, err := r.loadAnswers(ctx, `WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return &domain.AnswerProfile{UserID: userID, Answers: []domain.ProfileAnswer{}}, nil
+	}
+	return &profiles[0], nil
+}
+
+// SaveProfileAnswers upserts each answer in one transaction
+func (r *sqlUserRepository) SaveProfileAnswers(ctx context.Context, userID string, answers []domain.ProfileAnswer) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM users WHERE id = ?`), userID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("error saving profile answers: %w", err)
+		}
+		for _, answer := range answers {
+			_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO user_answers (user_id, question_id, answer, updated_at)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT (user_id, question_id) DO UPDATE SET answer = excluded.answer, updated_at = excluded.updated_at`),
+				userID, answer.QuestionID, answer.Answer, answer.UpdatedAt.UTC())
+			if err != nil {
+				return fmt.Errorf("error saving profile answers: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sqlUserRepository) DeleteProfileAnswer(ctx context.Context, userID, questionID string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		r.dialect.rebind(`DELETE FROM user_answers WHERE user_id = ? AND question_id = ?`), userID, questionID)
+	if err != nil {
+		return fmt.Errorf("error deleting profile answer: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrProfileAnswerNotFound
+	}
+	return nil
+}
+
+func (r *sqlUserRepository) ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) ([]domain.AnswerProfile, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	return r.loadAnswers(ctx, `WHERE user_id IN (
+		SELECT DISTINCT user_id FROM user_answers WHERE user_id > ? ORDER BY user_id LIMIT ?)`, afterUserID, limit)
+}
+
+// loadAnswers groups the user_answers rows matching where into one profile per user, in user ID order
+func (r *sqlUserRepository) loadAnswers(ctx context.Context, where string, args ...any) ([]domain.AnswerProfile, error) {
+	rows, err := r.db.QueryContext(ctx,
+		r.dialect.rebind(`SELECT user_id, question_id, answer, updated_at FROM user_answers `+where+` ORDER BY user_id, question_id`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading answer profile: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []domain.AnswerProfile
+	for rows.Next() {
+		var userID string
+		var answer domain.ProfileAnswer
+		if err := rows.Scan(&userID, &answer.QuestionID, &answer.Answer, &answer.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error reading answer profile: %w", err)
+		}
+		answer.UpdatedAt = answer.UpdatedAt.UTC()
+		if len(profiles) == 0 || profiles[len(profiles)-1].UserID != userID {
+			profiles = append(profiles, domain.AnswerProfile{UserID: userID})
+		}
+		last := &profiles[len(profiles)-1]
+		last.Answers = append(last.Answers, answer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading answer profile: %w", err)
+	}
+	return profiles, nil
+}
+
+// findOne loads the single user whose column equals value; column is never user input
+func (r *sqlUserRepository) findOne(ctx context.Context, column, value string) (*domain.User, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var user domain.User
+	var calendarToken sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		r.dialect.rebind(`SELECT id, username, password, email, retention_days, calendar_token_hash, version FROM users WHERE `+column+` = ?`), value).
+		Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.RetentionDays, &calendarToken, &user.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error finding user: %w", err)
+	}
+	user.CalendarTokenHash = calendarToken.String
+	return &user, nil
+}
+
+// set updates one column of the user with the given ID, bumps its version and returns the new one; a
+// non-zero version must match the stored one. column is never user input
+func (r *sqlUserRepository) set(ctx context.Context, id, column string, value any, version int64) (int64, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	query, args := `UPDATE users SET `+column+` = ?, version = version + 1 WHERE id = ?`, []any{value, id}
+	if version != 0 {
+		query, args = query+` AND version = ?`, append(args, version)
+	}
+	var updated int64
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(query+` RETURNING version`), args...).Scan(&updated)
+	if err == nil {
+		return updated, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, mapUniqueViolation(err)
+	}
+	if version == 0 {
+		return 0, domain.ErrUserNotFound
+	}
+	// Users are never deleted, but tell a missing user apart from a stale version all the same
+	var exists int
+	err = r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM users WHERE id = ?`), id).Scan(&exists)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, domain.ErrUserNotFound
+	case err != nil:
+		return 0, fmt.Errorf("error writing user: %w", err)
+	}
+	return 0, domain.ErrVersionConflict
+}
+
+// mapUniqueViolation translates PostgreSQL and SQLite unique constraint failures into domain errors
+func mapUniqueViolation(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch msg := err.Error(); {
+	case strings.Contains(msg, userEmailIndex), strings.Contains(msg, "users.email"):
+		return domain.ErrEmailTaken
+	case strings.Contains(msg, userUsernameIndex), strings.Contains(msg, "users.username"):
+		return domain.ErrUsernameTaken
+	}
+	return fmt.Errorf("error writing user: %w", err)
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bounds how long a single repository operation may take on top of the caller's deadline
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// DefaultTimeouts are used when no per-operation deadlines are configured
+var DefaultTimeouts = Timeouts{
+	Read:  5 * time.Second,
+	Write: 10 * time.Second,
+}
+
+// read derives a context bounded by the read deadline
+func (t Timeouts) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Read)
+}
+
+// write derives a context bounded by the write deadline
+func (t Timeouts) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Write)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
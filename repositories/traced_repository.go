@@ -0,0 +1,418 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+var tracer = otel.Tracer("github.com/shaloms4/Pass-Me-Core-Functionality/repositories")
+
+// tracedFlightRepository opens a span around every call to another FlightRepository
+type tracedFlightRepository struct {
+	next    domain.FlightRepository
+	backend string
+}
+
+// NewTracedFlightRepository wraps repo so every call becomes a child span tagged with the backend
+func NewTracedFlightRepository(repo domain.FlightRepository, backend string) domain.FlightRepository {
+	return &tracedFlightRepository{next: repo, backend: backend}
+}
+
+func (r *tracedFlightRepository) CreateFlight(ctx context.Context, flight *domain.Flight) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.CreateFlight", attribute.String("user.id", flight.UserID))
+	defer func() {
+		span.SetAttributes(attribute.String("flight.id", flight.ID))
+		endSpan(span, err)
+	}()
+	return r.next.CreateFlight(ctx, flight)
+}
+
+func (r *tracedFlightRepository) GetFlightByID(ctx context.Context, userID, id string) (flight *domain.Flight, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.GetFlightByID",
+		attribute.String("flight.id", id), attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetFlightByID(ctx, userID, id)
+}
+
+func (r *tracedFlightRepository) DeleteFlight(ctx context.Context, userID, id string, version int64) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.DeleteFlight",
+		attribute.String("flight.id", id), attribute.String("user.id", userID), attribute.Int64("flight.version", version))
+	defer func() { endSpan(span, err) }()
+	return r.next.DeleteFlight(ctx, userID, id, version)
+}
+
+func (r *tracedFlightRepository) GetFlightsByUserID(ctx context.Context, userID string) (flights []domain.Flight, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.GetFlightsByUserID", attribute.String("user.id", userID))
+	defer func() {
+		span.SetAttributes(attribute.Int("flight.count", len(flights)))
+		endSpan(span, err)
+	}()
+	return r.next.GetFlightsByUserID(ctx, userID)
+}
+
+func (r *tracedFlightRepository) UpdateFlight(ctx context.Context, flight *domain.Flight) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.UpdateFlight",
+		attribute.String("flight.id", flight.ID), attribute.String("user.id", flight.UserID), attribute.Int64("flight.version", flight.Version))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdateFlight(ctx, flight)
+}
+
+func (r *tracedFlightRepository) ResealFlight(ctx context.Context, flight *domain.Flight) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.ResealFlight",
+		attribute.String("flight.id", flight.ID), attribute.String("user.id", flight.UserID), attribute.Int64("flight.version", flight.Version))
+	defer func() { endSpan(span, err) }()
+	return r.next.ResealFlight(ctx, flight)
+}
+
+func (r *tracedFlightRepository) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) (changes []domain.FlightChange, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.GetFlightChanges",
+		attribute.String("user.id", userID), attribute.Int("limit", limit))
+	defer func() {
+		span.SetAttributes(attribute.Int("change.count", len(changes)))
+		endSpan(span, err)
+	}()
+	return r.next.GetFlightChanges(ctx, userID, after, limit)
+}
+
+func (r *tracedFlightRepository) GetFlightByClientRef(ctx context.Context, userID, clientRef string) (flight *domain.Flight, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.GetFlightByClientRef", attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetFlightByClientRef(ctx, userID, clientRef)
+}
+
+func (r *tracedFlightRepository) PruneTombstones(ctx context.Context, before time.Time) (pruned int, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.PruneTombstones")
+	defer func() {
+		span.SetAttributes(attribute.Int("tombstone.count", pruned))
+		endSpan(span, err)
+	}()
+	return r.next.PruneTombstones(ctx, before)
+}
+
+func (r *tracedFlightRepository) ListFlights(ctx context.Context, afterID string, limit int) (flights []domain.Flight, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.ListFlights", attribute.Int("limit", limit))
+	defer func() {
+		span.SetAttributes(attribute.Int("flight.count", len(flights)))
+		endSpan(span, err)
+	}()
+	return r.next.ListFlights(ctx, afterID, limit)
+}
+
+func (r *tracedFlightRepository) SetFlightExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.SetFlightExpiry", attribute.String("flight.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.SetFlightExpiry(ctx, id, expiresAt, warnedAt)
+}
+
+func (r *tracedFlightRepository) FindExpiringFlights(ctx context.Context, before time.Time) (flights []domain.Flight, err error) {
+	ctx, span := startSpan(ctx, r.backend, "FlightRepository.FindExpiringFlights")
+	defer func() {
+		span.SetAttributes(attribute.Int("flight.count", len(flights)))
+		endSpan(span, err)
+	}()
+	return r.next.FindExpiringFlights(ctx, before)
+}
+
+// tracedUserRepository opens a span around every call to another UserRepository
+type tracedUserRepository struct {
+	next    domain.UserRepository
+	backend string
+}
+
+// NewTracedUserRepository wraps repo so every call becomes a child span tagged with the backend
+func NewTracedUserRepository(repo domain.UserRepository, backend string) domain.UserRepository {
+	return &tracedUserRepository{next: repo, backend: backend}
+}
+
+func (r *tracedUserRepository) CreateUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.CreateUser")
+	defer func() {
+		span.SetAttributes(attribute.String("user.id", user.ID))
+		endSpan(span, err)
+	}()
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *tracedUserRepository) FindUserByEmail(ctx context.Context, email string) (user *domain.User, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.FindUserByEmail")
+	defer func() { endSpan(span, err) }()
+	return r.next.FindUserByEmail(ctx, email)
+}
+
+func (r *tracedUserRepository) FindUserByUsername(ctx context.Context, username string) (user *domain.User, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.FindUserByUsername")
+	defer func() { endSpan(span, err) }()
+	return r.next.FindUserByUsername(ctx, username)
+}
+
+func (r *tracedUserRepository) FindUserByID(ctx context.Context, id string) (user *domain.User, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.FindUserByID", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.FindUserByID(ctx, id)
+}
+
+func (r *tracedUserRepository) UpdateUsername(ctx context.Context, id, newUsername string, version int64) (updated int64, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.UpdateUsername", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdateUsername(ctx, id, newUsername, version)
+}
+
+func (r *tracedUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (updated int64, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.UpdatePassword", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdatePassword(ctx, id, hashedPassword, version)
+}
+
+func (r *tracedUserRepository) UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (updated int64, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.UpdateRetention", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdateRetention(ctx, id, retentionDays, version)
+}
+
+func (r *tracedUserRepository) UpdateCalendarToken(ctx context.Context, id, tokenHash string) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.UpdateCalendarToken", attribute.String("user.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdateCalendarToken(ctx, id, tokenHash)
+}
+
+func (r *tracedUserRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (user *domain.User, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.FindUserByCalendarToken")
+	defer func() { endSpan(span, err) }()
+	return r.next.FindUserByCalendarToken(ctx, tokenHash)
+}
+
+func (r *tracedUserRepository) GetAnswerProfile(ctx context.Context, userID string) (profile *domain.AnswerProfile, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.GetAnswerProfile", attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetAnswerProfile(ctx, userID)
+}
+
+func (r *tracedUserRepository) SaveProfileAnswers(ctx context.Context, userID string, answers []domain.ProfileAnswer) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.SaveProfileAnswers",
+		attribute.String("user.id", userID), attribute.Int("answer.count", len(answers)))
+	defer func() { endSpan(span, err) }()
+	return r.next.SaveProfileAnswers(ctx, userID, answers)
+}
+
+func (r *tracedUserRepository) DeleteProfileAnswer(ctx context.Context, userID, questionID string) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.DeleteProfileAnswer",
+		attribute.String("user.id", userID), attribute.String("question.id", questionID))
+	defer func() { endSpan(span, err) }()
+	return r.next.DeleteProfileAnswer(ctx, userID, questionID)
+}
+
+func (r *tracedUserRepository) ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) (profiles []domain.AnswerProfile, err error) {
+	ctx, span := startSpan(ctx, r.backend, "UserRepository.ListAnswerProfiles", attribute.Int("limit", limit))
+	defer func() { endSpan(span, err) }()
+	return r.next.ListAnswerProfiles(ctx, afterUserID, limit)
+}
+
+// tracedTripRepository opens a span around every call to another TripRepository
+type tracedTripRepository struct {
+	next    domain.TripRepository
+	backend string
+}
+
+// NewTracedTripRepository wraps repo so every call becomes a child span tagged with the backend
+func NewTracedTripRepository(repo domain.TripRepository, backend string) domain.TripRepository {
+	return &tracedTripRepository{next: repo, backend: backend}
+}
+
+func (r *tracedTripRepository) CreateTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.CreateTrip", attribute.String("user.id", trip.UserID))
+	defer func() {
+		span.SetAttributes(attribute.String("trip.id", trip.ID))
+		endSpan(span, err)
+	}()
+	return r.next.CreateTrip(ctx, trip)
+}
+
+func (r *tracedTripRepository) GetTripByID(ctx context.Context, userID, id string) (trip *domain.Trip, err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.GetTripByID",
+		attribute.String("trip.id", id), attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetTripByID(ctx, userID, id)
+}
+
+func (r *tracedTripRepository) GetTripsByUserID(ctx context.Context, userID string) (trips []domain.Trip, err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.GetTripsByUserID", attribute.String("user.id", userID))
+	defer func() {
+		span.SetAttributes(attribute.Int("trip.count", len(trips)))
+		endSpan(span, err)
+	}()
+	return r.next.GetTripsByUserID(ctx, userID)
+}
+
+func (r *tracedTripRepository) UpdateTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.UpdateTrip",
+		attribute.String("trip.id", trip.ID), attribute.String("user.id", trip.UserID))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdateTrip(ctx, trip)
+}
+
+func (r *tracedTripRepository) ResealTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.ResealTrip",
+		attribute.String("trip.id", trip.ID), attribute.String("user.id", trip.UserID))
+	defer func() { endSpan(span, err) }()
+	return r.next.ResealTrip(ctx, trip)
+}
+
+func (r *tracedTripRepository) DeleteTrip(ctx context.Context, userID, id string, version int64) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.DeleteTrip",
+		attribute.String("trip.id", id), attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return r.next.DeleteTrip(ctx, userID, id, version)
+}
+
+func (r *tracedTripRepository) ListTrips(ctx context.Context, afterID string, limit int) (trips []domain.Trip, err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.ListTrips", attribute.Int("limit", limit))
+	defer func() {
+		span.SetAttributes(attribute.Int("trip.count", len(trips)))
+		endSpan(span, err)
+	}()
+	return r.next.ListTrips(ctx, afterID, limit)
+}
+
+func (r *tracedTripRepository) SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.SetTripExpiry", attribute.String("trip.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.SetTripExpiry(ctx, id, expiresAt, warnedAt)
+}
+
+func (r *tracedTripRepository) FindExpiringTrips(ctx context.Context, before time.Time) (trips []domain.Trip, err error) {
+	ctx, span := startSpan(ctx, r.backend, "TripRepository.FindExpiringTrips")
+	defer func() {
+		span.SetAttributes(attribute.Int("trip.count", len(trips)))
+		endSpan(span, err)
+	}()
+	return r.next.FindExpiringTrips(ctx, before)
+}
+
+// tracedShareRepository opens a span around every call to another ShareRepository
+type tracedShareRepository struct {
+	next    domain.ShareRepository
+	backend string
+}
+
+// NewTracedShareRepository wraps repo so every call becomes a child span tagged with the backend
+func NewTracedShareRepository(repo domain.ShareRepository, backend string) domain.ShareRepository {
+	return &tracedShareRepository{next: repo, backend: backend}
+}
+
+func (r *tracedShareRepository) CreateShare(ctx context.Context, share *domain.FlightShare) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "ShareRepository.CreateShare", attribute.String("flight.id", share.FlightID))
+	defer func() {
+		span.SetAttributes(attribute.String("share.id", share.ID))
+		endSpan(span, err)
+	}()
+	return r.next.CreateShare(ctx, share)
+}
+
+func (r *tracedShareRepository) GetShareByID(ctx context.Context, id string) (share *domain.FlightShare, err error) {
+	ctx, span := startSpan(ctx, r.backend, "ShareRepository.GetShareByID", attribute.String("share.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetShareByID(ctx, id)
+}
+
+func (r *tracedShareRepository) GetSharesByFlightID(ctx context.Context, flightID string) (shares []domain.FlightShare, err error) {
+	ctx, span := startSpan(ctx, r.backend, "ShareRepository.GetSharesByFlightID", attribute.String("flight.id", flightID))
+	defer func() {
+		span.SetAttributes(attribute.Int("share.count", len(shares)))
+		endSpan(span, err)
+	}()
+	return r.next.GetSharesByFlightID(ctx, flightID)
+}
+
+func (r *tracedShareRepository) RevokeShare(ctx context.Context, id string, revokedAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "ShareRepository.RevokeShare", attribute.String("share.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.RevokeShare(ctx, id, revokedAt)
+}
+
+func (r *tracedShareRepository) RecordShareAccess(ctx context.Context, id string, accessedAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "ShareRepository.RecordShareAccess", attribute.String("share.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.RecordShareAccess(ctx, id, accessedAt)
+}
+
+// tracedHouseholdRepository opens a span around every call to another HouseholdRepository
+type tracedHouseholdRepository struct {
+	next    domain.HouseholdRepository
+	backend string
+}
+
+// NewTracedHouseholdRepository wraps repo so every call becomes a child span tagged with the backend
+func NewTracedHouseholdRepository(repo domain.HouseholdRepository, backend string) domain.HouseholdRepository {
+	return &tracedHouseholdRepository{next: repo, backend: backend}
+}
+
+func (r *tracedHouseholdRepository) CreateHousehold(ctx context.Context, household *domain.Household) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.CreateHousehold")
+	defer func() {
+		span.SetAttributes(attribute.String("household.id", household.ID))
+		endSpan(span, err)
+	}()
+	return r.next.CreateHousehold(ctx, household)
+}
+
+func (r *tracedHouseholdRepository) GetHouseholdByID(ctx context.Context, id string) (household *domain.Household, err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.GetHouseholdByID", attribute.String("household.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetHouseholdByID(ctx, id)
+}
+
+func (r *tracedHouseholdRepository) GetHouseholdsByUserID(ctx context.Context, userID string) (households []domain.Household, err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.GetHouseholdsByUserID", attribute.String("user.id", userID))
+	defer func() {
+		span.SetAttributes(attribute.Int("household.count", len(households)))
+		endSpan(span, err)
+	}()
+	return r.next.GetHouseholdsByUserID(ctx, userID)
+}
+
+func (r *tracedHouseholdRepository) DeleteHousehold(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.DeleteHousehold", attribute.String("household.id", id))
+	defer func() { endSpan(span, err) }()
+	return r.next.DeleteHousehold(ctx, id)
+}
+
+func (r *tracedHouseholdRepository) AddHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.AddHouseholdMember",
+		attribute.String("household.id", householdID), attribute.String("user.id", member.UserID))
+	defer func() { endSpan(span, err) }()
+	return r.next.AddHouseholdMember(ctx, householdID, member)
+}
+
+func (r *tracedHouseholdRepository) UpdateHouseholdMember(ctx context.Context, householdID string, member domain.HouseholdMember) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.UpdateHouseholdMember",
+		attribute.String("household.id", householdID), attribute.String("user.id", member.UserID))
+	defer func() { endSpan(span, err) }()
+	return r.next.UpdateHouseholdMember(ctx, householdID, member)
+}
+
+func (r *tracedHouseholdRepository) RemoveHouseholdMember(ctx context.Context, householdID, userID string) (err error) {
+	ctx, span := startSpan(ctx, r.backend, "HouseholdRepository.RemoveHouseholdMember",
+		attribute.String("household.id", householdID), attribute.String("user.id", userID))
+	defer func() { endSpan(span, err) }()
+	return r.next.RemoveHouseholdMember(ctx, householdID, userID)
+}
+
+func startSpan(ctx context.Context, backend, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", backend))
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
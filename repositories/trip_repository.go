@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// tripRepository is the MongoDB implementation of the TripRepository interface; legs are embedded in the trip
+type tripRepository struct {
+	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+// NewTripRepository initializes a new trip repository
+func NewTripRepository(db *mongo.Database, timeouts Timeouts) domain.TripRepository {
+	return &tripRepository{
+		collection: db.Collection("trips"),
+		timeouts:   timeouts,
+	}
+}
+
+// CreateTrip stores a new trip at version 1
+func (r *tripRepository) CreateTrip(ctx context.Context, trip *domain.Trip) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	normalizeTrip(trip)
+	if trip.ID == "" {
+		trip.ID = newID()
+	}
+	trip.Version = 1
+	if _, err := r.collection.InsertOne(ctx, trip); err != nil {
+		return fmt.Errorf("error creating trip: %w", err)
+	}
+	return nil
+}
+
+// GetTripByID retrieves one of the user's trips by its ID
+func (r *tripRepository) GetTripByID(ctx context.Context, userID, id string) (*domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var trip domain.Trip
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&trip)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrTripNotFound
+		}
+		return nil, fmt.Errorf("error finding trip: %w", err)
+	}
+	return &trip, nil
+}
+
+// GetTripsByUserID retrieves all trips of a user ordered by the date of their first leg
+func (r *tripRepository) GetTripsByUserID(ctx context.Context, userID string) ([]domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "legs.0.date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding trips: %w", err)
+	}
+
+	var trips []domain.Trip
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, fmt.Errorf("error finding trips: %w", err)
+	}
+	return trips, nil
+}
+
+// UpdateTrip replaces a stored trip with the given one, matching on the version it read so a concurrent
+// write is not lost
+func (r *tripRepository) UpdateTrip(ctx context.Context, trip *domain.Trip) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	normalizeTrip(trip)
+
+	stored, err := r.storedVersion(ctx, trip.UserID, trip.ID, trip.Version)
+	if err != nil {
+		return err
+	}
+	updated := *trip
+	updated.Version = stored + 1
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": trip.ID, "user_id": trip.UserID, "version": stored}, updated)
+	if err != nil {
+		return fmt.Errorf("error updating trip: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrVersionConflict
+	}
+	trip.Version = updated.Version
+	return nil
+}
+
+// ResealTrip sets the legs of a stored trip, matching on the version it read, and leaves the version alone
+func (r *tripRepository) ResealTrip(ctx context.Context, trip *domain.Trip) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	stored, err := r.storedVersion(ctx, trip.UserID, trip.ID, trip.Version)
+	if err != nil {
+		return err
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": trip.ID, "user_id": trip.UserID, "version": stored},
+		bson.M{"$set": bson.M{"legs": trip.Legs}})
+	if err != nil {
+		return fmt.Errorf("error resealing trip: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+// DeleteTrip removes one of the user's trips by its ID if its version still matches
+func (r *tripRepository) DeleteTrip(ctx context.Context, userID, id string, version int64) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	stored, err := r.storedVersion(ctx, userID, id, version)
+	if err != nil {
+		return err
+	}
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID, "version": stored})
+	if err != nil {
+		return fmt.Errorf("error deleting trip: %w", err)
+	}
+	// A concurrent writer moved the version between the read and the delete
+	if result.DeletedCount == 0 {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+// storedVersion reads the version of one of the user's trips and checks it against an expected non-zero
+// version
+func (r *tripRepository) storedVersion(ctx context.Context, userID, id string, expected int64) (int64, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"version": 1})
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}, opts).Decode(&doc)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return 0, domain.ErrTripNotFound
+	case err != nil:
+		return 0, fmt.Errorf("error finding trip: %w", err)
+	case expected != 0 && expected != doc.Version:
+		return 0, domain.ErrVersionConflict
+	}
+	return doc.Version, nil
+}
+
+// ListTrips pages through every trip ordered by ID
+func (r *tripRepository) ListTrips(ctx context.Context, afterID string, limit int) ([]domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	filter := bson.M{}
+	if afterID != "" {
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing trips: %w", err)
+	}
+
+	var trips []domain.Trip
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, fmt.Errorf("error listing trips: %w", err)
+	}
+	return trips, nil
+}
+
+// SetTripExpiry sets or clears the expiry fields of a trip
+func (r *tripRepository) SetTripExpiry(ctx context.Context, id string, expiresAt, warnedAt *time.Time) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	set, unset := bson.M{}, bson.M{}
+	for field, value := range map[string]*time.Time{"expires_at": expiresAt, "expiry_warned_at": warnedAt} {
+		if value == nil {
+			unset[field] = ""
+		} else {
+			set[field] = value.UTC()
+		}
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("error updating trip expiry: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrTripNotFound
+	}
+	return nil
+}
+
+// FindExpiringTrips returns trips whose expiry is at or before the given time, soonest first
+func (r *tripRepository) FindExpiringTrips(ctx context.Context, before time.Time) ([]domain.Trip, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"expires_at": bson.M{"$lte": before.UTC()}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expiring trips: %w", err)
+	}
+
+	var trips []domain.Trip
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, fmt.Errorf("error finding expiring trips: %w", err)
+	}
+	return trips, nil
+}
+
+// normalizeTrip stores leg dates in UTC and defaults their language, matching the flight repositories
+func normalizeTrip(trip *domain.Trip) {
+	for i := range trip.Legs {
+		trip.Legs[i].Date = trip.Legs[i].Date.UTC()
+		if trip.Legs[i].Language == "" {
+			trip.Legs[i].Language = "English"
+		}
+	}
+}
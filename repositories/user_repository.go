@@ -3,10 +3,14 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
 )
@@ -14,93 +18,296 @@ import (
 // userRepository is the implementation of the UserRepository interface
 type userRepository struct {
 	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+// userDocument is the MongoDB representation of a user, keeping ObjectIDs out of the domain
+type userDocument struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	Username string             `bson:"username"`
+	Password string             `bson:"password,omitempty"`
+	Email    string             `bson:"email"`
+	// Users created before retention existed have no field, which decodes as zero (keep forever)
+	RetentionDays int `bson:"retention_days"`
+	// CalendarTokenHash is absent rather than empty when no feed is issued, keeping the sparse unique index valid
+	CalendarTokenHash string `bson:"calendar_token_hash,omitempty"`
+	// AnswerProfile is keyed by question ID so single answers can be set and unset atomically
+	AnswerProfile map[string]profileAnswerDocument `bson:"answer_profile,omitempty"`
+	Version       int64                            `bson:"version"`
+}
+
+type profileAnswerDocument struct {
+	Answer    string    `bson:"answer"`
+	UpdatedAt time.Time `bson:"updated_at"`
 }
 
 // NewUserRepository initializes a new user repository
-func NewUserRepository(db *mongo.Database) domain.UserRepository {
+func NewUserRepository(db *mongo.Database, timeouts Timeouts) domain.UserRepository {
 	return &userRepository{
 		collection: db.Collection("users"),
+		timeouts:   timeouts,
 	}
 }
 
 // CreateUser stores a new user into the MongoDB database
-func (r *userRepository) CreateUser(user *domain.User) error {
-	result, err := r.collection.InsertOne(context.Background(), user)
-	if err != nil {
-		return err
+func (r *userRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	doc := userDocument{
+		ID:            primitive.NewObjectID(),
+		Username:      user.Username,
+		Password:      user.Password,
+		Email:         user.Email,
+		RetentionDays: user.RetentionDays,
+		Version:       1,
+	}
+	if user.ID != "" {
+		objID, err := primitive.ObjectIDFromHex(user.ID)
+		if err != nil {
+			return fmt.Errorf("invalid user id %q: %w", user.ID, err)
+		}
+		doc.ID = objID
 	}
 
-	// Update the user's ID with the MongoDB-generated ID
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		user.ID = oid
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		return mapDuplicateKeyError(err)
 	}
 
+	// Update the user's ID with the MongoDB ObjectID
+	user.ID = doc.ID.Hex()
+	user.Version = doc.Version
 	return nil
 }
 
 // FindUserByEmail retrieves a user by their email from MongoDB
-func (r *userRepository) FindUserByEmail(email string) (*domain.User, error) {
-	var user domain.User
-	err := r.collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
+func (r *userRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+// FindUserByUsername retrieves a user by their username from MongoDB
+func (r *userRepository) FindUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"username": username})
+}
+
+// FindUserByID retrieves a user by their hex ObjectID from MongoDB
+func (r *userRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, err
+		return nil, domain.ErrUserNotFound
 	}
-	return &user, nil
+	return r.findOne(ctx, bson.M{"_id": objID})
 }
 
-// FindUserByUsername retrieves a user by their username from MongoDB
-func (r *userRepository) FindUserByUsername(username string) (*domain.User, error) {
-	var user domain.User
-	err := r.collection.FindOne(context.Background(), bson.M{"username": username}).Decode(&user)
+func (r *userRepository) UpdateUsername(ctx context.Context, id, newUsername string, version int64) (int64, error) {
+	return r.set(ctx, id, bson.M{"username": newUsername}, version)
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id, hashedPassword string, version int64) (int64, error) {
+	return r.set(ctx, id, bson.M{"password": hashedPassword}, version)
+}
+
+func (r *userRepository) UpdateRetention(ctx context.Context, id string, retentionDays int, version int64) (int64, error) {
+	return r.set(ctx, id, bson.M{"retention_days": retentionDays}, version)
+}
+
+func (r *userRepository) UpdateCalendarToken(ctx context.Context, id, tokenHash string) error {
+	var err error
+	if tokenHash == "" {
+		_, err = r.update(ctx, id, bson.M{"$unset": bson.M{"calendar_token_hash": ""}, "$inc": bson.M{"version": 1}}, 0)
+	} else {
+		_, err = r.set(ctx, id, bson.M{"calendar_token_hash": tokenHash}, 0)
+	}
+	return err
+}
+
+// FindUserByCalendarToken retrieves the user whose calendar feed token has the given hash
+func (r *userRepository) FindUserByCalendarToken(ctx context.Context, tokenHash string) (*domain.User, error) {
+	if tokenHash == "" {
+		return nil, domain.ErrUserNotFound
+	}
+	return r.findOne(ctx, bson.M{"calendar_token_hash": tokenHash})
+}
+
+// GetAnswerProfile reads the answer_profile field of the user document
+func (r *userRepository) GetAnswerProfile(ctx context.Context, userID string) (*domain.AnswerProfile, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	var doc userDocument
+	opts := options.FindOne().SetProjection(bson.M{"answer_profile": 1})
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}, opts).Decode(&doc); err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("user not found")
+			return nil, domain.ErrUserNotFound
 		}
-		return nil, err
+		return nil, fmt.Errorf("error reading answer profile: %w", err)
 	}
-	return &user, nil
+	return answerProfile(userID, doc.AnswerProfile), nil
 }
 
-// repositories/user_repository.go
+// SaveProfileAnswers sets each answer under its own key, so concurrent saves of different questions both land
+func (r *userRepository) SaveProfileAnswers(ctx context.Context, userID string, answers []domain.ProfileAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	fields := bson.M{}
+	for _, answer := range answers {
+		fields["answer_profile."+answer.QuestionID] = profileAnswerDocument{Answer: answer.Answer, UpdatedAt: answer.UpdatedAt}
+	}
+	// Answers are not part of the user record, so saving them leaves the version alone
+	_, err := r.update(ctx, userID, bson.M{"$set": fields}, 0)
+	return err
+}
 
-func (r *userRepository) FindUserByID(id string) (*domain.User, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+func (r *userRepository) DeleteProfileAnswer(ctx context.Context, userID, questionID string) error {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, err
+		return domain.ErrUserNotFound
 	}
-	var user domain.User
-	err = r.collection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&user)
+	key := "answer_profile." + questionID
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objID, key: bson.M{"$exists": true}}, bson.M{"$unset": bson.M{key: ""}})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("error deleting profile answer: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrProfileAnswerNotFound
 	}
-	return &user, nil
+	return nil
 }
 
-func (r *userRepository) UpdateUsername(id, newUsername string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+func (r *userRepository) ListAnswerProfiles(ctx context.Context, afterUserID string, limit int) ([]domain.AnswerProfile, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	filter := bson.M{"answer_profile": bson.M{"$exists": true, "$ne": bson.M{}}}
+	if afterUserID != "" {
+		objID, err := primitive.ObjectIDFromHex(afterUserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id %q: %w", afterUserID, err)
+		}
+		filter["_id"] = bson.M{"$gt": objID}
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"answer_profile": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error listing answer profiles: %w", err)
 	}
-	_, err = r.collection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": objID},
-		bson.M{"$set": bson.M{"username": newUsername}},
-	)
-	return err
+	var docs []userDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("error listing answer profiles: %w", err)
+	}
+
+	profiles := make([]domain.AnswerProfile, 0, len(docs))
+	for _, doc := range docs {
+		profiles = append(profiles, *answerProfile(doc.ID.Hex(), doc.AnswerProfile))
+	}
+	return profiles, nil
+}
+
+// answerProfile converts the stored map into the domain's sorted list
+func answerProfile(userID string, stored map[string]profileAnswerDocument) *domain.AnswerProfile {
+	profile := &domain.AnswerProfile{UserID: userID, Answers: make([]domain.ProfileAnswer, 0, len(stored))}
+	for questionID, answer := range stored {
+		profile.Answers = append(profile.Answers, domain.ProfileAnswer{
+			QuestionID: questionID,
+			Answer:     answer.Answer,
+			UpdatedAt:  answer.UpdatedAt,
+		})
+	}
+	sort.Slice(profile.Answers, func(i, j int) bool { return profile.Answers[i].QuestionID < profile.Answers[j].QuestionID })
+	return profile
+}
+
+// findOne decodes the single user matching filter
+func (r *userRepository) findOne(ctx context.Context, filter bson.M) (*domain.User, error) {
+	ctx, cancel := r.timeouts.read(ctx)
+	defer cancel()
+
+	var doc userDocument
+	err := r.collection.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error finding user: %w", err)
+	}
+	return &domain.User{
+		ID:                doc.ID.Hex(),
+		Username:          doc.Username,
+		Password:          doc.Password,
+		Email:             doc.Email,
+		RetentionDays:     doc.RetentionDays,
+		CalendarTokenHash: doc.CalendarTokenHash,
+		Version:           doc.Version,
+	}, nil
+}
+
+// set applies a $set update to the user with the given hex ID, bumps its version and returns the new one
+func (r *userRepository) set(ctx context.Context, id string, fields bson.M, version int64) (int64, error) {
+	return r.update(ctx, id, bson.M{"$set": fields, "$inc": bson.M{"version": 1}}, version)
 }
 
-func (r *userRepository) UpdatePassword(id, hashedPassword string) error {
+// update applies an update document to the user with the given hex ID and returns the version it leaves; a
+// non-zero version must match the stored one
+func (r *userRepository) update(ctx context.Context, id string, update bson.M, version int64) (int64, error) {
+	ctx, cancel := r.timeouts.write(ctx)
+	defer cancel()
+
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return 0, domain.ErrUserNotFound
 	}
-	_, err = r.collection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": objID},
-		bson.M{"$set": bson.M{"password": hashedPassword}},
-	)
-	return err
+	filter := bson.M{"_id": objID}
+	if version != 0 {
+		filter["version"] = version
+	}
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"version": 1})
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err == nil {
+		return doc.Version, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return 0, mapDuplicateKeyError(err)
+	}
+	if version == 0 {
+		return 0, domain.ErrUserNotFound
+	}
+	n, err := r.collection.CountDocuments(ctx, bson.M{"_id": objID})
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("error writing user: %w", err)
+	case n == 0:
+		return 0, domain.ErrUserNotFound
+	}
+	return 0, domain.ErrVersionConflict
+}
+
+// mapDuplicateKeyError translates a unique index violation into the matching domain error and wraps anything else
+func mapDuplicateKeyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		switch msg := err.Error(); {
+		case strings.Contains(msg, userEmailIndex):
+			return domain.ErrEmailTaken
+		case strings.Contains(msg, userUsernameIndex):
+			return domain.ErrUsernameTaken
+		}
+	}
+	return fmt.Errorf("error writing user: %w", err)
 }
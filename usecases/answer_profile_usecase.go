@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// AnswerProfileUseCase manages a user's library of saved answers to template questions. Every method acts
+// for actorID on the profile of ownerID as far as the authorizer allows
+type AnswerProfileUseCase interface {
+	GetAnswerProfile(ctx context.Context, actorID, ownerID string) (*domain.AnswerProfile, error)
+	SaveProfileAnswers(ctx context.Context, actorID, ownerID string, answers []domain.ProfileAnswer) (*domain.AnswerProfile, error)
+	DeleteProfileAnswer(ctx context.Context, actorID, ownerID, questionID string) error
+}
+
+// answerProfileUseCase implements the AnswerProfileUseCase interface
+type answerProfileUseCase struct {
+	userRepo domain.UserRepository
+	authz    Authorizer
+	now      func() time.Time
+}
+
+// NewAnswerProfileUseCase creates a new instance of the answer profile use case
+func NewAnswerProfileUseCase(userRepo domain.UserRepository, authz Authorizer) AnswerProfileUseCase {
+	return &answerProfileUseCase{
+		userRepo: userRepo,
+		authz:    authz,
+		now:      time.Now,
+	}
+}
+
+func (uc *answerProfileUseCase) GetAnswerProfile(ctx context.Context, actorID, ownerID string) (*domain.AnswerProfile, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID}, ActionView); err != nil {
+		return nil, err
+	}
+	return uc.userRepo.GetAnswerProfile(ctx, ownerID)
+}
+
+// SaveProfileAnswers adds or replaces the given answers, leaving the rest of the profile as it is, and
+// returns the whole profile
+func (uc *answerProfileUseCase) SaveProfileAnswers(ctx context.Context, actorID, ownerID string, answers []domain.ProfileAnswer) (*domain.AnswerProfile, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID}, ActionManage); err != nil {
+		return nil, err
+	}
+	invalid := map[string]string{}
+	if len(answers) == 0 {
+		invalid["answers"] = "required"
+	}
+	seen := map[string]bool{}
+	for i, answer := range answers {
+		field := fmt.Sprintf("answers[%d]", i)
+		answers[i].Answer = strings.TrimSpace(answer.Answer)
+		switch template, ok := domain.LookupQuestionTemplate(answer.QuestionID); {
+		case !ok:
+			invalid[field+".question_id"] = "unknown_question"
+		case template.DateSensitive:
+			invalid[field+".question_id"] = "date_sensitive"
+		case seen[answer.QuestionID]:
+			invalid[field+".question_id"] = "duplicate"
+		}
+		seen[answer.QuestionID] = true
+		if answers[i].Answer == "" {
+			invalid[field+".answer"] = "required"
+		}
+		answers[i].UpdatedAt = uc.now().UTC()
+	}
+	if len(invalid) > 0 {
+		return nil, domain.NewValidationError("Invalid profile answers", invalid)
+	}
+
+	if err := uc.userRepo.SaveProfileAnswers(ctx, ownerID, answers); err != nil {
+		return nil, err
+	}
+	return uc.userRepo.GetAnswerProfile(ctx, ownerID)
+}
+
+func (uc *answerProfileUseCase) DeleteProfileAnswer(ctx context.Context, actorID, ownerID, questionID string) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID}, ActionManage); err != nil {
+		return err
+	}
+	return uc.userRepo.DeleteProfileAnswer(ctx, ownerID, questionID)
+}
+
+// fillFromProfile completes QA pairs that name a template question: a blank question takes the template's
+// wording and a blank answer takes the saved one. Answers the traveller typed are left alone
+func fillFromProfile(ctx context.Context, userRepo domain.UserRepository, userID string, qa []domain.QA) error {
+	invalid := map[string]string{}
+	templated := false
+	for i, pair := range qa {
+		if pair.QuestionID == "" {
+			continue
+		}
+		template, ok := domain.LookupQuestionTemplate(pair.QuestionID)
+		if !ok {
+			invalid[fmt.Sprintf("qa[%d].question_id", i)] = "unknown_question"
+			continue
+		}
+		templated = true
+		if strings.TrimSpace(pair.Question) == "" {
+			qa[i].Question = template.Question
+		}
+	}
+	if len(invalid) > 0 {
+		return domain.NewValidationError("Invalid flight details", invalid)
+	}
+	if !templated {
+		return nil
+	}
+
+	profile, err := userRepo.GetAnswerProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i, pair := range qa {
+		if pair.QuestionID == "" || strings.TrimSpace(pair.Answer) != "" {
+			continue
+		}
+		if saved, ok := profile.Answer(pair.QuestionID); ok {
+			qa[i].Answer = saved.Answer
+		}
+	}
+	return nil
+}
+
+// writeBackAnswers writes the answers to template questions on a flight to country back to the profile.
+// Questions that are date-sensitive there and blank answers are skipped
+func writeBackAnswers(ctx context.Context, userRepo domain.UserRepository, userID, country string, qa []domain.QA, now time.Time) error {
+	var answers []domain.ProfileAnswer
+	for _, pair := range qa {
+		_, ok := domain.LookupQuestionTemplate(pair.QuestionID)
+		answer := strings.TrimSpace(pair.Answer)
+		if !ok || domain.IsDateSensitive(pair, country) || answer == "" {
+			continue
+		}
+		answers = append(answers, domain.ProfileAnswer{QuestionID: pair.QuestionID, Answer: answer, UpdatedAt: now.UTC()})
+	}
+	if len(answers) == 0 {
+		return nil
+	}
+	return userRepo.SaveProfileAnswers(ctx, userID, answers)
+}
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// Action is what an actor wants to do with a user's flights, trips or answers
+type Action string
+
+const (
+	ActionView   Action = "view"
+	ActionManage Action = "manage"
+)
+
+// Actor is who is asking: a signed-in user, or the holder of a share link
+type Actor struct {
+	UserID string
+	// Share is set instead of UserID when the request carries a share-link token
+	Share *domain.FlightShare
+}
+
+// Resource is the data being acted on. FlightID is empty for everything a user owns, like their flight list
+// or answer profile
+type Resource struct {
+	OwnerID  string
+	FlightID string
+}
+
+// Rule grants an action when it recognises the relationship between actor and resource; it returns false,
+// not an error, when it does not apply
+type Rule func(ctx context.Context, actor Actor, resource Resource, action Action) (bool, error)
+
+// Authorizer decides every access to user data in the use cases. With the rules below it grants:
+//
+//	actor                                    view   manage
+//	owner                                    yes    yes
+//	active guardian of the owner             yes    yes
+//	admin                                    yes    yes
+//	holder of an active share of the flight  yes    no
+//	invited guardian, member, anyone else    no     no
+type Authorizer interface {
+	// Authorize returns nil when any rule grants the action and ErrForbidden otherwise
+	Authorize(ctx context.Context, actor Actor, resource Resource, action Action) error
+}
+
+type authorizer struct {
+	rules []Rule
+}
+
+// NewAuthorizer creates an authorizer that grants an action when any of the rules does
+func NewAuthorizer(rules ...Rule) Authorizer {
+	return &authorizer{rules: rules}
+}
+
+func (a *authorizer) Authorize(ctx context.Context, actor Actor, resource Resource, action Action) error {
+	for _, rule := range a.rules {
+		allowed, err := rule(ctx, actor, resource, action)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+	logger().WarnContext(ctx, "access denied", "actor_id", actor.UserID, "owner_id", resource.OwnerID,
+		"flight_id", resource.FlightID, "action", string(action))
+	return domain.ErrForbidden
+}
+
+// OwnerRule lets users act on their own data
+func OwnerRule() Rule {
+	return func(ctx context.Context, actor Actor, resource Resource, action Action) (bool, error) {
+		return actor.UserID != "" && actor.UserID == resource.OwnerID, nil
+	}
+}
+
+// SharedRule lets the holder of an active share link view the one flight it was issued for
+func SharedRule() Rule {
+	return func(ctx context.Context, actor Actor, resource Resource, action Action) (bool, error) {
+		share := actor.Share
+		return share != nil && action == ActionView && share.Active(time.Now()) &&
+			share.FlightID == resource.FlightID && share.UserID == resource.OwnerID, nil
+	}
+}
+
+// AdminRule lets the configured administrators act on anyone's data
+func AdminRule(adminIDs []string) Rule {
+	admins := make(map[string]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+	return func(ctx context.Context, actor Actor, resource Resource, action Action) (bool, error) {
+		if actor.UserID == "" || !admins[actor.UserID] {
+			return false, nil
+		}
+		logger().InfoContext(ctx, "admin access", "actor_id", actor.UserID, "owner_id", resource.OwnerID,
+			"flight_id", resource.FlightID, "action", string(action))
+		return true, nil
+	}
+}
+
+// GuardianRule lets active guardians act on the data of the active dependents in their households
+func GuardianRule(households domain.HouseholdRepository) Rule {
+	return func(ctx context.Context, actor Actor, resource Resource, action Action) (bool, error) {
+		if actor.UserID == "" || resource.OwnerID == "" {
+			return false, nil
+		}
+		memberships, err := households.GetHouseholdsByUserID(ctx, resource.OwnerID)
+		if err != nil {
+			return false, err
+		}
+		for _, household := range memberships {
+			if household.HasRole(resource.OwnerID, domain.RoleDependent) && household.HasRole(actor.UserID, domain.RoleGuardian) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// authorize is the common check of use cases acting for a signed-in user
+func authorize(ctx context.Context, authz Authorizer, actorID string, resource Resource, action Action) error {
+	return authz.Authorize(ctx, Actor{UserID: actorID}, resource, action)
+}
@@ -0,0 +1,117 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+)
+
+func TestAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	households := repositories.NewMemoryHouseholdRepository()
+	household := &domain.Household{
+		Name: "Family",
+		Members: []domain.HouseholdMember{
+			{UserID: "guardian", Role: domain.RoleGuardian, Status: domain.MemberActive},
+			{UserID: "exguardian", Role: domain.RoleGuardian, Status: domain.MemberActive},
+			{UserID: "invited", Role: domain.RoleGuardian, Status: domain.MemberInvited},
+			{UserID: "member", Role: domain.RoleMember, Status: domain.MemberActive},
+			{UserID: "owner", Role: domain.RoleDependent, Status: domain.MemberActive},
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := households.CreateHousehold(ctx, household); err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	if err := households.RemoveHouseholdMember(ctx, household.ID, "exguardian"); err != nil {
+		t.Fatalf("RemoveHouseholdMember: %v", err)
+	}
+
+	authz := usecases.NewAuthorizer(
+		usecases.OwnerRule(),
+		usecases.GuardianRule(households),
+		usecases.AdminRule([]string{"admin"}),
+		usecases.SharedRule(),
+	)
+
+	now := time.Now()
+	share := &domain.FlightShare{ID: "s1", FlightID: "f1", UserID: "owner", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	expired := &domain.FlightShare{ID: "s2", FlightID: "f1", UserID: "owner", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	revoked := &domain.FlightShare{ID: "s3", FlightID: "f1", UserID: "owner", CreatedAt: now, ExpiresAt: now.Add(time.Hour), RevokedAt: &now}
+
+	flight := usecases.Resource{OwnerID: "owner", FlightID: "f1"}
+	otherFlight := usecases.Resource{OwnerID: "owner", FlightID: "f2"}
+	profile := usecases.Resource{OwnerID: "owner"}
+
+	tests := []struct {
+		name     string
+		actor    usecases.Actor
+		resource usecases.Resource
+		view     bool
+		manage   bool
+	}{
+		{"owner", usecases.Actor{UserID: "owner"}, flight, true, true},
+		{"owner's profile", usecases.Actor{UserID: "owner"}, profile, true, true},
+		{"guardian", usecases.Actor{UserID: "guardian"}, flight, true, true},
+		{"guardian on the dependent's profile", usecases.Actor{UserID: "guardian"}, profile, true, true},
+		{"guardian removed from the household", usecases.Actor{UserID: "exguardian"}, flight, false, false},
+		{"invited guardian", usecases.Actor{UserID: "invited"}, flight, false, false},
+		{"household member", usecases.Actor{UserID: "member"}, flight, false, false},
+		{"admin", usecases.Actor{UserID: "admin"}, flight, true, true},
+		{"shared link", usecases.Actor{Share: share}, flight, true, false},
+		{"shared link on another flight", usecases.Actor{Share: share}, otherFlight, false, false},
+		{"shared link on the profile", usecases.Actor{Share: share}, profile, false, false},
+		{"expired shared link", usecases.Actor{Share: expired}, flight, false, false},
+		{"revoked shared link", usecases.Actor{Share: revoked}, flight, false, false},
+		{"stranger", usecases.Actor{UserID: "stranger"}, flight, false, false},
+		{"anonymous", usecases.Actor{}, flight, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for action, want := range map[usecases.Action]bool{usecases.ActionView: tt.view, usecases.ActionManage: tt.manage} {
+				err := authz.Authorize(ctx, tt.actor, tt.resource, action)
+				if want && err != nil {
+					t.Errorf("%s: Authorize = %v, want access", action, err)
+				}
+				if !want && !errors.Is(err, domain.ErrForbidden) {
+					t.Errorf("%s: Authorize = %v, want %v", action, err, domain.ErrForbidden)
+				}
+			}
+		})
+	}
+}
+
+// A guardian loses access as soon as the household drops them, without any cached grant
+func TestGuardianRuleFollowsHouseholdChanges(t *testing.T) {
+	ctx := context.Background()
+	households := repositories.NewMemoryHouseholdRepository()
+	household := &domain.Household{
+		Name: "Family",
+		Members: []domain.HouseholdMember{
+			{UserID: "guardian", Role: domain.RoleGuardian, Status: domain.MemberActive},
+			{UserID: "coguardian", Role: domain.RoleGuardian, Status: domain.MemberActive},
+			{UserID: "owner", Role: domain.RoleDependent, Status: domain.MemberActive},
+		},
+	}
+	if err := households.CreateHousehold(ctx, household); err != nil {
+		t.Fatalf("CreateHousehold: %v", err)
+	}
+	authz := usecases.NewAuthorizer(usecases.GuardianRule(households))
+	guardian := usecases.Actor{UserID: "guardian"}
+	resource := usecases.Resource{OwnerID: "owner", FlightID: "f1"}
+
+	if err := authz.Authorize(ctx, guardian, resource, usecases.ActionManage); err != nil {
+		t.Fatalf("before removal: Authorize = %v, want access", err)
+	}
+	if err := households.RemoveHouseholdMember(ctx, household.ID, "guardian"); err != nil {
+		t.Fatalf("RemoveHouseholdMember: %v", err)
+	}
+	if err := authz.Authorize(ctx, guardian, resource, usecases.ActionManage); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("after removal: Authorize = %v, want %v", err, domain.ErrForbidden)
+	}
+}
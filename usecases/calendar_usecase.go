@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// CalendarUseCase exports flights as calendar events and manages each user's secret feed URL
+type CalendarUseCase interface {
+	// ExportFlight returns one of the owner's flights for a single-event download
+	ExportFlight(ctx context.Context, actorID, ownerID, flightID string) (*domain.Flight, error)
+	// RotateCalendarToken issues a new feed token, invalidating any previous one; only its hash is stored
+	RotateCalendarToken(ctx context.Context, userID string) (string, error)
+	// RevokeCalendarToken disables the user's feed until a new token is issued
+	RevokeCalendarToken(ctx context.Context, userID string) error
+	// UpcomingFlights resolves a feed token to its owner's flights that have not yet landed, soonest first
+	UpcomingFlights(ctx context.Context, token string) ([]domain.Flight, error)
+}
+
+// calendarTokenBytes is the entropy of a feed token; the token is the only credential a calendar client sends
+const calendarTokenBytes = 32
+
+type calendarUseCase struct {
+	flightRepo domain.FlightRepository
+	userRepo   domain.UserRepository
+	authz      Authorizer
+	now        func() time.Time
+}
+
+// NewCalendarUseCase creates a new instance of calendar use case
+func NewCalendarUseCase(flightRepo domain.FlightRepository, userRepo domain.UserRepository, authz Authorizer) CalendarUseCase {
+	return &calendarUseCase{
+		flightRepo: flightRepo,
+		userRepo:   userRepo,
+		authz:      authz,
+		now:        time.Now,
+	}
+}
+
+func (uc *calendarUseCase) ExportFlight(ctx context.Context, actorID, ownerID, flightID string) (*domain.Flight, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: flightID}, ActionView); err != nil {
+		return nil, err
+	}
+	return uc.flightRepo.GetFlightByID(ctx, ownerID, flightID)
+}
+
+func (uc *calendarUseCase) RotateCalendarToken(ctx context.Context, userID string) (string, error) {
+	raw := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	if err := uc.userRepo.UpdateCalendarToken(ctx, userID, hashCalendarToken(token)); err != nil {
+		return "", err
+	}
+	logger().InfoContext(ctx, "calendar token rotated", "user_id", userID)
+	return token, nil
+}
+
+func (uc *calendarUseCase) RevokeCalendarToken(ctx context.Context, userID string) error {
+	if err := uc.userRepo.UpdateCalendarToken(ctx, userID, ""); err != nil {
+		return err
+	}
+	logger().InfoContext(ctx, "calendar token revoked", "user_id", userID)
+	return nil
+}
+
+func (uc *calendarUseCase) UpcomingFlights(ctx context.Context, token string) ([]domain.Flight, error) {
+	if token == "" {
+		return nil, domain.ErrCalendarNotFound
+	}
+	user, err := uc.userRepo.FindUserByCalendarToken(ctx, hashCalendarToken(token))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrCalendarNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	flights, err := uc.flightRepo.GetFlightsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := uc.now()
+	upcoming := make([]domain.Flight, 0, len(flights))
+	for _, flight := range flights {
+		if !flightEnd(flight).Before(now) {
+			upcoming = append(upcoming, flight)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return flightStart(upcoming[i]).Before(flightStart(upcoming[j])) })
+	return upcoming, nil
+}
+
+// hashCalendarToken is what the user record stores, so a leaked database does not leak working feed URLs
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// flightStart is the departure instant, or the start of the flight's date when no time is known
+func flightStart(flight domain.Flight) time.Time {
+	if flight.DepartureTime != nil {
+		return *flight.DepartureTime
+	}
+	return flight.Date
+}
+
+// flightEnd is the arrival instant, falling back to the departure or the end of the flight's date
+func flightEnd(flight domain.Flight) time.Time {
+	switch {
+	case flight.ArrivalTime != nil:
+		return *flight.ArrivalTime
+	case flight.DepartureTime != nil:
+		return *flight.DepartureTime
+	default:
+		return flight.Date.Add(24 * time.Hour)
+	}
+}
@@ -1,43 +1,345 @@
 package usecases
 
-import "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
-// FlightUseCase interface defines the business logic methods
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+var (
+	// flightNumberPattern matches an IATA flight designator: a two-character airline code, 1-4 digits and an optional suffix
+	flightNumberPattern = regexp.MustCompile(`^([A-Z0-9]{2})([0-9]{1,4})[A-Z]?$`)
+	airlineCodePattern  = regexp.MustCompile(`^[A-Z0-9]{2}$`)
+)
+
+// FlightUseCase interface defines the business logic methods. Every method acts for actorID on flights
+// owned by ownerID (flight.UserID when a flight is passed in) as far as the authorizer allows
 type FlightUseCase interface {
-    AddFlight(flight *domain.Flight) error
-    FetchFlightByID(id string) (*domain.Flight, error)
-    DeleteFlight(id string) error
-    FetchFlightsByUserID(userID string) ([]domain.Flight, error)
+	// AddFlight and UpdateFlight fill template questions from the owner's answer profile; saveToProfile
+	// writes the flight's template answers back to it
+	AddFlight(ctx context.Context, actorID string, flight *domain.Flight, saveToProfile bool) error
+	UpdateFlight(ctx context.Context, actorID string, flight *domain.Flight, saveToProfile bool) error
+	// CloneFlight copies one of the owner's flights as a new flight of theirs, applying the overrides
+	CloneFlight(ctx context.Context, actorID, ownerID, sourceID string, overrides FlightOverrides) (*FlightClone, error)
+	FetchFlightByID(ctx context.Context, actorID, ownerID, id string) (*domain.Flight, error)
+	// UpdateFlight and DeleteFlight only apply to the stored version when given a non-zero one, and fail
+	// with ErrVersionConflict otherwise
+	DeleteFlight(ctx context.Context, actorID, ownerID, id string, version int64) error
+	FetchFlightsByUserID(ctx context.Context, actorID, ownerID string) ([]domain.Flight, error)
+	// FetchExpiringFlights lists the owner's flights that the retention sweep has warned about, soonest
+	// purge first
+	FetchExpiringFlights(ctx context.Context, actorID, ownerID string) ([]domain.Flight, error)
+}
+
+// FlightOverrides replaces fields of a cloned flight; nil fields keep the source's value. QA, when given,
+// replaces the whole set
+type FlightOverrides struct {
+	Title       *string
+	FromCountry *string
+	ToCountry   *string
+	Language    *string
+	Date        *time.Time
+	QA          []domain.QA
+	// The flight number, airports and times belong to the old journey and are only set when given
+	FlightNumber       *string
+	OriginAirport      *string
+	DestinationAirport *string
+	DepartureTime      *time.Time
+	DepartureTimezone  *string
+	ArrivalTime        *time.Time
+	ArrivalTimezone    *string
+}
+
+// FlightClone is the stored copy with the positions of its QA pairs whose answers likely changed with the date
+type FlightClone struct {
+	Flight domain.Flight
+	Review []int
 }
 
 // flightUseCase implements the FlightUseCase interface
 type flightUseCase struct {
-    flightRepo domain.FlightRepository
+	flightRepo domain.FlightRepository
+	userRepo   domain.UserRepository
+	airports   domain.AirportDirectory
+	authz      Authorizer
 }
 
 // NewFlightUseCase creates a new instance of flight use case
-func NewFlightUseCase(repo domain.FlightRepository) FlightUseCase {
-    return &flightUseCase{
-        flightRepo: repo,
-    }
+func NewFlightUseCase(repo domain.FlightRepository, userRepo domain.UserRepository, airports domain.AirportDirectory, authz Authorizer) FlightUseCase {
+	return &flightUseCase{
+		flightRepo: repo,
+		userRepo:   userRepo,
+		airports:   airports,
+		authz:      authz,
+	}
+}
+
+// AddFlight creates a new flight, stamping its expiry from the owner's retention setting
+func (uc *flightUseCase) AddFlight(ctx context.Context, actorID string, flight *domain.Flight, saveToProfile bool) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: flight.UserID}, ActionManage); err != nil {
+		return err
+	}
+	return uc.addFlight(ctx, flight, saveToProfile)
+}
+
+func (uc *flightUseCase) addFlight(ctx context.Context, flight *domain.Flight, saveToProfile bool) error {
+	if err := uc.prepare(ctx, flight); err != nil {
+		return err
+	}
+	owner, err := uc.userRepo.FindUserByID(ctx, flight.UserID)
+	if err != nil {
+		return err
+	}
+	flight.ExpiresAt = domain.FlightExpiry(flight.Date, owner.RetentionDays)
+	flight.ExpiryWarnedAt = nil
+	if err := uc.flightRepo.CreateFlight(ctx, flight); err != nil {
+		return err
+	}
+	uc.saveToProfile(ctx, flight, saveToProfile)
+	return nil
 }
 
-// AddFlight creates a new flight
-func (uc *flightUseCase) AddFlight(flight *domain.Flight) error {
-    return uc.flightRepo.CreateFlight(flight)
+// UpdateFlight replaces a flight owned by flight.UserID. The expiry is restamped from the new date, and
+// an expiry warning already sent stands only if the expiry is unchanged
+func (uc *flightUseCase) UpdateFlight(ctx context.Context, actorID string, flight *domain.Flight, saveToProfile bool) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: flight.UserID, FlightID: flight.ID}, ActionManage); err != nil {
+		return err
+	}
+	existing, err := uc.flightRepo.GetFlightByID(ctx, flight.UserID, flight.ID)
+	if err != nil {
+		return err
+	}
+	if err := uc.prepare(ctx, flight); err != nil {
+		return err
+	}
+	owner, err := uc.userRepo.FindUserByID(ctx, flight.UserID)
+	if err != nil {
+		return err
+	}
+	flight.ExpiresAt = domain.FlightExpiry(flight.Date, owner.RetentionDays)
+	flight.ExpiryWarnedAt = nil
+	if sameTime(flight.ExpiresAt, existing.ExpiresAt) {
+		flight.ExpiryWarnedAt = existing.ExpiryWarnedAt
+	}
+	if err := uc.flightRepo.UpdateFlight(ctx, flight); err != nil {
+		return err
+	}
+	uc.saveToProfile(ctx, flight, saveToProfile)
+	return nil
 }
 
-// FetchFlightByID retrieves a flight by its ID
-func (uc *flightUseCase) FetchFlightByID(id string) (*domain.Flight, error) {
-    return uc.flightRepo.GetFlightByID(id)
+// CloneFlight copies the title, countries, language and QA of one of the owner's flights into a new flight
+func (uc *flightUseCase) CloneFlight(ctx context.Context, actorID, ownerID, sourceID string, overrides FlightOverrides) (*FlightClone, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: sourceID}, ActionManage); err != nil {
+		return nil, err
+	}
+	source, err := uc.flightRepo.GetFlightByID(ctx, ownerID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := domain.Flight{
+		Title:       source.Title,
+		FromCountry: source.FromCountry,
+		ToCountry:   source.ToCountry,
+		UserID:      ownerID,
+		Language:    source.Language,
+		QA:          append([]domain.QA(nil), source.QA...),
+	}
+	applyOverrides(&clone, overrides)
+	// Like a new flight without a date, the clone defaults to today
+	if clone.Date.IsZero() && clone.DepartureTime == nil {
+		clone.Date = time.Now()
+	}
+
+	if err := uc.addFlight(ctx, &clone, false); err != nil {
+		return nil, err
+	}
+	result := &FlightClone{Flight: clone}
+	for i, qa := range clone.QA {
+		if domain.IsDateSensitive(qa, clone.ToCountry) {
+			result.Review = append(result.Review, i)
+		}
+	}
+	return result, nil
+}
+
+func applyOverrides(flight *domain.Flight, o FlightOverrides) {
+	setString(&flight.Title, o.Title)
+	setString(&flight.FromCountry, o.FromCountry)
+	setString(&flight.ToCountry, o.ToCountry)
+	setString(&flight.Language, o.Language)
+	if o.Date != nil {
+		flight.Date = *o.Date
+	}
+	if o.QA != nil {
+		flight.QA = o.QA
+	}
+	setString(&flight.FlightNumber, o.FlightNumber)
+	setString(&flight.OriginAirport, o.OriginAirport)
+	setString(&flight.DestinationAirport, o.DestinationAirport)
+	setString(&flight.DepartureTimezone, o.DepartureTimezone)
+	setString(&flight.ArrivalTimezone, o.ArrivalTimezone)
+	flight.DepartureTime = o.DepartureTime
+	flight.ArrivalTime = o.ArrivalTime
+}
+
+func setString(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+// prepare fills the flight from the answer profile and validates it
+func (uc *flightUseCase) prepare(ctx context.Context, flight *domain.Flight) error {
+	if err := fillFromProfile(ctx, uc.userRepo, flight.UserID, flight.QA); err != nil {
+		return err
+	}
+	return enrichFlight(uc.airports, flight)
+}
+
+// saveToProfile writes the answers of a stored flight back to the owner's profile when asked. It runs only
+// after the flight write succeeded, so a rejected or conflicting write never changes the profile; a failure
+// here is logged rather than returned because the flight itself is already saved
+func (uc *flightUseCase) saveToProfile(ctx context.Context, flight *domain.Flight, save bool) {
+	if !save {
+		return
+	}
+	if err := writeBackAnswers(ctx, uc.userRepo, flight.UserID, flight.ToCountry, flight.QA, time.Now()); err != nil {
+		logger().WarnContext(ctx, "saving answers to profile failed", "flight_id", flight.ID, "user_id", flight.UserID, "error", err)
+	}
+}
+
+// FetchFlightByID retrieves one of the owner's flights by its ID
+func (uc *flightUseCase) FetchFlightByID(ctx context.Context, actorID, ownerID, id string) (*domain.Flight, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: id}, ActionView); err != nil {
+		return nil, err
+	}
+	return uc.flightRepo.GetFlightByID(ctx, ownerID, id)
+}
+
+// DeleteFlight removes one of the owner's flights by its ID
+func (uc *flightUseCase) DeleteFlight(ctx context.Context, actorID, ownerID, id string, version int64) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: id}, ActionManage); err != nil {
+		return err
+	}
+	return uc.flightRepo.DeleteFlight(ctx, ownerID, id, version)
+}
+
+// FetchFlightsByUserID retrieves all flights of the owner
+func (uc *flightUseCase) FetchFlightsByUserID(ctx context.Context, actorID, ownerID string) ([]domain.Flight, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID}, ActionView); err != nil {
+		return nil, err
+	}
+	return uc.flightRepo.GetFlightsByUserID(ctx, ownerID)
+}
+
+// FetchExpiringFlights is how a warning reaches the owner: the sweep marks flights as warned and clients
+// show what this returns
+func (uc *flightUseCase) FetchExpiringFlights(ctx context.Context, actorID, ownerID string) ([]domain.Flight, error) {
+	flights, err := uc.FetchFlightsByUserID(ctx, actorID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	expiring := []domain.Flight{}
+	for _, flight := range flights {
+		if flight.ExpiryWarnedAt != nil && flight.ExpiresAt != nil {
+			expiring = append(expiring, flight)
+		}
+	}
+	sort.Slice(expiring, func(i, j int) bool { return expiring[i].ExpiresAt.Before(*expiring[j].ExpiresAt) })
+	return expiring, nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// enrichFlight normalizes the optional flight metadata, deriving countries, airline and timezones from the
+// airport dataset and the flight number, and rejects anything it cannot resolve
+func enrichFlight(airports domain.AirportDirectory, flight *domain.Flight) error {
+	invalid := map[string]string{}
+
+	flight.FlightNumber = strings.ToUpper(strings.ReplaceAll(flight.FlightNumber, " ", ""))
+	flight.AirlineCode = strings.ToUpper(strings.TrimSpace(flight.AirlineCode))
+	if flight.FlightNumber != "" {
+		match := flightNumberPattern.FindStringSubmatch(flight.FlightNumber)
+		switch {
+		case match == nil:
+			invalid["flight_number"] = "iata_designator"
+		case flight.AirlineCode == "":
+			flight.AirlineCode = match[1]
+		case flight.AirlineCode != match[1]:
+			invalid["airline_code"] = "must match flight_number"
+		}
+	}
+	if flight.AirlineCode != "" && !airlineCodePattern.MatchString(flight.AirlineCode) {
+		invalid["airline_code"] = "iata_code"
+	}
+
+	flight.OriginAirport, flight.FromCountry, flight.DepartureTimezone =
+		resolveAirport(airports, invalid, "origin_airport", flight.OriginAirport, flight.FromCountry, flight.DepartureTimezone)
+	flight.DestinationAirport, flight.ToCountry, flight.ArrivalTimezone =
+		resolveAirport(airports, invalid, "destination_airport", flight.DestinationAirport, flight.ToCountry, flight.ArrivalTimezone)
+
+	checkZonedTime(invalid, "departure", flight.DepartureTime, flight.DepartureTimezone)
+	checkZonedTime(invalid, "arrival", flight.ArrivalTime, flight.ArrivalTimezone)
+	if flight.DepartureTime != nil && flight.ArrivalTime != nil && flight.ArrivalTime.Before(*flight.DepartureTime) {
+		invalid["arrival_time"] = "must not be before departure_time"
+	}
+
+	if len(invalid) > 0 {
+		return domain.NewValidationError("Invalid flight details", invalid)
+	}
+
+	if flight.DepartureTime != nil {
+		departure := flight.DepartureTime.UTC()
+		flight.DepartureTime = &departure
+		if flight.Date.IsZero() {
+			flight.Date = departure
+		}
+	}
+	if flight.ArrivalTime != nil {
+		arrival := flight.ArrivalTime.UTC()
+		flight.ArrivalTime = &arrival
+	}
+	return nil
 }
 
-// DeleteFlight removes a flight by its ID
-func (uc *flightUseCase) DeleteFlight(id string) error {
-    return uc.flightRepo.DeleteFlight(id)
+// resolveAirport looks up an optional IATA code and returns it with the country it decides and the
+// timezone to use, which defaults to the airport's own
+func resolveAirport(airports domain.AirportDirectory, invalid map[string]string, field, code, country, timezone string) (string, string, string) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return code, country, timezone
+	}
+	airport, ok := airports.LookupAirport(code)
+	if !ok {
+		invalid[field] = "unknown_airport"
+		return code, country, timezone
+	}
+	if timezone == "" {
+		timezone = airport.Timezone
+	}
+	return airport.IATA, airport.Country, timezone
 }
 
-// FetchFlightsByUserID retrieves all flights for a specific user
-func (uc *flightUseCase) FetchFlightsByUserID(userID string) ([]domain.Flight, error) {
-    return uc.flightRepo.GetFlightsByUserID(userID)
+// checkZonedTime requires a valid IANA timezone alongside a time, whether given or taken from the airport
+func checkZonedTime(invalid map[string]string, prefix string, t *time.Time, timezone string) {
+	if timezone == "" {
+		if t != nil {
+			invalid[prefix+"_timezone"] = "required"
+		}
+		return
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		invalid[prefix+"_timezone"] = "iana_timezone"
+	}
 }
@@ -0,0 +1,236 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// HouseholdUseCase manages households and the roles of their members
+type HouseholdUseCase interface {
+	// CreateHousehold makes the user the first guardian of a new household
+	CreateHousehold(ctx context.Context, userID, name string) (*domain.Household, error)
+	ListHouseholds(ctx context.Context, userID string) ([]domain.Household, error)
+	// GetHousehold returns a household the user belongs to or is invited to
+	GetHousehold(ctx context.Context, userID, householdID string) (*domain.Household, error)
+	// AddDependent creates an account that cannot sign in for someone whose flights a guardian manages
+	AddDependent(ctx context.Context, userID, householdID, name string) (*domain.HouseholdMember, error)
+	// InviteMember invites an existing user by username; the role takes effect once they accept
+	InviteMember(ctx context.Context, userID, householdID, username string, role domain.HouseholdRole) (*domain.HouseholdMember, error)
+	AcceptInvitation(ctx context.Context, userID, householdID string) error
+	// RemoveMember lets a guardian remove anyone and any member leave; the household is deleted with its
+	// last member. The last active guardian cannot leave while others remain, so dependents are never left
+	// without someone to manage them
+	RemoveMember(ctx context.Context, userID, householdID, memberID string) error
+}
+
+// householdUseCase implements the HouseholdUseCase interface
+type householdUseCase struct {
+	householdRepo domain.HouseholdRepository
+	userRepo      domain.UserRepository
+	now           func() time.Time
+}
+
+// maxHouseholdName caps household and dependent names
+const maxHouseholdName = 100
+
+// dependentEmailDomain is a reserved domain, so dependents' placeholder addresses can never receive mail
+// or collide with a real registration
+const dependentEmailDomain = "dependents.invalid"
+
+// NewHouseholdUseCase creates a new instance of household use case
+func NewHouseholdUseCase(householdRepo domain.HouseholdRepository, userRepo domain.UserRepository) HouseholdUseCase {
+	return &householdUseCase{
+		householdRepo: householdRepo,
+		userRepo:      userRepo,
+		now:           time.Now,
+	}
+}
+
+func (uc *householdUseCase) CreateHousehold(ctx context.Context, userID, name string) (*domain.Household, error) {
+	name, err := householdName(name)
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now().UTC().Truncate(time.Second)
+	household := &domain.Household{
+		Name:      name,
+		CreatedAt: now,
+		Members: []domain.HouseholdMember{{
+			UserID:  userID,
+			Name:    user.Username,
+			Role:    domain.RoleGuardian,
+			Status:  domain.MemberActive,
+			AddedAt: now,
+		}},
+	}
+	if err := uc.householdRepo.CreateHousehold(ctx, household); err != nil {
+		return nil, err
+	}
+	logger().InfoContext(ctx, "household created", "user_id", userID, "household_id", household.ID)
+	return household, nil
+}
+
+func (uc *householdUseCase) ListHouseholds(ctx context.Context, userID string) ([]domain.Household, error) {
+	return uc.householdRepo.GetHouseholdsByUserID(ctx, userID)
+}
+
+// GetHousehold reports households the user is not part of as not found, so their existence is not revealed
+func (uc *householdUseCase) GetHousehold(ctx context.Context, userID, householdID string) (*domain.Household, error) {
+	household, err := uc.householdRepo.GetHouseholdByID(ctx, householdID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := household.Member(userID); !ok {
+		return nil, domain.ErrHouseholdNotFound
+	}
+	return household, nil
+}
+
+func (uc *householdUseCase) AddDependent(ctx context.Context, userID, householdID, name string) (*domain.HouseholdMember, error) {
+	name, err := householdName(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.guardedHousehold(ctx, userID, householdID); err != nil {
+		return nil, err
+	}
+	guardian, err := uc.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dependent gets a user of their own so flights, answers and data keys stay separate. With no
+	// password hash every login attempt fails
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	handle := "dependent-" + hex.EncodeToString(raw)
+	dependent := &domain.User{
+		Username:      handle,
+		Email:         handle + "@" + dependentEmailDomain,
+		RetentionDays: guardian.RetentionDays,
+	}
+	if err := uc.userRepo.CreateUser(ctx, dependent); err != nil {
+		return nil, err
+	}
+
+	member := domain.HouseholdMember{
+		UserID:  dependent.ID,
+		Name:    name,
+		Role:    domain.RoleDependent,
+		Status:  domain.MemberActive,
+		AddedAt: uc.now().UTC().Truncate(time.Second),
+	}
+	if err := uc.householdRepo.AddHouseholdMember(ctx, householdID, member); err != nil {
+		return nil, err
+	}
+	logger().InfoContext(ctx, "household dependent added", "user_id", userID, "household_id", householdID, "dependent_id", dependent.ID)
+	return &member, nil
+}
+
+func (uc *householdUseCase) InviteMember(ctx context.Context, userID, householdID, username string, role domain.HouseholdRole) (*domain.HouseholdMember, error) {
+	invalid := map[string]string{}
+	username = strings.TrimSpace(username)
+	if username == "" {
+		invalid["username"] = "required"
+	}
+	if !role.Valid() {
+		invalid["role"] = "oneof=guardian member dependent"
+	}
+	if len(invalid) > 0 {
+		return nil, domain.NewValidationError("Invalid invitation", invalid)
+	}
+	if _, err := uc.guardedHousehold(ctx, userID, householdID); err != nil {
+		return nil, err
+	}
+
+	invitee, err := uc.userRepo.FindUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	member := domain.HouseholdMember{
+		UserID:  invitee.ID,
+		Name:    invitee.Username,
+		Role:    role,
+		Status:  domain.MemberInvited,
+		AddedAt: uc.now().UTC().Truncate(time.Second),
+	}
+	if err := uc.householdRepo.AddHouseholdMember(ctx, householdID, member); err != nil {
+		return nil, err
+	}
+	logger().InfoContext(ctx, "household member invited", "user_id", userID, "household_id", householdID, "invitee_id", invitee.ID, "role", string(role))
+	return &member, nil
+}
+
+func (uc *householdUseCase) AcceptInvitation(ctx context.Context, userID, householdID string) error {
+	household, err := uc.GetHousehold(ctx, userID, householdID)
+	if err != nil {
+		return err
+	}
+	member, _ := household.Member(userID)
+	if member.Status != domain.MemberInvited {
+		return domain.ErrMemberNotFound
+	}
+	member.Status = domain.MemberActive
+	if err := uc.householdRepo.UpdateHouseholdMember(ctx, householdID, member); err != nil {
+		return err
+	}
+	logger().InfoContext(ctx, "household invitation accepted", "user_id", userID, "household_id", householdID, "role", string(member.Role))
+	return nil
+}
+
+func (uc *householdUseCase) RemoveMember(ctx context.Context, userID, householdID, memberID string) error {
+	household, err := uc.GetHousehold(ctx, userID, householdID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !household.HasRole(userID, domain.RoleGuardian) {
+		return domain.ErrForbidden
+	}
+	member, ok := household.Member(memberID)
+	if !ok {
+		return domain.ErrMemberNotFound
+	}
+
+	// The repository keeps the last guardian in place and drops the household with its last member
+	if err := uc.householdRepo.RemoveHouseholdMember(ctx, householdID, memberID); err != nil {
+		return err
+	}
+	logger().InfoContext(ctx, "household member removed", "user_id", userID, "household_id", householdID, "member_id", memberID, "role", string(member.Role))
+	return nil
+}
+
+// guardedHousehold loads a household the user is an active guardian of
+func (uc *householdUseCase) guardedHousehold(ctx context.Context, userID, householdID string) (*domain.Household, error) {
+	household, err := uc.GetHousehold(ctx, userID, householdID)
+	if err != nil {
+		return nil, err
+	}
+	if !household.HasRole(userID, domain.RoleGuardian) {
+		return nil, domain.ErrForbidden
+	}
+	return household, nil
+}
+
+// householdName trims a household or dependent name and checks its length
+func householdName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", domain.NewValidationError("Invalid name", map[string]string{"name": "required"})
+	case len(name) > maxHouseholdName:
+		return "", domain.NewValidationError("Invalid name", map[string]string{"name": "max=100"})
+	}
+	return name, nil
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// ImportUseCase turns travel documents into draft flights the traveller reviews before saving
+type ImportUseCase interface {
+	// ImportBoardingPass reads a BCBP boarding pass, from its decoded text or an image of its barcode,
+	// and returns it with one pre-filled flight per leg
+	ImportBoardingPass(ctx context.Context, userID, data string, image []byte) (*BoardingPassImport, error)
+	// ImportItinerary reads an airline confirmation email (.eml) or calendar file (.ics) and returns
+	// the flights found in it, each with a pre-filled flight
+	ImportItinerary(ctx context.Context, userID string, file []byte) (*ItineraryImport, error)
+}
+
+// BoardingPassImport is a parsed boarding pass with the flights drafted from it; the drafts are not stored
+type BoardingPassImport struct {
+	BoardingPass domain.BoardingPass
+	Flights      []domain.Flight
+}
+
+// ItineraryImport is the flights found in an itinerary with the flights drafted from them; the drafts are not stored
+type ItineraryImport struct {
+	Segments []domain.ItinerarySegment
+	Flights  []domain.Flight
+}
+
+type importUseCase struct {
+	airports    domain.AirportDirectory
+	barcodes    domain.BarcodeDecoder
+	itineraries domain.ItineraryReader
+	now         func() time.Time
+}
+
+// NewImportUseCase creates an import use case that decodes barcodes and itineraries in process and enriches
+// drafts from the airport dataset
+func NewImportUseCase(airports domain.AirportDirectory, barcodes domain.BarcodeDecoder, itineraries domain.ItineraryReader) ImportUseCase {
+	return &importUseCase{
+		airports:    airports,
+		barcodes:    barcodes,
+		itineraries: itineraries,
+		now:         time.Now,
+	}
+}
+
+// ImportBoardingPass decodes the barcode image when no text is given, parses it and drafts a flight per leg
+func (uc *importUseCase) ImportBoardingPass(ctx context.Context, userID, data string, image []byte) (*BoardingPassImport, error) {
+	if data == "" {
+		if len(image) == 0 {
+			return nil, domain.NewValidationError("Send either the barcode text or an image of it",
+				map[string]string{"data": "required_without=image"})
+		}
+		decoded, err := uc.barcodes.DecodeBarcode(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+
+	pass, err := domain.ParseBoardingPass(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BoardingPassImport{BoardingPass: *pass}
+	for _, leg := range pass.Legs {
+		result.Flights = append(result.Flights, uc.draftFlight(userID, leg))
+	}
+	return result, nil
+}
+
+// ImportItinerary extracts the flights in an uploaded itinerary and drafts one flight for each
+func (uc *importUseCase) ImportItinerary(ctx context.Context, userID string, file []byte) (*ItineraryImport, error) {
+	if len(file) == 0 {
+		return nil, domain.NewValidationError("Upload an .eml or .ics file", map[string]string{"file": "required"})
+	}
+	segments, err := uc.itineraries.ReadItinerary(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ItineraryImport{Segments: segments}
+	for _, segment := range segments {
+		result.Flights = append(result.Flights, uc.finishDraft(domain.Flight{
+			UserID:             userID,
+			FlightNumber:       segment.FlightNumber,
+			OriginAirport:      segment.OriginAirport,
+			DestinationAirport: segment.DestinationAirport,
+			Date:               segment.Date,
+			DepartureTime:      segment.DepartureTime,
+			DepartureTimezone:  segment.DepartureTimezone,
+			ArrivalTime:        segment.ArrivalTime,
+			ArrivalTimezone:    segment.ArrivalTimezone,
+		}))
+	}
+	logger().InfoContext(ctx, "itinerary imported", "user_id", userID, "flights", len(result.Flights))
+	return result, nil
+}
+
+// draftFlight pre-fills a flight from a boarding-pass leg
+func (uc *importUseCase) draftFlight(userID string, leg domain.BoardingPassLeg) domain.Flight {
+	return uc.finishDraft(domain.Flight{
+		UserID:             userID,
+		FlightNumber:       leg.FlightNumber,
+		AirlineCode:        leg.Carrier,
+		OriginAirport:      leg.FromAirport,
+		DestinationAirport: leg.ToAirport,
+		Date:               leg.FlightDate(uc.now()),
+	})
+}
+
+// finishDraft titles a draft after its flight and cities and enriches it from the airport dataset; airports
+// missing from the dataset are kept as given and left for the traveller to complete
+func (uc *importUseCase) finishDraft(draft domain.Flight) domain.Flight {
+	from, to := draft.OriginAirport, draft.DestinationAirport
+	if airport, ok := uc.airports.LookupAirport(from); ok {
+		from = airport.City
+	}
+	if airport, ok := uc.airports.LookupAirport(to); ok {
+		to = airport.City
+	}
+	draft.Title = strings.TrimSpace(fmt.Sprintf("%s %s to %s", draft.FlightNumber, from, to))
+	if from == "" || to == "" {
+		draft.Title = strings.TrimSpace(draft.FlightNumber + " " + from + to)
+	}
+
+	enriched := draft
+	if err := enrichFlight(uc.airports, &enriched); err == nil {
+		return enriched
+	}
+	return draft
+}
@@ -0,0 +1,202 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// RetentionUseCase manages per-user retention, purges flights and trips once they expire and prunes old
+// flight tombstones from the change feed
+type RetentionUseCase interface {
+	// SetRetention takes the user version the change was made against, zero applying it unconditionally, and
+	// returns the user's new version
+	SetRetention(ctx context.Context, userID string, retentionDays int, version int64) (int64, error)
+	Sweep(ctx context.Context, now time.Time) (RetentionSweep, error)
+}
+
+// RetentionOptions tunes the behaviour of the retention use case
+type RetentionOptions struct {
+	// Warning is how long before a purge the owner is notified; a purge is never sooner than this after the warning
+	Warning time.Duration
+	// TombstoneRetention is how long deleted flights stay in the change feed; zero keeps them forever. It
+	// must match SyncOptions.TombstoneRetention
+	TombstoneRetention time.Duration
+}
+
+// RetentionSweep reports what a single sweep did
+type RetentionSweep struct {
+	Warned     int
+	Purged     int
+	Tombstones int
+}
+
+// retentionUseCase implements the RetentionUseCase interface
+type retentionUseCase struct {
+	flightRepo domain.FlightRepository
+	tripRepo   domain.TripRepository
+	userRepo   domain.UserRepository
+	notifier   domain.ExpiryNotifier
+	options    RetentionOptions
+}
+
+// NewRetentionUseCase creates a new instance of retention use case
+func NewRetentionUseCase(flightRepo domain.FlightRepository, tripRepo domain.TripRepository, userRepo domain.UserRepository, notifier domain.ExpiryNotifier, options RetentionOptions) RetentionUseCase {
+	return &retentionUseCase{
+		flightRepo: flightRepo,
+		tripRepo:   tripRepo,
+		userRepo:   userRepo,
+		notifier:   notifier,
+		options:    options,
+	}
+}
+
+// SetRetention stores the user's retention setting and recomputes the expiry of each of their flights and trips
+func (uc *retentionUseCase) SetRetention(ctx context.Context, userID string, retentionDays int, version int64) (int64, error) {
+	if retentionDays < 0 || retentionDays > domain.MaxRetentionDays {
+		return 0, domain.NewValidationError("retention_days must be between 0 and "+strconv.Itoa(domain.MaxRetentionDays),
+			map[string]string{"retention_days": "range"})
+	}
+	updated, err := uc.userRepo.UpdateRetention(ctx, userID, retentionDays, version)
+	if err != nil {
+		return 0, err
+	}
+
+	flights, err := uc.flightRepo.GetFlightsByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, flight := range flights {
+		// A changed expiry needs a fresh warning before the flight can be purged
+		expiresAt := domain.FlightExpiry(flight.Date, retentionDays)
+		if err := uc.flightRepo.SetFlightExpiry(ctx, flight.ID, expiresAt, nil); err != nil && !errors.Is(err, domain.ErrFlightNotFound) {
+			return 0, err
+		}
+	}
+
+	trips, err := uc.tripRepo.GetTripsByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, trip := range trips {
+		expiresAt := domain.TripExpiry(trip, retentionDays)
+		if err := uc.tripRepo.SetTripExpiry(ctx, trip.ID, expiresAt, nil); err != nil && !errors.Is(err, domain.ErrTripNotFound) {
+			return 0, err
+		}
+	}
+	logger().InfoContext(ctx, "retention updated", "user_id", userID, "retention_days", retentionDays,
+		"flights", len(flights), "trips", len(trips))
+	return updated, nil
+}
+
+// Sweep warns owners of flights and trips entering the warning window, deletes warned ones whose expiry
+// has passed and prunes tombstones past the retention horizon
+func (uc *retentionUseCase) Sweep(ctx context.Context, now time.Time) (RetentionSweep, error) {
+	var result RetentionSweep
+	if err := uc.sweepFlights(ctx, now, &result); err != nil {
+		return result, err
+	}
+	if err := uc.sweepTrips(ctx, now, &result); err != nil {
+		return result, err
+	}
+	if uc.options.TombstoneRetention > 0 {
+		pruned, err := uc.flightRepo.PruneTombstones(ctx, now.Add(-uc.options.TombstoneRetention))
+		result.Tombstones = pruned
+		return result, err
+	}
+	return result, nil
+}
+
+// sweepFlights warns about and purges expiring flights, adding to result
+func (uc *retentionUseCase) sweepFlights(ctx context.Context, now time.Time, result *RetentionSweep) error {
+	flights, err := uc.flightRepo.FindExpiringFlights(ctx, now.Add(uc.options.Warning))
+	if err != nil {
+		return err
+	}
+
+	for _, flight := range flights {
+		if flight.ExpiryWarnedAt == nil {
+			// Push the expiry back if needed so the owner always gets the full warning period
+			expiresAt := *flight.ExpiresAt
+			if earliest := now.Add(uc.options.Warning); expiresAt.Before(earliest) {
+				expiresAt = earliest
+			}
+			// Marking the flight is what shows the warning in the app; the notifier only adds other channels
+			warnedAt := now
+			err := uc.flightRepo.SetFlightExpiry(ctx, flight.ID, &expiresAt, &warnedAt)
+			if errors.Is(err, domain.ErrFlightNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := uc.notifier.NotifyFlightExpiring(ctx, flight, expiresAt); err != nil {
+				logger().WarnContext(ctx, "expiry notification failed", "flight_id", flight.ID, "error", err)
+			}
+			result.Warned++
+			continue
+		}
+
+		if flight.ExpiresAt.After(now) {
+			continue
+		}
+		// A flight edited since it was read is left for the next sweep, which sees its new expiry
+		err := uc.flightRepo.DeleteFlight(ctx, flight.UserID, flight.ID, flight.Version)
+		if errors.Is(err, domain.ErrVersionConflict) {
+			continue
+		}
+		if err != nil && !errors.Is(err, domain.ErrFlightNotFound) {
+			return err
+		}
+		logger().InfoContext(ctx, "flight purged", "flight_id", flight.ID, "user_id", flight.UserID)
+		result.Purged++
+	}
+	return nil
+}
+
+// sweepTrips warns about and purges expiring trips the same way, adding to result
+func (uc *retentionUseCase) sweepTrips(ctx context.Context, now time.Time, result *RetentionSweep) error {
+	trips, err := uc.tripRepo.FindExpiringTrips(ctx, now.Add(uc.options.Warning))
+	if err != nil {
+		return err
+	}
+
+	for _, trip := range trips {
+		if trip.ExpiryWarnedAt == nil {
+			expiresAt := *trip.ExpiresAt
+			if earliest := now.Add(uc.options.Warning); expiresAt.Before(earliest) {
+				expiresAt = earliest
+			}
+			warnedAt := now
+			err := uc.tripRepo.SetTripExpiry(ctx, trip.ID, &expiresAt, &warnedAt)
+			if errors.Is(err, domain.ErrTripNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := uc.notifier.NotifyTripExpiring(ctx, trip, expiresAt); err != nil {
+				logger().WarnContext(ctx, "expiry notification failed", "trip_id", trip.ID, "error", err)
+			}
+			result.Warned++
+			continue
+		}
+
+		if trip.ExpiresAt.After(now) {
+			continue
+		}
+		err := uc.tripRepo.DeleteTrip(ctx, trip.UserID, trip.ID, trip.Version)
+		if errors.Is(err, domain.ErrVersionConflict) {
+			continue
+		}
+		if err != nil && !errors.Is(err, domain.ErrTripNotFound) {
+			return err
+		}
+		logger().InfoContext(ctx, "trip purged", "trip_id", trip.ID, "user_id", trip.UserID)
+		result.Purged++
+	}
+	return nil
+}
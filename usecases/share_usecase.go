@@ -0,0 +1,191 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// ShareUseCase manages read-only links that show one flight to someone without an account
+type ShareUseCase interface {
+	// CreateShare issues a link to one of the owner's flights; a nil expiresAt uses the default lifetime
+	CreateShare(ctx context.Context, actorID, ownerID, flightID, label string, expiresAt *time.Time) (*SharedLink, error)
+	// ListShares returns every link issued for one of the owner's flights, newest first
+	ListShares(ctx context.Context, actorID, ownerID, flightID string) ([]SharedLink, error)
+	// RevokeShare stops a link from working; revoking twice is not an error
+	RevokeShare(ctx context.Context, actorID, ownerID, flightID, shareID string) error
+	// OpenShare resolves a link's token to its flight and records the access
+	OpenShare(ctx context.Context, token string) (*domain.Flight, error)
+}
+
+// ShareOptions bounds the lifetime of share links
+type ShareOptions struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// SharedLink is a share with the token its link carries. Tokens are signed rather than stored, so the owner
+// can be shown the link again for as long as it works
+type SharedLink struct {
+	Share domain.FlightShare
+	Token string
+}
+
+type shareUseCase struct {
+	shareRepo  domain.ShareRepository
+	flightRepo domain.FlightRepository
+	signer     domain.ShareTokenSigner
+	authz      Authorizer
+	options    ShareOptions
+	now        func() time.Time
+}
+
+// maxShareLabel caps the owner's note on a link
+const maxShareLabel = 100
+
+// NewShareUseCase creates a new instance of share use case
+func NewShareUseCase(shareRepo domain.ShareRepository, flightRepo domain.FlightRepository, signer domain.ShareTokenSigner, authz Authorizer, options ShareOptions) ShareUseCase {
+	return &shareUseCase{
+		shareRepo:  shareRepo,
+		flightRepo: flightRepo,
+		signer:     signer,
+		authz:      authz,
+		options:    options,
+		now:        time.Now,
+	}
+}
+
+func (uc *shareUseCase) CreateShare(ctx context.Context, actorID, ownerID, flightID, label string, expiresAt *time.Time) (*SharedLink, error) {
+	if _, err := uc.managedFlight(ctx, actorID, ownerID, flightID); err != nil {
+		return nil, err
+	}
+
+	now := uc.now().UTC().Truncate(time.Second)
+	expiry := now.Add(uc.options.DefaultTTL)
+	invalid := map[string]string{}
+	if expiresAt != nil {
+		expiry = expiresAt.UTC().Truncate(time.Second)
+		switch {
+		case !expiry.After(now):
+			invalid["expires_at"] = "must be in the future"
+		case expiry.After(now.Add(uc.options.MaxTTL)):
+			invalid["expires_at"] = "must be within " + uc.options.MaxTTL.String()
+		}
+	}
+	label = strings.TrimSpace(label)
+	if len(label) > maxShareLabel {
+		invalid["label"] = "max=100"
+	}
+	if len(invalid) > 0 {
+		return nil, domain.NewValidationError("Invalid share link", invalid)
+	}
+
+	share := &domain.FlightShare{
+		FlightID:  flightID,
+		UserID:    ownerID,
+		Label:     label,
+		CreatedAt: now,
+		ExpiresAt: expiry,
+	}
+	if err := uc.shareRepo.CreateShare(ctx, share); err != nil {
+		return nil, err
+	}
+	token, err := uc.signer.SignShareToken(share.ID, share.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	logger().InfoContext(ctx, "flight share created", "user_id", actorID, "owner_id", ownerID, "flight_id", flightID, "share_id", share.ID)
+	return &SharedLink{Share: *share, Token: token}, nil
+}
+
+func (uc *shareUseCase) ListShares(ctx context.Context, actorID, ownerID, flightID string) ([]SharedLink, error) {
+	if _, err := uc.managedFlight(ctx, actorID, ownerID, flightID); err != nil {
+		return nil, err
+	}
+	shares, err := uc.shareRepo.GetSharesByFlightID(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	links := make([]SharedLink, 0, len(shares))
+	for _, share := range shares {
+		link := SharedLink{Share: share}
+		// Dead links are listed for their history but not handed out again
+		if share.Active(now) {
+			if link.Token, err = uc.signer.SignShareToken(share.ID, share.ExpiresAt); err != nil {
+				return nil, err
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+func (uc *shareUseCase) RevokeShare(ctx context.Context, actorID, ownerID, flightID, shareID string) error {
+	if _, err := uc.managedFlight(ctx, actorID, ownerID, flightID); err != nil {
+		return err
+	}
+	share, err := uc.shareRepo.GetShareByID(ctx, shareID)
+	if err != nil {
+		return err
+	}
+	if share.FlightID != flightID {
+		return domain.ErrShareNotFound
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+	if err := uc.shareRepo.RevokeShare(ctx, shareID, uc.now().UTC()); err != nil {
+		return err
+	}
+	logger().InfoContext(ctx, "flight share revoked", "user_id", actorID, "owner_id", ownerID, "flight_id", flightID, "share_id", shareID)
+	return nil
+}
+
+// OpenShare answers every failure with ErrShareNotFound, so a visitor cannot tell a forged link from an
+// expired or revoked one
+func (uc *shareUseCase) OpenShare(ctx context.Context, token string) (*domain.Flight, error) {
+	shareID, err := uc.signer.VerifyShareToken(token)
+	if err != nil {
+		return nil, domain.ErrShareNotFound
+	}
+	share, err := uc.shareRepo.GetShareByID(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+	err = uc.authz.Authorize(ctx, Actor{Share: share}, Resource{OwnerID: share.UserID, FlightID: share.FlightID}, ActionView)
+	if errors.Is(err, domain.ErrForbidden) {
+		return nil, domain.ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	flight, err := uc.flightRepo.GetFlightByID(ctx, share.UserID, share.FlightID)
+	if errors.Is(err, domain.ErrFlightNotFound) {
+		return nil, domain.ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now().UTC()
+
+	if err := uc.shareRepo.RecordShareAccess(ctx, share.ID, now); err != nil {
+		return nil, err
+	}
+	logger().InfoContext(ctx, "flight share opened", "flight_id", flight.ID, "share_id", share.ID)
+	return flight, nil
+}
+
+// managedFlight loads one of the owner's flights after checking that the actor may manage it
+func (uc *shareUseCase) managedFlight(ctx context.Context, actorID, ownerID, flightID string) (*domain.Flight, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: flightID}, ActionManage); err != nil {
+		return nil, err
+	}
+	return uc.flightRepo.GetFlightByID(ctx, ownerID, flightID)
+}
@@ -0,0 +1,336 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// SyncUseCase lets a device that worked offline push the flight changes it made and pull everyone else's
+type SyncUseCase interface {
+	// Sync applies the mutations in order, then returns up to limit of the owner's flight changes after the
+	// cursor. An empty cursor starts at the beginning of the feed, as does one older than the tombstone
+	// retention, which comes back marked FullResync
+	Sync(ctx context.Context, actorID, ownerID, cursor string, limit int, mutations []SyncMutation) (*SyncResult, error)
+}
+
+// SyncOp is what a mutation does to a flight
+type SyncOp string
+
+const (
+	SyncCreate SyncOp = "create"
+	SyncUpdate SyncOp = "update"
+	SyncDelete SyncOp = "delete"
+)
+
+// SyncMutation is one change a device made offline. Updates and deletes name the version they were made
+// against; a created flight has no ID yet, so ClientRef ties its result back to the device's record, and a
+// retried create with the same ClientRef returns the flight the first attempt made
+type SyncMutation struct {
+	Op            SyncOp
+	ClientRef     string
+	FlightID      string
+	Version       int64
+	Flight        domain.Flight
+	SaveToProfile bool
+}
+
+// MutationStatus is the outcome of a mutation
+type MutationStatus string
+
+const (
+	MutationApplied MutationStatus = "applied"
+	// MutationConflict means the flight changed or was deleted since the device last saw it
+	MutationConflict MutationStatus = "conflict"
+	MutationRejected MutationStatus = "rejected"
+)
+
+// MutationResult reports one mutation. Flight is the stored flight after an applied write and the server's
+// copy on a conflict, so the device can resolve it; it is nil once the flight is gone. Err says why a
+// mutation was rejected
+type MutationResult struct {
+	ClientRef string
+	Op        SyncOp
+	FlightID  string
+	Status    MutationStatus
+	Flight    *domain.Flight
+	Err       *domain.Error
+}
+
+// SyncResult holds the outcome of each mutation and the next page of the change feed. The device stores
+// Cursor for its next sync and asks again straight away while HasMore is set. The feed may repeat changes
+// the device has already seen; it keeps whichever copy of a flight has the higher version. FullResync means
+// the cursor was too old to catch up from: the device drops its flights and rebuilds them from the feed
+type SyncResult struct {
+	Results    []MutationResult
+	Changes    []domain.FlightChange
+	Cursor     string
+	HasMore    bool
+	FullResync bool
+}
+
+// SyncOptions tunes the change feed
+type SyncOptions struct {
+	// SafetyLag is how far the cursor stays behind the start of a read. Writes stamp their time before they
+	// commit, so one that commits during a read can carry an earlier time than changes the read returned;
+	// re-reading the lag on the next sync picks it up. It must exceed the storage write timeout
+	SafetyLag time.Duration
+	// TombstoneRetention is how long deletes stay in the feed. Cursors older than this get a full resync;
+	// zero keeps tombstones forever
+	TombstoneRetention time.Duration
+}
+
+const (
+	// maxSyncMutations bounds the work a single request can queue
+	maxSyncMutations = 100
+	defaultSyncLimit = 100
+	maxSyncLimit     = 500
+)
+
+type syncUseCase struct {
+	flights    FlightUseCase
+	flightRepo domain.FlightRepository
+	authz      Authorizer
+	options    SyncOptions
+}
+
+// NewSyncUseCase creates a sync use case that writes through the flight use case, so synced flights get
+// the same validation, enrichment and expiry as ones saved online
+func NewSyncUseCase(flights FlightUseCase, flightRepo domain.FlightRepository, authz Authorizer, options SyncOptions) SyncUseCase {
+	return &syncUseCase{
+		flights:    flights,
+		flightRepo: flightRepo,
+		authz:      authz,
+		options:    options,
+	}
+}
+
+// Sync stops at the first unexpected failure; mutations applied before it stay applied and show up in the
+// feed on the next sync
+func (uc *syncUseCase) Sync(ctx context.Context, actorID, ownerID, cursor string, limit int, mutations []SyncMutation) (*SyncResult, error) {
+	after, err := checkSyncRequest(cursor, &limit, mutations)
+	if err != nil {
+		return nil, err
+	}
+	action := ActionView
+	if len(mutations) > 0 {
+		action = ActionManage
+	}
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID}, action); err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{Results: make([]MutationResult, 0, len(mutations))}
+	for _, mutation := range mutations {
+		outcome, err := uc.apply(ctx, actorID, ownerID, mutation)
+		if err != nil {
+			return nil, err
+		}
+		result.Results = append(result.Results, outcome)
+	}
+
+	now := time.Now()
+	if cursor != "" && uc.options.TombstoneRetention > 0 && after.Since.Before(now.Add(-uc.options.TombstoneRetention)) {
+		// Deletes the device has not seen may already be pruned, so only the whole feed is safe
+		after, result.FullResync = syncCursor{}, true
+	}
+	// Every page of a pass carries the Since of its first read, so the pass ends no later than that read
+	// minus the lag
+	from, since := domain.ChangeCursor{UpdatedAt: after.Since}, now.Add(-uc.options.SafetyLag).UTC()
+	if after.Paging {
+		from, since = after.Page, after.Since
+	}
+
+	changes, err := uc.flightRepo.GetFlightChanges(ctx, ownerID, from, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > limit {
+		changes, result.HasMore = changes[:limit], true
+	}
+	result.Changes = changes
+	next := syncCursor{Since: since}
+	if result.HasMore {
+		last := changes[len(changes)-1]
+		next.Paging, next.Page = true, domain.ChangeCursor{UpdatedAt: last.UpdatedAt, FlightID: last.FlightID}
+	}
+	result.Cursor = encodeCursor(next)
+
+	applied := 0
+	for _, outcome := range result.Results {
+		if outcome.Status == MutationApplied {
+			applied++
+		}
+	}
+	logger().InfoContext(ctx, "flights synced", "user_id", actorID, "owner_id", ownerID,
+		"mutations", len(mutations), "applied", applied, "changes", len(changes), "full_resync", result.FullResync)
+	return result, nil
+}
+
+// apply runs one mutation through the flight use case and classifies the outcome
+func (uc *syncUseCase) apply(ctx context.Context, actorID, ownerID string, mutation SyncMutation) (MutationResult, error) {
+	outcome := MutationResult{ClientRef: mutation.ClientRef, Op: mutation.Op, FlightID: mutation.FlightID}
+
+	var err error
+	flight := mutation.Flight
+	flight.UserID = ownerID
+	switch mutation.Op {
+	case SyncCreate:
+		if existing, err := uc.createdFlight(ctx, ownerID, mutation.ClientRef); err != nil || existing != nil {
+			return retriedCreate(outcome, existing), err
+		}
+		flight.ID = ""
+		flight.ClientRef = mutation.ClientRef
+		// Like a new flight saved online without a date, it defaults to today
+		if flight.Date.IsZero() && flight.DepartureTime == nil {
+			flight.Date = time.Now()
+		}
+		err = uc.flights.AddFlight(ctx, actorID, &flight, mutation.SaveToProfile)
+		// A concurrent retry of the same create got there first
+		if errors.Is(err, domain.ErrClientRefExists) {
+			existing, err := uc.createdFlight(ctx, ownerID, mutation.ClientRef)
+			return retriedCreate(outcome, existing), err
+		}
+		outcome.FlightID = flight.ID
+	case SyncUpdate:
+		flight.ID = mutation.FlightID
+		flight.Version = mutation.Version
+		err = uc.flights.UpdateFlight(ctx, actorID, &flight, mutation.SaveToProfile)
+	case SyncDelete:
+		err = uc.flights.DeleteFlight(ctx, actorID, ownerID, mutation.FlightID, mutation.Version)
+		// Deleting a flight that is already gone leaves the device where it wanted to be
+		if errors.Is(err, domain.ErrFlightNotFound) {
+			err = nil
+		}
+	}
+
+	var domainErr *domain.Error
+	switch {
+	case err == nil:
+		outcome.Status = MutationApplied
+		if mutation.Op != SyncDelete {
+			outcome.Flight = &flight
+		}
+	case errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrFlightNotFound):
+		outcome.Status = MutationConflict
+		current, err := uc.flightRepo.GetFlightByID(ctx, ownerID, mutation.FlightID)
+		if err != nil && !errors.Is(err, domain.ErrFlightNotFound) {
+			return outcome, err
+		}
+		outcome.Flight = current
+	case errors.As(err, &domainErr) && domainErr.Kind == domain.KindInvalid:
+		outcome.Status = MutationRejected
+		outcome.Err = domainErr
+	default:
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// createdFlight finds the flight an earlier attempt of a create made, or returns nil when there is none
+func (uc *syncUseCase) createdFlight(ctx context.Context, ownerID, clientRef string) (*domain.Flight, error) {
+	if clientRef == "" {
+		return nil, nil
+	}
+	flight, err := uc.flightRepo.GetFlightByClientRef(ctx, ownerID, clientRef)
+	if errors.Is(err, domain.ErrFlightNotFound) {
+		return nil, nil
+	}
+	return flight, err
+}
+
+// retriedCreate reports a create that an earlier attempt already applied
+func retriedCreate(outcome MutationResult, existing *domain.Flight) MutationResult {
+	if existing != nil {
+		outcome.FlightID, outcome.Status, outcome.Flight = existing.ID, MutationApplied, existing
+	}
+	return outcome
+}
+
+// checkSyncRequest validates the shape of a sync request, applies the default limit and decodes the cursor
+func checkSyncRequest(cursor string, limit *int, mutations []SyncMutation) (syncCursor, error) {
+	invalid := map[string]string{}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		invalid["cursor"] = "invalid"
+	}
+	switch {
+	case *limit == 0:
+		*limit = defaultSyncLimit
+	case *limit < 0 || *limit > maxSyncLimit:
+		invalid["limit"] = "max=" + strconv.Itoa(maxSyncLimit)
+	}
+	if len(mutations) > maxSyncMutations {
+		invalid["mutations"] = "max=" + strconv.Itoa(maxSyncMutations)
+	}
+	for i, mutation := range mutations {
+		field := fmt.Sprintf("mutations[%d]", i)
+		switch mutation.Op {
+		case SyncCreate:
+		case SyncUpdate, SyncDelete:
+			if mutation.FlightID == "" {
+				invalid[field+".flight_id"] = "required"
+			}
+			if mutation.Version <= 0 {
+				invalid[field+".version"] = "required"
+			}
+		default:
+			invalid[field+".op"] = "oneof=create update delete"
+		}
+	}
+	if len(invalid) > 0 {
+		return after, domain.NewValidationError("Invalid sync request", invalid)
+	}
+	return after, nil
+}
+
+// syncCursor is what a device keeps between syncs. The next sync reads the feed from Since; while a sync
+// pages through a long feed, Paging is set and Page is the position reached
+type syncCursor struct {
+	Since  time.Time
+	Paging bool
+	Page   domain.ChangeCursor
+}
+
+// encodeCursor renders a cursor as an opaque token
+func encodeCursor(cursor syncCursor) string {
+	raw := strconv.FormatInt(cursor.Since.UnixMilli(), 10)
+	if cursor.Paging {
+		raw += ":" + strconv.FormatInt(cursor.Page.UpdatedAt.UnixMilli(), 10) + ":" + cursor.Page.FlightID
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor reads a token from encodeCursor; the empty token is the start of the feed
+func decodeCursor(token string) (syncCursor, error) {
+	if token == "" {
+		return syncCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return syncCursor{}, err
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 1 && len(parts) != 3 {
+		return syncCursor{}, errors.New("malformed sync cursor")
+	}
+	since, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return syncCursor{}, err
+	}
+	cursor := syncCursor{Since: time.UnixMilli(since).UTC()}
+	if len(parts) == 3 {
+		page, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return syncCursor{}, err
+		}
+		cursor.Paging, cursor.Page = true, domain.ChangeCursor{UpdatedAt: time.UnixMilli(page).UTC(), FlightID: parts[2]}
+	}
+	return cursor, nil
+}
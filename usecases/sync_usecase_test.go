@@ -0,0 +1,160 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+	repositories "github.com/shaloms4/Pass-Me-Core-Functionality/repositories"
+	usecases "github.com/shaloms4/Pass-Me-Core-Functionality/usecases"
+)
+
+// stubFeed serves a fixed change feed so a test can add a change stamped earlier than ones already read
+type stubFeed struct {
+	domain.FlightRepository
+	changes []domain.FlightChange
+}
+
+func (f *stubFeed) GetFlightChanges(ctx context.Context, userID string, after domain.ChangeCursor, limit int) ([]domain.FlightChange, error) {
+	var changes []domain.FlightChange
+	for _, change := range f.changes {
+		if after.After(change) {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return domain.ChangeCursor{UpdatedAt: changes[i].UpdatedAt, FlightID: changes[i].FlightID}.After(changes[j])
+	})
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+func newSyncUseCase(feed domain.FlightRepository, options usecases.SyncOptions) usecases.SyncUseCase {
+	return usecases.NewSyncUseCase(nil, feed, usecases.NewAuthorizer(usecases.OwnerRule()), options)
+}
+
+// syncAll pages through the feed from cursor and returns every change with the final cursor
+func syncAll(t *testing.T, uc usecases.SyncUseCase, cursor string, limit int) ([]domain.FlightChange, string) {
+	t.Helper()
+	var changes []domain.FlightChange
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("sync never ran out of pages")
+		}
+		result, err := uc.Sync(context.Background(), "owner", "owner", cursor, limit, nil)
+		if err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		changes, cursor = append(changes, result.Changes...), result.Cursor
+		if !result.HasMore {
+			return changes, cursor
+		}
+	}
+}
+
+func TestSyncRereadsLateCommits(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	feed := &stubFeed{changes: []domain.FlightChange{
+		{FlightID: "b", Version: 1, UpdatedAt: now.Add(-3 * time.Second)},
+		{FlightID: "c", Version: 1, UpdatedAt: now.Add(-2 * time.Second)},
+		{FlightID: "d", Version: 1, UpdatedAt: now.Add(-time.Second)},
+	}}
+	uc := newSyncUseCase(feed, usecases.SyncOptions{SafetyLag: time.Minute})
+
+	seen, cursor := syncAll(t, uc, "", 2)
+	if len(seen) != 3 {
+		t.Fatalf("first sync returned %d changes, want 3", len(seen))
+	}
+
+	// A write stamped before the last read but committed after it
+	feed.changes = append(feed.changes, domain.FlightChange{FlightID: "a", Version: 1, UpdatedAt: now.Add(-5 * time.Second)})
+	changes, _ := syncAll(t, uc, cursor, 2)
+	found := false
+	for _, change := range changes {
+		found = found || change.FlightID == "a"
+	}
+	if !found {
+		t.Errorf("second sync returned %+v, missing the late write", changes)
+	}
+}
+
+func TestSyncFullResync(t *testing.T) {
+	feed := &stubFeed{changes: []domain.FlightChange{{FlightID: "a", Version: 1, UpdatedAt: time.Unix(0, 0).UTC()}}}
+
+	// A lag longer than the retention makes every cursor already too old
+	stale := newSyncUseCase(feed, usecases.SyncOptions{SafetyLag: 48 * time.Hour, TombstoneRetention: 24 * time.Hour})
+	first, err := stale.Sync(context.Background(), "owner", "owner", "", 0, nil)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if first.FullResync || len(first.Changes) != 1 {
+		t.Fatalf("first sync = %d changes, full resync %v; want the whole feed without a resync", len(first.Changes), first.FullResync)
+	}
+	second, err := stale.Sync(context.Background(), "owner", "owner", first.Cursor, 0, nil)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if !second.FullResync || len(second.Changes) != 1 {
+		t.Errorf("sync past the retention = %d changes, full resync %v; want the whole feed again", len(second.Changes), second.FullResync)
+	}
+
+	fresh := newSyncUseCase(feed, usecases.SyncOptions{SafetyLag: time.Minute, TombstoneRetention: 24 * time.Hour})
+	next, err := fresh.Sync(context.Background(), "owner", "owner", first.Cursor, 0, nil)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if !next.FullResync {
+		t.Error("a cursor older than the retention did not ask for a full resync")
+	}
+	next, err = fresh.Sync(context.Background(), "owner", "owner", next.Cursor, 0, nil)
+	if err != nil || next.FullResync || len(next.Changes) != 0 {
+		t.Errorf("sync from a recent cursor = %d changes, full resync %v, %v; want nothing new", len(next.Changes), next.FullResync, err)
+	}
+
+	var derr *domain.Error
+	if _, err := fresh.Sync(context.Background(), "owner", "owner", "not a cursor", 0, nil); !errors.As(err, &derr) || derr.Kind != domain.KindInvalid {
+		t.Errorf("malformed cursor: got %v, want a validation error", err)
+	}
+}
+
+func TestSyncDedupesRetriedCreates(t *testing.T) {
+	ctx := context.Background()
+	users := repositories.NewMemoryUserRepository()
+	owner := &domain.User{Username: "ada", Email: "ada@example.com", Password: "hash"}
+	if err := users.CreateUser(ctx, owner); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	flightRepo := repositories.NewMemoryFlightRepository()
+	authz := usecases.NewAuthorizer(usecases.OwnerRule())
+	uc := usecases.NewSyncUseCase(usecases.NewFlightUseCase(flightRepo, users, nil, authz), flightRepo, authz,
+		usecases.SyncOptions{SafetyLag: time.Minute})
+
+	create := usecases.SyncMutation{Op: usecases.SyncCreate, ClientRef: "phone/1", Flight: domain.Flight{
+		Title: "Lisbon", FromCountry: "US", ToCountry: "PT", Date: time.Date(2026, 12, 1, 10, 0, 0, 0, time.UTC),
+		QA: []domain.QA{{Question: "Purpose of visit?", Answer: "Tourism"}},
+	}}
+	var ids []string
+	for attempt := 0; attempt < 2; attempt++ {
+		result, err := uc.Sync(ctx, owner.ID, owner.ID, "", 0, []usecases.SyncMutation{create})
+		if err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		outcome := result.Results[0]
+		if outcome.Status != usecases.MutationApplied || outcome.Flight == nil || outcome.FlightID != outcome.Flight.ID {
+			t.Fatalf("attempt %d = %+v, want the applied flight", attempt, outcome)
+		}
+		ids = append(ids, outcome.FlightID)
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("retried create made flight %s after %s", ids[1], ids[0])
+	}
+	flights, err := flightRepo.GetFlightsByUserID(ctx, owner.ID)
+	if err != nil || len(flights) != 1 {
+		t.Errorf("owner has %d flights (%v), want 1", len(flights), err)
+	}
+}
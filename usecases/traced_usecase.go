@@ -0,0 +1,463 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+var tracer = otel.Tracer("github.com/shaloms4/Pass-Me-Core-Functionality/usecases")
+
+// tracedFlightUseCase opens a span around every call to another FlightUseCase
+type tracedFlightUseCase struct {
+	next FlightUseCase
+}
+
+// NewTracedFlightUseCase wraps uc so each business operation appears as its own span
+func NewTracedFlightUseCase(uc FlightUseCase) FlightUseCase {
+	return &tracedFlightUseCase{next: uc}
+}
+
+func (t *tracedFlightUseCase) AddFlight(ctx context.Context, actorID string, flight *domain.Flight, saveToProfile bool) (err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.AddFlight", trace.WithAttributes(
+		attribute.String("actor.id", actorID),
+		attribute.String("user.id", flight.UserID),
+		attribute.Bool("save_to_profile", saveToProfile),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("flight.id", flight.ID))
+		endSpan(span, err)
+	}()
+	return t.next.AddFlight(ctx, actorID, flight, saveToProfile)
+}
+
+func (t *tracedFlightUseCase) UpdateFlight(ctx context.Context, actorID string, flight *domain.Flight, saveToProfile bool) (err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.UpdateFlight", trace.WithAttributes(
+		attribute.String("flight.id", flight.ID),
+		attribute.String("actor.id", actorID),
+		attribute.String("user.id", flight.UserID),
+		attribute.Bool("save_to_profile", saveToProfile),
+	))
+	defer func() { endSpan(span, err) }()
+	return t.next.UpdateFlight(ctx, actorID, flight, saveToProfile)
+}
+
+func (t *tracedFlightUseCase) CloneFlight(ctx context.Context, actorID, ownerID, sourceID string, overrides FlightOverrides) (clone *FlightClone, err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.CloneFlight", trace.WithAttributes(
+		attribute.String("actor.id", actorID),
+		attribute.String("user.id", ownerID),
+		attribute.String("flight.source_id", sourceID),
+	))
+	defer func() {
+		if clone != nil {
+			span.SetAttributes(attribute.String("flight.id", clone.Flight.ID), attribute.Int("flight.review_count", len(clone.Review)))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.CloneFlight(ctx, actorID, ownerID, sourceID, overrides)
+}
+
+func (t *tracedFlightUseCase) FetchFlightByID(ctx context.Context, actorID, ownerID, id string) (flight *domain.Flight, err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.FetchFlightByID", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("flight.id", id)))
+	defer func() { endSpan(span, err) }()
+	return t.next.FetchFlightByID(ctx, actorID, ownerID, id)
+}
+
+func (t *tracedFlightUseCase) DeleteFlight(ctx context.Context, actorID, ownerID, id string, version int64) (err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.DeleteFlight", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("flight.id", id),
+		attribute.Int64("flight.version", version)))
+	defer func() { endSpan(span, err) }()
+	return t.next.DeleteFlight(ctx, actorID, ownerID, id, version)
+}
+
+func (t *tracedFlightUseCase) FetchFlightsByUserID(ctx context.Context, actorID, ownerID string) (flights []domain.Flight, err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.FetchFlightsByUserID", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.FetchFlightsByUserID(ctx, actorID, ownerID)
+}
+
+func (t *tracedFlightUseCase) FetchExpiringFlights(ctx context.Context, actorID, ownerID string) (flights []domain.Flight, err error) {
+	ctx, span := tracer.Start(ctx, "FlightUseCase.FetchExpiringFlights", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.FetchExpiringFlights(ctx, actorID, ownerID)
+}
+
+// tracedUserUseCase opens a span around every call to another UserUseCase
+type tracedUserUseCase struct {
+	next UserUseCase
+}
+
+// NewTracedUserUseCase wraps uc so each business operation appears as its own span
+func NewTracedUserUseCase(uc UserUseCase) UserUseCase {
+	return &tracedUserUseCase{next: uc}
+}
+
+func (t *tracedUserUseCase) RegisterUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.RegisterUser")
+	defer func() {
+		span.SetAttributes(attribute.String("user.id", user.ID))
+		endSpan(span, err)
+	}()
+	return t.next.RegisterUser(ctx, user)
+}
+
+func (t *tracedUserUseCase) LoginUser(ctx context.Context, email, password string) (user *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.LoginUser")
+	defer func() {
+		if user != nil {
+			span.SetAttributes(attribute.String("user.id", user.ID))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.LoginUser(ctx, email, password)
+}
+
+func (t *tracedUserUseCase) GetProfile(ctx context.Context, userID string) (user *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.GetProfile", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.GetProfile(ctx, userID)
+}
+
+func (t *tracedUserUseCase) UpdateUsername(ctx context.Context, userID, newUsername string, version int64) (updated int64, err error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.UpdateUsername", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.UpdateUsername(ctx, userID, newUsername, version)
+}
+
+func (t *tracedUserUseCase) UpdatePassword(ctx context.Context, userID, oldPassword, newPassword string, version int64) (updated int64, err error) {
+	ctx, span := tracer.Start(ctx, "UserUseCase.UpdatePassword", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.UpdatePassword(ctx, userID, oldPassword, newPassword, version)
+}
+
+// tracedTripUseCase opens a span around every call to another TripUseCase
+type tracedTripUseCase struct {
+	next TripUseCase
+}
+
+// NewTracedTripUseCase wraps uc so each business operation appears as its own span
+func NewTracedTripUseCase(uc TripUseCase) TripUseCase {
+	return &tracedTripUseCase{next: uc}
+}
+
+func (t *tracedTripUseCase) AddTrip(ctx context.Context, actorID string, trip *domain.Trip) (err error) {
+	ctx, span := tracer.Start(ctx, "TripUseCase.AddTrip", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", trip.UserID), attribute.Int("trip.legs", len(trip.Legs))))
+	defer func() {
+		span.SetAttributes(attribute.String("trip.id", trip.ID))
+		endSpan(span, err)
+	}()
+	return t.next.AddTrip(ctx, actorID, trip)
+}
+
+func (t *tracedTripUseCase) FetchTripByID(ctx context.Context, actorID, ownerID, id string) (trip *domain.Trip, err error) {
+	ctx, span := tracer.Start(ctx, "TripUseCase.FetchTripByID", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("trip.id", id)))
+	defer func() { endSpan(span, err) }()
+	return t.next.FetchTripByID(ctx, actorID, ownerID, id)
+}
+
+func (t *tracedTripUseCase) FetchTripsByUserID(ctx context.Context, actorID, ownerID string) (trips []domain.Trip, err error) {
+	ctx, span := tracer.Start(ctx, "TripUseCase.FetchTripsByUserID", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.FetchTripsByUserID(ctx, actorID, ownerID)
+}
+
+func (t *tracedTripUseCase) UpdateTrip(ctx context.Context, actorID string, trip *domain.Trip) (err error) {
+	ctx, span := tracer.Start(ctx, "TripUseCase.UpdateTrip", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("trip.id", trip.ID), attribute.Int("trip.legs", len(trip.Legs))))
+	defer func() { endSpan(span, err) }()
+	return t.next.UpdateTrip(ctx, actorID, trip)
+}
+
+func (t *tracedTripUseCase) DeleteTrip(ctx context.Context, actorID, ownerID, id string, version int64) (err error) {
+	ctx, span := tracer.Start(ctx, "TripUseCase.DeleteTrip", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("trip.id", id)))
+	defer func() { endSpan(span, err) }()
+	return t.next.DeleteTrip(ctx, actorID, ownerID, id, version)
+}
+
+// tracedRetentionUseCase opens a span around every call to another RetentionUseCase
+type tracedRetentionUseCase struct {
+	next RetentionUseCase
+}
+
+// NewTracedRetentionUseCase wraps uc so each business operation appears as its own span
+func NewTracedRetentionUseCase(uc RetentionUseCase) RetentionUseCase {
+	return &tracedRetentionUseCase{next: uc}
+}
+
+func (t *tracedRetentionUseCase) SetRetention(ctx context.Context, userID string, retentionDays int, version int64) (updated int64, err error) {
+	ctx, span := tracer.Start(ctx, "RetentionUseCase.SetRetention", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.Int("retention.days", retentionDays)))
+	defer func() { endSpan(span, err) }()
+	return t.next.SetRetention(ctx, userID, retentionDays, version)
+}
+
+func (t *tracedRetentionUseCase) Sweep(ctx context.Context, now time.Time) (result RetentionSweep, err error) {
+	ctx, span := tracer.Start(ctx, "RetentionUseCase.Sweep")
+	defer func() {
+		span.SetAttributes(attribute.Int("flights.warned", result.Warned), attribute.Int("flights.purged", result.Purged),
+			attribute.Int("tombstones.pruned", result.Tombstones))
+		endSpan(span, err)
+	}()
+	return t.next.Sweep(ctx, now)
+}
+
+// tracedImportUseCase opens a span around every call to another ImportUseCase
+type tracedImportUseCase struct {
+	next ImportUseCase
+}
+
+// NewTracedImportUseCase wraps uc so each business operation appears as its own span
+func NewTracedImportUseCase(uc ImportUseCase) ImportUseCase {
+	return &tracedImportUseCase{next: uc}
+}
+
+func (t *tracedImportUseCase) ImportBoardingPass(ctx context.Context, userID, data string, image []byte) (result *BoardingPassImport, err error) {
+	ctx, span := tracer.Start(ctx, "ImportUseCase.ImportBoardingPass", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.Bool("import.image", data == "")))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(attribute.Int("import.flights", len(result.Flights)))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.ImportBoardingPass(ctx, userID, data, image)
+}
+
+func (t *tracedImportUseCase) ImportItinerary(ctx context.Context, userID string, file []byte) (result *ItineraryImport, err error) {
+	ctx, span := tracer.Start(ctx, "ImportUseCase.ImportItinerary", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.Int("import.bytes", len(file))))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(attribute.Int("import.flights", len(result.Flights)))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.ImportItinerary(ctx, userID, file)
+}
+
+// tracedCalendarUseCase opens a span around every call to another CalendarUseCase
+type tracedCalendarUseCase struct {
+	next CalendarUseCase
+}
+
+// NewTracedCalendarUseCase wraps uc so each business operation appears as its own span
+func NewTracedCalendarUseCase(uc CalendarUseCase) CalendarUseCase {
+	return &tracedCalendarUseCase{next: uc}
+}
+
+func (t *tracedCalendarUseCase) ExportFlight(ctx context.Context, actorID, ownerID, flightID string) (flight *domain.Flight, err error) {
+	ctx, span := tracer.Start(ctx, "CalendarUseCase.ExportFlight", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("flight.id", flightID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.ExportFlight(ctx, actorID, ownerID, flightID)
+}
+
+func (t *tracedCalendarUseCase) RotateCalendarToken(ctx context.Context, userID string) (token string, err error) {
+	ctx, span := tracer.Start(ctx, "CalendarUseCase.RotateCalendarToken", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.RotateCalendarToken(ctx, userID)
+}
+
+func (t *tracedCalendarUseCase) RevokeCalendarToken(ctx context.Context, userID string) (err error) {
+	ctx, span := tracer.Start(ctx, "CalendarUseCase.RevokeCalendarToken", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.RevokeCalendarToken(ctx, userID)
+}
+
+// UpcomingFlights leaves the token out of the span; it is a bearer credential
+func (t *tracedCalendarUseCase) UpcomingFlights(ctx context.Context, token string) (flights []domain.Flight, err error) {
+	ctx, span := tracer.Start(ctx, "CalendarUseCase.UpcomingFlights")
+	defer func() {
+		span.SetAttributes(attribute.Int("calendar.flights", len(flights)))
+		endSpan(span, err)
+	}()
+	return t.next.UpcomingFlights(ctx, token)
+}
+
+// tracedAnswerProfileUseCase opens a span around every call to another AnswerProfileUseCase
+type tracedAnswerProfileUseCase struct {
+	next AnswerProfileUseCase
+}
+
+// NewTracedAnswerProfileUseCase wraps uc so each business operation appears as its own span
+func NewTracedAnswerProfileUseCase(uc AnswerProfileUseCase) AnswerProfileUseCase {
+	return &tracedAnswerProfileUseCase{next: uc}
+}
+
+func (t *tracedAnswerProfileUseCase) GetAnswerProfile(ctx context.Context, actorID, ownerID string) (profile *domain.AnswerProfile, err error) {
+	ctx, span := tracer.Start(ctx, "AnswerProfileUseCase.GetAnswerProfile", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.GetAnswerProfile(ctx, actorID, ownerID)
+}
+
+func (t *tracedAnswerProfileUseCase) SaveProfileAnswers(ctx context.Context, actorID, ownerID string, answers []domain.ProfileAnswer) (profile *domain.AnswerProfile, err error) {
+	ctx, span := tracer.Start(ctx, "AnswerProfileUseCase.SaveProfileAnswers", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.Int("answer.count", len(answers))))
+	defer func() { endSpan(span, err) }()
+	return t.next.SaveProfileAnswers(ctx, actorID, ownerID, answers)
+}
+
+func (t *tracedAnswerProfileUseCase) DeleteProfileAnswer(ctx context.Context, actorID, ownerID, questionID string) (err error) {
+	ctx, span := tracer.Start(ctx, "AnswerProfileUseCase.DeleteProfileAnswer", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("question.id", questionID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.DeleteProfileAnswer(ctx, actorID, ownerID, questionID)
+}
+
+// tracedShareUseCase opens a span around every call to another ShareUseCase
+type tracedShareUseCase struct {
+	next ShareUseCase
+}
+
+// NewTracedShareUseCase wraps uc so each business operation appears as its own span
+func NewTracedShareUseCase(uc ShareUseCase) ShareUseCase {
+	return &tracedShareUseCase{next: uc}
+}
+
+func (t *tracedShareUseCase) CreateShare(ctx context.Context, actorID, ownerID, flightID, label string, expiresAt *time.Time) (link *SharedLink, err error) {
+	ctx, span := tracer.Start(ctx, "ShareUseCase.CreateShare", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("flight.id", flightID)))
+	defer func() {
+		if link != nil {
+			span.SetAttributes(attribute.String("share.id", link.Share.ID))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.CreateShare(ctx, actorID, ownerID, flightID, label, expiresAt)
+}
+
+func (t *tracedShareUseCase) ListShares(ctx context.Context, actorID, ownerID, flightID string) (links []SharedLink, err error) {
+	ctx, span := tracer.Start(ctx, "ShareUseCase.ListShares", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID), attribute.String("flight.id", flightID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.ListShares(ctx, actorID, ownerID, flightID)
+}
+
+func (t *tracedShareUseCase) RevokeShare(ctx context.Context, actorID, ownerID, flightID, shareID string) (err error) {
+	ctx, span := tracer.Start(ctx, "ShareUseCase.RevokeShare", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID),
+		attribute.String("flight.id", flightID), attribute.String("share.id", shareID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.RevokeShare(ctx, actorID, ownerID, flightID, shareID)
+}
+
+// OpenShare leaves the token out of the span; it is a bearer credential
+func (t *tracedShareUseCase) OpenShare(ctx context.Context, token string) (flight *domain.Flight, err error) {
+	ctx, span := tracer.Start(ctx, "ShareUseCase.OpenShare")
+	defer func() {
+		if flight != nil {
+			span.SetAttributes(attribute.String("flight.id", flight.ID))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.OpenShare(ctx, token)
+}
+
+// tracedHouseholdUseCase opens a span around every call to another HouseholdUseCase
+type tracedHouseholdUseCase struct {
+	next HouseholdUseCase
+}
+
+// NewTracedHouseholdUseCase wraps uc so each business operation appears as its own span
+func NewTracedHouseholdUseCase(uc HouseholdUseCase) HouseholdUseCase {
+	return &tracedHouseholdUseCase{next: uc}
+}
+
+func (t *tracedHouseholdUseCase) CreateHousehold(ctx context.Context, userID, name string) (household *domain.Household, err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.CreateHousehold", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() {
+		if household != nil {
+			span.SetAttributes(attribute.String("household.id", household.ID))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.CreateHousehold(ctx, userID, name)
+}
+
+func (t *tracedHouseholdUseCase) ListHouseholds(ctx context.Context, userID string) (households []domain.Household, err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.ListHouseholds", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.ListHouseholds(ctx, userID)
+}
+
+func (t *tracedHouseholdUseCase) GetHousehold(ctx context.Context, userID, householdID string) (household *domain.Household, err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.GetHousehold", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.String("household.id", householdID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.GetHousehold(ctx, userID, householdID)
+}
+
+func (t *tracedHouseholdUseCase) AddDependent(ctx context.Context, userID, householdID, name string) (member *domain.HouseholdMember, err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.AddDependent", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.String("household.id", householdID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.AddDependent(ctx, userID, householdID, name)
+}
+
+func (t *tracedHouseholdUseCase) InviteMember(ctx context.Context, userID, householdID, username string, role domain.HouseholdRole) (member *domain.HouseholdMember, err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.InviteMember", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.String("household.id", householdID), attribute.String("household.role", string(role))))
+	defer func() { endSpan(span, err) }()
+	return t.next.InviteMember(ctx, userID, householdID, username, role)
+}
+
+func (t *tracedHouseholdUseCase) AcceptInvitation(ctx context.Context, userID, householdID string) (err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.AcceptInvitation", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.String("household.id", householdID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.AcceptInvitation(ctx, userID, householdID)
+}
+
+func (t *tracedHouseholdUseCase) RemoveMember(ctx context.Context, userID, householdID, memberID string) (err error) {
+	ctx, span := tracer.Start(ctx, "HouseholdUseCase.RemoveMember", trace.WithAttributes(
+		attribute.String("user.id", userID), attribute.String("household.id", householdID), attribute.String("member.id", memberID)))
+	defer func() { endSpan(span, err) }()
+	return t.next.RemoveMember(ctx, userID, householdID, memberID)
+}
+
+// tracedSyncUseCase opens a span around every call to another SyncUseCase
+type tracedSyncUseCase struct {
+	next SyncUseCase
+}
+
+// NewTracedSyncUseCase wraps uc so each business operation appears as its own span
+func NewTracedSyncUseCase(uc SyncUseCase) SyncUseCase {
+	return &tracedSyncUseCase{next: uc}
+}
+
+func (t *tracedSyncUseCase) Sync(ctx context.Context, actorID, ownerID, cursor string, limit int, mutations []SyncMutation) (result *SyncResult, err error) {
+	ctx, span := tracer.Start(ctx, "SyncUseCase.Sync", trace.WithAttributes(
+		attribute.String("actor.id", actorID), attribute.String("user.id", ownerID),
+		attribute.Int("mutation.count", len(mutations)), attribute.Int("limit", limit)))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(attribute.Int("change.count", len(result.Changes)), attribute.Bool("has_more", result.HasMore),
+				attribute.Bool("full_resync", result.FullResync))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.Sync(ctx, actorID, ownerID, cursor, limit, mutations)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
+)
+
+// TripUseCase interface defines the business logic methods for multi-leg trips. Every method acts for
+// actorID on trips owned by ownerID (trip.UserID when a trip is passed in) as far as the authorizer allows
+type TripUseCase interface {
+	AddTrip(ctx context.Context, actorID string, trip *domain.Trip) error
+	FetchTripByID(ctx context.Context, actorID, ownerID, id string) (*domain.Trip, error)
+	FetchTripsByUserID(ctx context.Context, actorID, ownerID string) ([]domain.Trip, error)
+	UpdateTrip(ctx context.Context, actorID string, trip *domain.Trip) error
+	// DeleteTrip takes the version the caller last saw; zero deletes unconditionally
+	DeleteTrip(ctx context.Context, actorID, ownerID, id string, version int64) error
+}
+
+// tripUseCase implements the TripUseCase interface; single flights are presented as one-leg trips
+type tripUseCase struct {
+	tripRepo   domain.TripRepository
+	flightRepo domain.FlightRepository
+	userRepo   domain.UserRepository
+	authz      Authorizer
+}
+
+// NewTripUseCase creates a new instance of trip use case
+func NewTripUseCase(tripRepo domain.TripRepository, flightRepo domain.FlightRepository, userRepo domain.UserRepository, authz Authorizer) TripUseCase {
+	return &tripUseCase{
+		tripRepo:   tripRepo,
+		flightRepo: flightRepo,
+		userRepo:   userRepo,
+		authz:      authz,
+	}
+}
+
+// AddTrip creates a new trip, stamping its expiry from the owner's retention setting
+func (uc *tripUseCase) AddTrip(ctx context.Context, actorID string, trip *domain.Trip) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: trip.UserID}, ActionManage); err != nil {
+		return err
+	}
+	owner, err := uc.userRepo.FindUserByID(ctx, trip.UserID)
+	if err != nil {
+		return err
+	}
+	trip.ExpiresAt = domain.TripExpiry(*trip, owner.RetentionDays)
+	trip.ExpiryWarnedAt = nil
+	return uc.tripRepo.CreateTrip(ctx, trip)
+}
+
+// FetchTripByID retrieves a trip, falling back to a single flight with the same ID
+func (uc *tripUseCase) FetchTripByID(ctx context.Context, actorID, ownerID, id string) (*domain.Trip, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: id}, ActionView); err != nil {
+		return nil, err
+	}
+	trip, err := uc.tripRepo.GetTripByID(ctx, ownerID, id)
+	if !errors.Is(err, domain.ErrTripNotFound) {
+		return trip, err
+	}
+
+	flight, err := uc.flightRepo.GetFlightByID(ctx, ownerID, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrFlightNotFound) {
+			return nil, domain.ErrTripNotFound
+		}
+		return nil, err
+	}
+	legacy := domain.TripFromFlight(*flight)
+	return &legacy, nil
+}
+
+// FetchTripsByUserID retrieves the owner's trips and single flights, ordered by departure
+func (uc *tripUseCase) FetchTripsByUserID(ctx context.Context, actorID, ownerID string) ([]domain.Trip, error) {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID}, ActionView); err != nil {
+		return nil, err
+	}
+	trips, err := uc.tripRepo.GetTripsByUserID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	flights, err := uc.flightRepo.GetFlightsByUserID(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, flight := range flights {
+		trips = append(trips, domain.TripFromFlight(flight))
+	}
+
+	sort.SliceStable(trips, func(i, j int) bool {
+		if a, b := trips[i].StartDate(), trips[j].StartDate(); !a.Equal(b) {
+			return a.Before(b)
+		}
+		return trips[i].ID < trips[j].ID
+	})
+	return trips, nil
+}
+
+// UpdateTrip replaces a trip; single flights must be changed through the flight endpoints. The expiry is
+// restamped from the new last leg, and an expiry warning already sent stands only if the expiry is unchanged
+func (uc *tripUseCase) UpdateTrip(ctx context.Context, actorID string, trip *domain.Trip) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: trip.UserID, FlightID: trip.ID}, ActionManage); err != nil {
+		return err
+	}
+	existing, err := uc.tripRepo.GetTripByID(ctx, trip.UserID, trip.ID)
+	if errors.Is(err, domain.ErrTripNotFound) {
+		if _, ferr := uc.flightRepo.GetFlightByID(ctx, trip.UserID, trip.ID); ferr == nil {
+			return domain.ErrTripReadOnly
+		}
+	}
+	if err != nil {
+		return err
+	}
+	owner, err := uc.userRepo.FindUserByID(ctx, trip.UserID)
+	if err != nil {
+		return err
+	}
+	trip.ExpiresAt = domain.TripExpiry(*trip, owner.RetentionDays)
+	trip.ExpiryWarnedAt = nil
+	if sameTime(trip.ExpiresAt, existing.ExpiresAt) {
+		trip.ExpiryWarnedAt = existing.ExpiryWarnedAt
+	}
+	return uc.tripRepo.UpdateTrip(ctx, trip)
+}
+
+// DeleteTrip removes a trip, or the single flight presented under the same ID; either way a non-zero
+// version must match the one stored
+func (uc *tripUseCase) DeleteTrip(ctx context.Context, actorID, ownerID, id string, version int64) error {
+	if err := authorize(ctx, uc.authz, actorID, Resource{OwnerID: ownerID, FlightID: id}, ActionManage); err != nil {
+		return err
+	}
+	err := uc.tripRepo.DeleteTrip(ctx, ownerID, id, version)
+	if !errors.Is(err, domain.ErrTripNotFound) {
+		return err
+	}
+	if err := uc.flightRepo.DeleteFlight(ctx, ownerID, id, version); err != nil {
+		if errors.Is(err, domain.ErrFlightNotFound) {
+			return domain.ErrTripNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package usecases
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 
 	domain "github.com/shaloms4/Pass-Me-Core-Functionality/domain"
 	"golang.org/x/crypto/bcrypt"
@@ -9,88 +11,132 @@ import (
 
 // UserUseCase interface defines the business logic methods
 type UserUseCase interface {
-	RegisterUser(user *domain.User) error
-	LoginUser(email, password string) (*domain.User, error)
-	GetProfile(userID string) (*domain.User, error)
-	UpdateUsername(userID, newUsername string) error
-	UpdatePassword(userID, oldPassword, newPassword string) error
+	RegisterUser(ctx context.Context, user *domain.User) error
+	LoginUser(ctx context.Context, email, password string) (*domain.User, error)
+	GetProfile(ctx context.Context, userID string) (*domain.User, error)
+	// UpdateUsername and UpdatePassword take the user version the change was made against, zero applying
+	// it unconditionally, and return the user's new version
+	UpdateUsername(ctx context.Context, userID, newUsername string, version int64) (int64, error)
+	UpdatePassword(ctx context.Context, userID, oldPassword, newPassword string, version int64) (int64, error)
+}
+
+// UserOptions tunes the behaviour of the user use case
+type UserOptions struct {
+	BcryptCost          int
+	RegistrationEnabled bool
+	// DefaultRetentionDays is the retention setting given to new users
+	DefaultRetentionDays int
 }
 
 // userUseCase implements the UserUseCase interface
 type userUseCase struct {
 	userRepo domain.UserRepository
+	options  UserOptions
 }
 
 // NewUserUseCase creates a new instance of user use case
-func NewUserUseCase(repo domain.UserRepository) UserUseCase {
+func NewUserUseCase(repo domain.UserRepository, options UserOptions) UserUseCase {
 	return &userUseCase{
 		userRepo: repo,
+		options:  options,
 	}
 }
 
 // RegisterUser creates a new user
-func (uc *userUseCase) RegisterUser(user *domain.User) error {
+func (uc *userUseCase) RegisterUser(ctx context.Context, user *domain.User) error {
+	if !uc.options.RegistrationEnabled {
+		return domain.ErrRegistrationDisabled
+	}
+
 	// Check if user with same email already exists
-	existingUser, _ := uc.userRepo.FindUserByEmail(user.Email)
+	existingUser, err := uc.userRepo.FindUserByEmail(ctx, user.Email)
 	if existingUser != nil {
-		return errors.New("user with this email already exists")
+		return domain.ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
 	}
 
 	// Check if user with same username already exists
-	existingUser, _ = uc.userRepo.FindUserByUsername(user.Username)
+	existingUser, err = uc.userRepo.FindUserByUsername(ctx, user.Username)
 	if existingUser != nil {
-		return errors.New("username already taken")
+		return domain.ErrUsernameTaken
+	}
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), uc.options.BcryptCost)
 	if err != nil {
 		return err
 	}
 	user.Password = string(hashedPassword)
+	user.RetentionDays = uc.options.DefaultRetentionDays
 
-	// Create the user
-	return uc.userRepo.CreateUser(user)
+	// Create the user; the unique indexes catch registrations that race past the checks above
+	if err := uc.userRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	logger().InfoContext(ctx, "user registered", "user_id", user.ID)
+	return nil
 }
 
 // LoginUser authenticates a user
-func (uc *userUseCase) LoginUser(email, password string) (*domain.User, error) {
+func (uc *userUseCase) LoginUser(ctx context.Context, email, password string) (*domain.User, error) {
 	// Find user by email
-	user, err := uc.userRepo.FindUserByEmail(email)
+	user, err := uc.userRepo.FindUserByEmail(ctx, email)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logger().WarnContext(ctx, "login failed", "reason", "unknown_email")
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
 	}
 
 	// Compare passwords
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		logger().WarnContext(ctx, "login failed", "reason", "wrong_password", "user_id", user.ID)
+		return nil, domain.ErrInvalidCredentials
 	}
 
 	return user, nil
 }
 
-func (uc *userUseCase) GetProfile(userID string) (*domain.User, error) {
-	return uc.userRepo.FindUserByID(userID)
+func (uc *userUseCase) GetProfile(ctx context.Context, userID string) (*domain.User, error) {
+	return uc.userRepo.FindUserByID(ctx, userID)
 }
 
-func (uc *userUseCase) UpdateUsername(userID, newUsername string) error {
-	existingUser, _ := uc.userRepo.FindUserByUsername(newUsername)
+func (uc *userUseCase) UpdateUsername(ctx context.Context, userID, newUsername string, version int64) (int64, error) {
+	existingUser, err := uc.userRepo.FindUserByUsername(ctx, newUsername)
 	if existingUser != nil {
-		return errors.New("username already taken")
+		return 0, domain.ErrUsernameTaken
 	}
-	return uc.userRepo.UpdateUsername(userID, newUsername)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return 0, err
+	}
+	return uc.userRepo.UpdateUsername(ctx, userID, newUsername, version)
 }
 
-func (uc *userUseCase) UpdatePassword(userID, oldPassword, newPassword string) error {
-	user, err := uc.userRepo.FindUserByID(userID)
+func (uc *userUseCase) UpdatePassword(ctx context.Context, userID, oldPassword, newPassword string, version int64) (int64, error) {
+	user, err := uc.userRepo.FindUserByID(ctx, userID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword))
 	if err != nil {
-		return errors.New("incorrect old password")
+		logger().WarnContext(ctx, "password change rejected", "reason", "wrong_password", "user_id", userID)
+		return 0, domain.ErrIncorrectPassword
 	}
-	hashed, _ := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	return uc.userRepo.UpdatePassword(userID, string(hashed))
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), uc.options.BcryptCost)
+	if err != nil {
+		return 0, err
+	}
+	return uc.userRepo.UpdatePassword(ctx, userID, string(hashed), version)
+}
+
+// logger returns the default logger tagged as the use case layer
+func logger() *slog.Logger {
+	return slog.Default().With("layer", "usecases")
 }